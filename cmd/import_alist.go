@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap"
+	"github.com/OpenListTeam/OpenList/v4/internal/migrate"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// importAListCmd represents the import-alist command
+var importAListCmd = &cobra.Command{
+	Use:   "import-alist",
+	Short: "Import users, storages, metas and settings of an AList v3 instance",
+	Long: `Import the data of an AList v3 instance from its database or from a backup
+exported in its manage page. Existing users, storages and metas are kept, the items
+that need attention are listed in the report.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backup, _ := cmd.Flags().GetString("backup")
+		dbType, _ := cmd.Flags().GetString("db-type")
+		dsn, _ := cmd.Flags().GetString("db")
+		tablePrefix, _ := cmd.Flags().GetString("table-prefix")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		output, _ := cmd.Flags().GetString("report")
+		var src *migrate.Source
+		var err error
+		switch {
+		case backup != "":
+			f, err := os.Open(backup)
+			if err != nil {
+				return fmt.Errorf("failed to open backup: %+v", err)
+			}
+			src, err = migrate.LoadBackup(f)
+			_ = f.Close()
+			if err != nil {
+				return fmt.Errorf("failed to load backup: %+v", err)
+			}
+		case dsn != "":
+			if src, err = migrate.LoadDB(dbType, dsn, tablePrefix); err != nil {
+				return fmt.Errorf("failed to load database: %+v", err)
+			}
+		default:
+			return fmt.Errorf("--backup or --db is required")
+		}
+		bootstrap.Init()
+		defer bootstrap.Release()
+		report := migrate.Import(src, migrate.Options{DryRun: dryRun})
+		if output != "" {
+			data, err := utils.Json.MarshalIndent(report, "", "  ")
+			if err == nil {
+				err = os.WriteFile(output, data, 0o644)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to write report: %+v", err)
+			}
+		}
+		if !dryRun {
+			utils.Log.Infof("AList data has been imported from CLI")
+		}
+		printImportReport(report)
+		return nil
+	},
+}
+
+func printImportReport(report *migrate.Report) {
+	if report.DryRun {
+		fmt.Println("Dry run, nothing has been imported.")
+	}
+	for _, kind := range []string{migrate.KindSetting, migrate.KindUser, migrate.KindStorage, migrate.KindMeta} {
+		fmt.Printf("%ss: %d imported, %d skipped\n", kind, report.Imported[kind], report.Skipped[kind])
+	}
+	if len(report.Items) == 0 {
+		return
+	}
+	items := append([]migrate.Item(nil), report.Items...)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Kind < items[j].Kind })
+	fmt.Println("Needs attention:")
+	for _, item := range items {
+		state := "skipped"
+		if item.Imported {
+			state = "imported"
+		}
+		fmt.Printf("  [%s] %s (%s): %s\n", item.Kind, item.Name, state, item.Message)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(importAListCmd)
+	importAListCmd.Flags().StringP("backup", "b", "", "Backup file exported in the manage page of AList")
+	importAListCmd.Flags().String("db-type", "sqlite3", "Database type of AList, sqlite3, mysql or postgres")
+	importAListCmd.Flags().String("db", "", "Database file of AList for sqlite3, dsn otherwise")
+	importAListCmd.Flags().String("table-prefix", "x_", "Table prefix of the AList database")
+	importAListCmd.Flags().Bool("dry-run", false, "Only report what would be imported")
+	importAListCmd.Flags().StringP("report", "r", "", "Write the report as json to the file")
+}
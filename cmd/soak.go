@@ -0,0 +1,95 @@
+//go:build dev
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/soak"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var soakConfig = soak.Config{
+	Storage: mock.Addition{Extensions: "txt,mp4,jpg"},
+}
+
+// SoakCmd represents the soak command, it is only built with `-tags dev`
+// so that the mock driver never ships in a release
+var SoakCmd = &cobra.Command{
+	Use:   "soak",
+	Short: "Run the server against the mock driver with synthetic traffic",
+	Long: `Run the server against an in-memory database and the mock driver,
+generate listing storms, concurrent range streams and share hits,
+then print latency, throughput and runtime metrics`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		report, err := soak.Run(ctx, soakConfig)
+		if err != nil {
+			return err
+		}
+		printSoakReport(report)
+		if output, _ := cmd.Flags().GetString("output"); output != "" {
+			data, err := utils.Json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err = os.WriteFile(output, data, 0o644); err != nil {
+				return fmt.Errorf("failed to write report: %+v", err)
+			}
+			fmt.Printf("Report has been written to %s\n", output)
+		}
+		return nil
+	},
+}
+
+func printSoakReport(r *soak.Report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "SCENARIO\tREQUESTS\tERRORS\tREQ/S\tMB/S\tMIN\tAVG\tP50\tP95\tP99\tMAX\n")
+	seconds := r.Duration.Seconds()
+	for _, s := range r.Scenarios {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.1f\t%.2f\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Name, s.Requests, s.Errors,
+			float64(s.Requests)/seconds, float64(s.Bytes)/seconds/float64(utils.MB),
+			s.Min.Round(time.Microsecond), s.Avg.Round(time.Microsecond), s.P50.Round(time.Microsecond),
+			s.P95.Round(time.Microsecond), s.P99.Round(time.Microsecond), s.Max.Round(time.Microsecond))
+	}
+	_ = w.Flush()
+	for _, s := range r.Scenarios {
+		if s.LastError != "" {
+			fmt.Printf("last %s error: %s\n", s.Name, s.LastError)
+		}
+	}
+	fmt.Printf("duration: %s\n", r.Duration.Round(time.Millisecond))
+	fmt.Printf("media requests: %d, media access logs: %d\n", r.MediaRequests, r.MediaAccessLogs)
+	fmt.Printf("error logs: %d\n", r.ErrorLogs)
+	fmt.Printf("goroutines: %d, heap: %.2f MB, gc: %d\n", r.Goroutines, float64(r.HeapAlloc)/float64(utils.MB), r.NumGC)
+}
+
+func init() {
+	RootCmd.AddCommand(SoakCmd)
+	f := SoakCmd.Flags()
+	f.DurationVar(&soakConfig.Duration, "duration", 30*time.Second, "How long to generate traffic")
+	f.IntVar(&soakConfig.ListWorkers, "list-workers", 8, "Concurrent clients listing folders")
+	f.IntVar(&soakConfig.RangeWorkers, "range-workers", 8, "Concurrent clients streaming ranges through /d")
+	f.IntVar(&soakConfig.ShareWorkers, "share-workers", 4, "Concurrent clients hitting shares")
+	f.Int64Var(&soakConfig.RangeSize, "range-size", 256*1024, "Bytes of every range request, 0 reads whole files")
+	f.IntVar(&soakConfig.Shares, "shares", 10, "Number of shares to create")
+	f.BoolVar(&soakConfig.Refresh, "refresh", false, "Bypass the listing cache")
+	f.Int64Var(&soakConfig.Storage.Seed, "seed", 1, "Seed of the mock tree and the traffic")
+	f.IntVar(&soakConfig.Storage.Depth, "depth", 3, "Depth of the mock tree")
+	f.IntVar(&soakConfig.Storage.NumFolder, "folders", 5, "Folders in every mock folder")
+	f.IntVar(&soakConfig.Storage.NumFile, "files", 20, "Files in every mock folder")
+	f.Int64Var(&soakConfig.Storage.FileSize, "file-size", 16*1024*1024, "Size of the mock files")
+	f.IntVar(&soakConfig.Storage.Latency, "latency", 0, "Latency in milliseconds of every mock driver call")
+	f.Float64Var(&soakConfig.Storage.ErrorRate, "error-rate", 0, "Probability that a mock driver call fails")
+	f.StringP("output", "o", "", "Also write the report as json to this file")
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/privacy"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// userDataCmd represents the user-data command
+var userDataCmd = &cobra.Command{
+	Use:   "user-data",
+	Short: "Export or erase the data stored about a user",
+}
+
+var exportUserDataCmd = &cobra.Command{
+	Use:   "export [username]",
+	Short: "Export the data of a user to a zip archive",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return fmt.Errorf("username is required")
+		}
+		username := args[0]
+		output, _ := cmd.Flags().GetString("output")
+		if output == "" {
+			output = fmt.Sprintf("%s-%s.zip", username, time.Now().Format("20060102150405"))
+		}
+		bootstrap.Init()
+		defer bootstrap.Release()
+		user, err := op.GetUserByName(username)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %+v", err)
+		}
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %+v", err)
+		}
+		defer f.Close()
+		if err = privacy.Export(context.Background(), user, f); err != nil {
+			_ = f.Close()
+			_ = os.Remove(output)
+			return fmt.Errorf("failed to export user data: %+v", err)
+		}
+		utils.Log.Infof("Data of user [%s] has been exported from CLI", username)
+		fmt.Printf("Data of user [%s] has been exported to %s\n", username, output)
+		return nil
+	},
+}
+
+var eraseUserDataCmd = &cobra.Command{
+	Use:   "erase [username]",
+	Short: "Erase the data of a user, except the data kept by the retention policy",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return fmt.Errorf("username is required")
+		}
+		username := args[0]
+		if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+			fmt.Printf("Are you sure you want to erase the data of user [%s]? [y/N]: ", username)
+			var confirm string
+			fmt.Scanln(&confirm)
+			if confirm != "y" && confirm != "Y" {
+				fmt.Println("Erase operation cancelled.")
+				return nil
+			}
+		}
+		bootstrap.Init()
+		defer bootstrap.Release()
+		res, err := eraseUserData(username)
+		if err != nil {
+			return err
+		}
+		DelUserCacheOnline(username)
+		utils.Log.Infof("Data of user [%s] has been erased from CLI", username)
+		fmt.Printf("Erased: %s\n", strings.Join(res.Erased, ", "))
+		fmt.Printf("Retained: %s\n", strings.Join(res.Retained, ", "))
+		for _, note := range res.Notes {
+			fmt.Printf("Note: %s\n", note)
+		}
+		return nil
+	},
+}
+
+func eraseUserData(username string) (*privacy.EraseResult, error) {
+	user, err := op.GetUserByName(username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %+v", err)
+	}
+	res, err := privacy.Erase(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase user data: %+v", err)
+	}
+	return res, nil
+}
+
+func init() {
+	RootCmd.AddCommand(userDataCmd)
+	userDataCmd.AddCommand(exportUserDataCmd)
+	userDataCmd.AddCommand(eraseUserDataCmd)
+	exportUserDataCmd.Flags().StringP("output", "o", "", "Output file, defaults to <username>-<time>.zip")
+	eraseUserDataCmd.Flags().BoolP("yes", "y", false, "Erase without confirmation")
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	imodel "github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:cmd?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestEraseUserData(t *testing.T) {
+	users := make(map[string]*imodel.User)
+	for _, name := range []string{"cli_erase", "cli_keep"} {
+		u := &imodel.User{Username: name, BasePath: "/", Permission: 1 << 14, Authn: "[]"}
+		if err := op.CreateUser(u); err != nil {
+			t.Fatalf("failed create user: %+v", err)
+		}
+		t.Cleanup(func() {
+			_ = op.DeleteUserById(u.ID)
+			_ = op.DeleteSharingsByCreatorId(u.ID)
+		})
+		if _, err := op.CreateSharing(&imodel.Sharing{SharingDB: &imodel.SharingDB{}, Files: []string{"/" + name}, Creator: u}); err != nil {
+			t.Fatalf("failed create sharing: %+v", err)
+		}
+		users[name] = u
+	}
+
+	if _, err := eraseUserData("cli_missing"); err == nil {
+		t.Error("expected erasing a missing user to fail")
+	}
+	if _, err := eraseUserData("cli_erase"); err != nil {
+		t.Fatalf("failed erase user data: %+v", err)
+	}
+	if _, err := op.GetUserByName("cli_erase"); err == nil {
+		t.Error("expected the user to be deleted")
+	}
+	for name, n := range map[string]int64{"cli_erase": 0, "cli_keep": 1} {
+		if _, cnt, err := op.GetSharingsByCreatorId(users[name].ID, 1, -1); err != nil || cnt != n {
+			t.Errorf("expected %s to have %d sharings, got %d %v", name, n, cnt, err)
+		}
+	}
+	if _, err := op.GetUserByName("cli_keep"); err != nil {
+		t.Errorf("expected the other user to be kept: %+v", err)
+	}
+}
@@ -28,6 +28,7 @@ func InitClient() {
 		}),
 	).SetTLSClientConfig(&tls.Config{InsecureSkipVerify: conf.Conf.TlsInsecureSkipVerify})
 	NoRedirectClient.SetHeader("user-agent", UserAgent)
+	NoRedirectClient.SetPreRequestHook(setAuditInfo)
 	net.SetRestyProxyIfConfigured(NoRedirectClient)
 
 	RestyClient = NewRestyClient()
@@ -40,8 +41,16 @@ func NewRestyClient() *resty.Client {
 		SetRetryCount(3).
 		SetRetryResetReaders(true).
 		SetTimeout(DefaultTimeout).
-		SetTLSClientConfig(&tls.Config{InsecureSkipVerify: conf.Conf.TlsInsecureSkipVerify})
+		SetTLSClientConfig(&tls.Config{InsecureSkipVerify: conf.Conf.TlsInsecureSkipVerify}).
+		SetPreRequestHook(setAuditInfo)
 
 	net.SetRestyProxyIfConfigured(client)
 	return client
 }
+
+// setAuditInfo passes the user, request id and client ip of the request context on to the provider,
+// if the driver_audit_mode setting allows it for the host
+func setAuditInfo(_ *resty.Client, req *http.Request) error {
+	net.SetAuditInfo(req)
+	return nil
+}
@@ -0,0 +1,287 @@
+package mock
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	stdpath "path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/pkg/errors"
+)
+
+// Mock is an in-memory driver with a deterministic tree, used to test the
+// server end-to-end without a real storage provider
+type Mock struct {
+	model.Storage
+	Addition
+
+	mu      sync.Mutex
+	root    *node
+	rng     *rand.Rand
+	failOps map[string]bool
+}
+
+func (d *Mock) Config() driver.Config {
+	return config
+}
+
+func (d *Mock) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Mock) Init(ctx context.Context) error {
+	if d.RootFolderPath == "" {
+		d.RootFolderPath = "/"
+	}
+	if d.Extensions == "" {
+		d.Extensions = "txt"
+	}
+	d.failOps = make(map[string]bool)
+	for _, op := range strings.Split(d.FailOps, ",") {
+		if op = strings.TrimSpace(op); op != "" {
+			d.failOps[op] = true
+		}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rng = rand.New(rand.NewSource(d.Seed))
+	d.root = d.genTree()
+	return nil
+}
+
+func (d *Mock) Drop(ctx context.Context) error {
+	return nil
+}
+
+// Content returns the whole content of the file at path, which is the
+// path in the storage with the root folder path included
+func (d *Mock) Content(path string) ([]byte, error) {
+	d.mu.Lock()
+	n, err := d.lookup(path)
+	d.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if n.children != nil {
+		return nil, errors.WithStack(errs.NotFile)
+	}
+	return io.ReadAll(n.file())
+}
+
+// Walk calls fn for every object in the tree, parents before their children
+func (d *Mock) Walk(fn func(obj model.Obj)) {
+	d.mu.Lock()
+	var objs []model.Obj
+	var walk func(n *node)
+	walk = func(n *node) {
+		for _, c := range n.list() {
+			obj := *c.obj
+			objs = append(objs, &obj)
+			if c.children != nil {
+				walk(c)
+			}
+		}
+	}
+	walk(d.root)
+	d.mu.Unlock()
+	for _, obj := range objs {
+		fn(obj)
+	}
+}
+
+func (d *Mock) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	if err := d.fault(ctx, "list"); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n, err := d.lookupDir(dir.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	children := n.list()
+	res := make([]model.Obj, 0, len(children))
+	for _, c := range children {
+		obj := *c.obj
+		res = append(res, &obj)
+	}
+	return res, nil
+}
+
+func (d *Mock) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	if err := d.fault(ctx, "link"); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	n, err := d.lookup(file.GetPath())
+	d.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if n.children != nil {
+		return nil, errors.WithStack(errs.NotFile)
+	}
+	return &model.Link{
+		RangeReader: stream.GetRangeReaderFromMFile(n.obj.Size, n.file()),
+	}, nil
+}
+
+func (d *Mock) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) (model.Obj, error) {
+	if err := d.fault(ctx, "mkdir"); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	parent, err := d.lookupDir(parentDir.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := parent.children[dirName]; ok {
+		return nil, errors.WithStack(errs.ObjectAlreadyExists)
+	}
+	path := stdpath.Join(parent.obj.Path, dirName)
+	n := &node{
+		obj: &model.Object{
+			ID:       path,
+			Path:     path,
+			Name:     dirName,
+			Modified: time.Now(),
+			IsFolder: true,
+		},
+		children: map[string]*node{},
+	}
+	parent.children[dirName] = n
+	obj := *n.obj
+	return &obj, nil
+}
+
+func (d *Mock) Move(ctx context.Context, srcObj, dstDir model.Obj) (model.Obj, error) {
+	if err := d.fault(ctx, "move"); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.transfer(srcObj, dstDir, false)
+}
+
+func (d *Mock) Copy(ctx context.Context, srcObj, dstDir model.Obj) (model.Obj, error) {
+	if err := d.fault(ctx, "copy"); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.transfer(srcObj, dstDir, true)
+}
+
+// transfer must be called with d.mu held
+func (d *Mock) transfer(srcObj, dstDir model.Obj, isCopy bool) (model.Obj, error) {
+	src, err := d.lookup(srcObj.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	srcParent, err := d.lookupDir(stdpath.Dir(src.obj.Path))
+	if err != nil {
+		return nil, err
+	}
+	dst, err := d.lookupDir(dstDir.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	name := src.obj.Name
+	if _, ok := dst.children[name]; ok {
+		return nil, errors.WithStack(errs.ObjectAlreadyExists)
+	}
+	path := stdpath.Join(dst.obj.Path, name)
+	if isCopy {
+		src = src.clone(path)
+	} else {
+		delete(srcParent.children, name)
+		src.setPath(path)
+	}
+	dst.children[name] = src
+	obj := *src.obj
+	return &obj, nil
+}
+
+func (d *Mock) Rename(ctx context.Context, srcObj model.Obj, newName string) (model.Obj, error) {
+	if err := d.fault(ctx, "rename"); err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	src, err := d.lookup(srcObj.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	parent, err := d.lookupDir(stdpath.Dir(src.obj.Path))
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := parent.children[newName]; ok {
+		return nil, errors.WithStack(errs.ObjectAlreadyExists)
+	}
+	delete(parent.children, src.obj.Name)
+	src.obj.Name = newName
+	src.setPath(stdpath.Join(parent.obj.Path, newName))
+	parent.children[newName] = src
+	obj := *src.obj
+	return &obj, nil
+}
+
+func (d *Mock) Remove(ctx context.Context, obj model.Obj) error {
+	if err := d.fault(ctx, "remove"); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n, err := d.lookup(obj.GetPath())
+	if err != nil {
+		return err
+	}
+	parent, err := d.lookupDir(stdpath.Dir(n.obj.Path))
+	if err != nil {
+		return err
+	}
+	delete(parent.children, n.obj.Name)
+	return nil
+}
+
+func (d *Mock) Put(ctx context.Context, dstDir model.Obj, file model.FileStreamer, up driver.UpdateProgress) (model.Obj, error) {
+	if err := d.fault(ctx, "put"); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(driver.NewLimitedUploadStream(ctx, file))
+	if err != nil {
+		return nil, err
+	}
+	up(100)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dir, err := d.lookupDir(dstDir.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	path := stdpath.Join(dir.obj.Path, file.GetName())
+	n := &node{
+		obj: &model.Object{
+			ID:       path,
+			Path:     path,
+			Name:     file.GetName(),
+			Size:     int64(len(data)),
+			Modified: time.Now(),
+		},
+		data: data,
+	}
+	dir.children[n.obj.Name] = n
+	obj := *n.obj
+	return &obj, nil
+}
+
+var _ driver.Driver = (*Mock)(nil)
@@ -0,0 +1,37 @@
+package mock
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+type Addition struct {
+	driver.RootPath
+	// Seed makes the generated tree, the file contents and the injected errors reproducible
+	Seed       int64  `json:"seed" type:"number" default:"1"`
+	Depth      int    `json:"depth" type:"number" default:"2"`
+	NumFolder  int    `json:"num_folder" type:"number" default:"3"`
+	NumFile    int    `json:"num_file" type:"number" default:"5"`
+	FileSize   int64  `json:"file_size" type:"number" default:"1048576"`
+	Extensions string `json:"extensions" default:"txt,mp4,jpg" help:"comma separated, assigned to generated files in turn"`
+	// Latency in milliseconds added to every operation
+	Latency int `json:"latency" type:"number" default:"0"`
+	// ErrorRate is the probability in [0, 1] that an operation fails
+	ErrorRate float64 `json:"error_rate" type:"float" default:"0"`
+	FailOps   string  `json:"fail_ops" help:"comma separated operations that always fail: list,link,mkdir,move,rename,copy,remove,put"`
+}
+
+var config = driver.Config{
+	Name:      "Mock",
+	LocalSort: true,
+	OnlyProxy: true,
+	NoLinkURL: true,
+}
+
+// The mock driver is not part of drivers/all.go, so it is only registered
+// in builds that import it, such as tests and dev tools.
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Mock{}
+	})
+}
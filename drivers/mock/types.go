@@ -0,0 +1,108 @@
+package mock
+
+import (
+	"bytes"
+	"io"
+	stdpath "path"
+	"sort"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+type node struct {
+	obj *model.Object
+	// seed of the generated content, uploaded files keep their data instead
+	seed     uint32
+	data     []byte
+	children map[string]*node
+}
+
+func (n *node) list() []*node {
+	res := make([]*node, 0, len(n.children))
+	for _, c := range n.children {
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].obj.Name < res[j].obj.Name
+	})
+	return res
+}
+
+func (n *node) clone(path string) *node {
+	obj := *n.obj
+	obj.Path = path
+	obj.ID = path
+	c := &node{obj: &obj, seed: n.seed, data: n.data}
+	if n.children != nil {
+		c.children = make(map[string]*node, len(n.children))
+		for name, child := range n.children {
+			c.children[name] = child.clone(stdpath.Join(path, name))
+		}
+	}
+	return c
+}
+
+func (n *node) setPath(path string) {
+	n.obj.Path = path
+	n.obj.ID = path
+	for name, child := range n.children {
+		child.setPath(stdpath.Join(path, name))
+	}
+}
+
+func (n *node) file() model.File {
+	if n.data != nil {
+		return bytes.NewReader(n.data)
+	}
+	return &patternFile{seed: n.seed, size: n.obj.Size}
+}
+
+// patternFile serves the deterministic content of a generated file
+type patternFile struct {
+	seed uint32
+	size int64
+	off  int64
+}
+
+func patternByte(seed uint32, off int64) byte {
+	x := uint64(seed) ^ uint64(off)*0x9E3779B97F4A7C15
+	x ^= x >> 29
+	return byte(x)
+}
+
+func (f *patternFile) ReadAt(p []byte, off int64) (int, error) {
+	if off >= f.size {
+		return 0, io.EOF
+	}
+	n := len(p)
+	if rest := f.size - off; int64(n) > rest {
+		n = int(rest)
+	}
+	for i := 0; i < n; i++ {
+		p[i] = patternByte(f.seed, off+int64(i))
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *patternFile) Read(p []byte) (int, error) {
+	n, err := f.ReadAt(p, f.off)
+	f.off += int64(n)
+	return n, err
+}
+
+func (f *patternFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.off
+	case io.SeekEnd:
+		offset += f.size
+	}
+	if offset < 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	f.off = offset
+	return offset, nil
+}
@@ -0,0 +1,142 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// baseTime is the modified time of the generated tree, so listings don't change between runs
+var baseTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func pathSeed(seed int64, path string) uint32 {
+	h := fnv.New32a()
+	_, _ = fmt.Fprintf(h, "%d:%s", seed, path)
+	return h.Sum32()
+}
+
+func (d *Mock) genTree() *node {
+	rng := rand.New(rand.NewSource(d.Seed))
+	exts := strings.Split(d.Extensions, ",")
+	root := &node{
+		obj: &model.Object{
+			ID:       d.RootFolderPath,
+			Path:     d.RootFolderPath,
+			Name:     "root",
+			Modified: baseTime,
+			IsFolder: true,
+		},
+		children: map[string]*node{},
+	}
+	var gen func(dir *node, depth int)
+	gen = func(dir *node, depth int) {
+		for i := 0; i < d.NumFile; i++ {
+			name := fmt.Sprintf("file_%d", i)
+			if ext := strings.TrimSpace(exts[i%len(exts)]); ext != "" {
+				name += "." + ext
+			}
+			path := stdpath.Join(dir.obj.Path, name)
+			size := d.FileSize
+			if size > 1 {
+				size = size/2 + rng.Int63n(size/2+1)
+			}
+			dir.children[name] = &node{
+				obj: &model.Object{
+					ID:       path,
+					Path:     path,
+					Name:     name,
+					Size:     size,
+					Modified: baseTime.Add(time.Duration(rng.Intn(60*24*365)) * time.Minute),
+				},
+				seed: pathSeed(d.Seed, path),
+			}
+		}
+		if depth >= d.Depth {
+			return
+		}
+		for i := 0; i < d.NumFolder; i++ {
+			name := fmt.Sprintf("folder_%d", i)
+			path := stdpath.Join(dir.obj.Path, name)
+			child := &node{
+				obj: &model.Object{
+					ID:       path,
+					Path:     path,
+					Name:     name,
+					Modified: baseTime,
+					IsFolder: true,
+				},
+				children: map[string]*node{},
+			}
+			dir.children[name] = child
+			gen(child, depth+1)
+		}
+	}
+	gen(root, 1)
+	return root
+}
+
+// lookup must be called with d.mu held
+func (d *Mock) lookup(path string) (*node, error) {
+	rel := strings.TrimPrefix(utils.FixAndCleanPath(path), utils.FixAndCleanPath(d.RootFolderPath))
+	n := d.root
+	for _, name := range strings.Split(rel, "/") {
+		if name == "" {
+			continue
+		}
+		if n.children == nil {
+			return nil, errors.WithStack(errs.NotFolder)
+		}
+		child, ok := n.children[name]
+		if !ok {
+			return nil, errors.WithStack(errs.ObjectNotFound)
+		}
+		n = child
+	}
+	return n, nil
+}
+
+// lookupDir must be called with d.mu held
+func (d *Mock) lookupDir(path string) (*node, error) {
+	n, err := d.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+	if n.children == nil {
+		return nil, errors.WithStack(errs.NotFolder)
+	}
+	return n, nil
+}
+
+// fault applies the configured latency and injects errors for op
+func (d *Mock) fault(ctx context.Context, op string) error {
+	if d.Latency > 0 {
+		t := time.NewTimer(time.Duration(d.Latency) * time.Millisecond)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if d.failOps[op] {
+		return errors.Errorf("mock: %s failed", op)
+	}
+	if d.ErrorRate > 0 {
+		d.mu.Lock()
+		r := d.rng.Float64()
+		d.mu.Unlock()
+		if r < d.ErrorRate {
+			return errors.Errorf("mock: %s failed randomly", op)
+		}
+	}
+	return nil
+}
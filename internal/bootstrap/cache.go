@@ -0,0 +1,27 @@
+package bootstrap
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// LoadCache reads the cache snapshot of the last shutdown, it has to run before the storages are loaded
+func LoadCache() {
+	if !conf.Conf.CachePersist.Enable || conf.Conf.CachePersist.File == "" {
+		return
+	}
+	if err := op.LoadCache(conf.Conf.CachePersist.File); err != nil {
+		utils.Log.Warnf("failed load cache snapshot: %+v", err)
+	}
+}
+
+// SaveCache writes the caches to disk, so the next start doesn't have to ask every storage again
+func SaveCache() {
+	if !conf.Conf.CachePersist.Enable || conf.Conf.CachePersist.File == "" {
+		return
+	}
+	if err := op.SaveCache(conf.Conf.CachePersist.File); err != nil {
+		utils.Log.Errorf("failed save cache snapshot: %+v", err)
+	}
+}
@@ -155,6 +155,10 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.ReadMeAutoRender, Value: "true", Type: conf.TypeBool, Group: model.PREVIEW},
 		{Key: conf.FilterReadMeScripts, Value: "true", Type: conf.TypeBool, Group: model.PREVIEW},
 		{Key: conf.NonEFSZipEncoding, Value: "IBM437", Type: conf.TypeString, Group: model.PREVIEW},
+		{Key: conf.FileTypes, Value: `{
+	"apk": {"mime": "application/vnd.android.package-archive"},
+	"doc,docx,xls,xlsx,ppt,pptx": {"preview": "office"}
+}`, Type: conf.TypeText, Group: model.PREVIEW, Help: `json object of "ext1,ext2": {"mime", "preview", "url"}, preview is video, audio, image, text, office, external or download, url is the template of an external preview. It takes precedence over the type lists above`},
 		// global settings
 		{Key: conf.HideFiles, Value: "/\\/README.md/i", Type: conf.TypeText, Group: model.GLOBAL},
 		{Key: "package_download", Value: "true", Type: conf.TypeBool, Group: model.GLOBAL},
@@ -180,6 +184,30 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.HandleHookAfterWriting, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE},
 		{Key: conf.HandleHookRateLimit, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE},
 		{Key: conf.IgnoreSystemFiles, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `When enabled, ignores common system files during upload (.DS_Store, desktop.ini, Thumbs.db, and files starting with ._)`},
+		{Key: conf.KillSwitch, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `When enabled, guest access, public shares and unsigned downloads are rejected while logged-in users keep working`},
+		{Key: conf.KillSwitchExpireAt, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `Unix timestamp at which the kill switch turns itself off, 0 means it stays on until turned off manually`},
+		{Key: conf.ApiV1DeprecatedAt, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `Unix timestamp sent in the Deprecation header of the unversioned /api routes, 0 only marks them as deprecated`},
+		{Key: conf.ApiV1SunsetAt, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `Unix timestamp sent in the Sunset header of the unversioned /api routes, 0 omits the header`},
+		{Key: conf.DownloadPolicy, Value: "[]", Type: conf.TypeText, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `json array of {"name", "when", "action", "message", "annotations"} evaluated in order on the downloads of /d, /p, the archives and the shares, webdav and s3 are not checked, action is allow, deny or annotate`},
+		{Key: conf.AccessReviewers, Value: "", Type: conf.TypeString, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `comma separated usernames that review the grants of restricted paths together with the admin, a grant must be approved by another reviewer than the one who requested it`},
+		{Key: conf.DriverAuditMode, Value: "off", Type: conf.TypeSelect, Options: "off,header,user_agent", Group: model.GLOBAL, Flag: model.PRIVATE, Help: `pass the user, request id and client ip of a request on to the storage providers it causes requests to, as X-OpenList-* headers or appended to the user agent`},
+		{Key: conf.DriverAuditHosts, Value: "", Type: conf.TypeText, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `comma separated provider hosts allowed to receive the audit info, a host also matches its subdomains`},
+		{Key: conf.UploadInspectors, Value: "[]", Type: conf.TypeText, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `json array of {"name", "type", "url", "rules", "headers", "verdict", "max_size", "timeout"} run in order on every upload, type is icap, yara or webhook, the yara binary is yara_path of the config file`},
+		{Key: conf.UploadInspectActions, Value: `{"malicious":"reject","suspicious":"quarantine","sensitive":"reject","error":"reject"}`, Type: conf.TypeText, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `json object mapping the verdicts of the upload inspectors to allow, reject or quarantine, verdicts not listed are rejected except clean`},
+		{Key: conf.UploadQuarantinePath, Value: "", Type: conf.TypeString, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `folder the quarantined uploads are put in below their original path, quarantine rejects the upload if it is empty`},
+		{Key: conf.PrefetchHints, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `learn which files are played after each other and send Link: preload headers pointing at the next likely files with the downloads of video and audio`},
+		{Key: conf.PrefetchHintCount, Value: "2", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `max files hinted per download`},
+		{Key: conf.AbuseReportEnabled, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PUBLIC, Help: `Allow visitors to report public shares for abuse`},
+		{Key: conf.AbuseReportRateLimit, Value: "5", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `max abuse reports per IP per hour`},
+		{Key: conf.AbuseReportCaptchaVerifyUrl, Value: "", Type: conf.TypeString, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `siteverify endpoint of reCAPTCHA, hCaptcha or Turnstile, leave empty to disable captcha`},
+		{Key: conf.AbuseReportCaptchaSiteKey, Value: "", Type: conf.TypeString, Group: model.GLOBAL, Flag: model.PUBLIC},
+		{Key: conf.AbuseReportCaptchaSecret, Value: "", Type: conf.TypeString, Group: model.GLOBAL, Flag: model.PRIVATE},
+		{Key: conf.SmtpHost, Value: "", Type: conf.TypeString, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `leave empty to disable email notifications`},
+		{Key: conf.SmtpPort, Value: "587", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE},
+		{Key: conf.SmtpUsername, Value: "", Type: conf.TypeString, Group: model.GLOBAL, Flag: model.PRIVATE},
+		{Key: conf.SmtpPassword, Value: "", Type: conf.TypeString, Group: model.GLOBAL, Flag: model.PRIVATE},
+		{Key: conf.SmtpFrom, Value: "", Type: conf.TypeString, Group: model.GLOBAL, Flag: model.PRIVATE},
+		{Key: conf.UserDataRetention, Value: "access_logs", Type: conf.TypeString, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `comma separated data kept when erasing a user: tasks, ssh_keys, credentials, sharings, path_grants, link_passwords, scheduled_deletions, watch_rules, access_logs`},
 
 		// single settings
 		{Key: conf.Token, Value: token, Type: conf.TypeString, Group: model.SINGLE, Flag: model.PRIVATE},
@@ -0,0 +1,22 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+)
+
+var purgeCron *cron.Cron
+
+// InitScheduledPurge checks every minute for the scheduled deletions that are due
+func InitScheduledPurge() {
+	if purgeCron != nil {
+		purgeCron.Stop()
+	}
+	purgeCron = cron.NewCron(time.Minute)
+	purgeCron.Do(func() {
+		fs.PurgeScheduledDeletions(context.Background(), time.Now())
+	})
+}
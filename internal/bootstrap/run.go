@@ -90,8 +90,11 @@ func Start() {
 		time.Sleep(time.Duration(conf.Conf.DelayedStart) * time.Second)
 	}
 	InitOfflineDownloadTools()
+	LoadCache()
 	LoadStorages()
 	InitTaskManager()
+	InitScheduledPurge()
+	InitWatchRules()
 	if !flags.Debug && !flags.Dev {
 		gin.SetMode(gin.ReleaseMode)
 	}
@@ -353,6 +356,7 @@ func Shutdown(timeout time.Duration) {
 		}()
 	}
 	wg.Wait()
+	SaveCache()
 	utils.Log.Println("Server exit")
 	running = false
 }
@@ -0,0 +1,22 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/watch"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+)
+
+var watchCron *cron.Cron
+
+// InitWatchRules checks every minute for the watch rules that are due for a scan
+func InitWatchRules() {
+	if watchCron != nil {
+		watchCron.Stop()
+	}
+	watchCron = cron.NewCron(time.Minute)
+	watchCron.Do(func() {
+		watch.ScanDue(context.Background())
+	})
+}
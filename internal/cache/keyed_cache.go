@@ -99,3 +99,18 @@ func (c *KeyedCache[T]) GC() {
 		delete(c.entries, key)
 	}
 }
+
+// Range calls fn for the entries that expire at a known time, until fn returns false
+func (c *KeyedCache[T]) Range(fn func(key string, value T, expireAt time.Time) bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for key, entry := range c.entries {
+		exp, ok := entry.Expirable.(ExpirationTime)
+		if !ok || entry.Expired() {
+			continue
+		}
+		if !fn(key, entry.data, time.Time(exp)) {
+			return
+		}
+	}
+}
@@ -111,3 +111,20 @@ func (c *TypedCache[T]) GC() {
 		}
 	}
 }
+
+// Range calls fn for the entries that expire at a known time, until fn returns false
+func (c *TypedCache[T]) Range(fn func(key, typeKey string, value T, expireAt time.Time) bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for key, entries := range c.entries {
+		for typeKey, entry := range entries {
+			exp, ok := entry.Expirable.(ExpirationTime)
+			if !ok || entry.Expired() {
+				continue
+			}
+			if !fn(key, typeKey, entry.data, time.Time(exp)) {
+				return
+			}
+		}
+	}
+}
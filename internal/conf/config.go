@@ -106,32 +106,40 @@ type SFTP struct {
 	Listen string `json:"listen" env:"LISTEN"`
 }
 
+type CachePersist struct {
+	// Enable saves the directory and link caches on shutdown and reloads them on start
+	Enable bool   `json:"enable" env:"ENABLE"`
+	File   string `json:"file" env:"FILE"`
+}
+
 type Config struct {
-	Force                 bool        `json:"force" env:"FORCE"`
-	SiteURL               string      `json:"site_url" env:"SITE_URL"`
-	Cdn                   string      `json:"cdn" env:"CDN"`
-	JwtSecret             string      `json:"jwt_secret" env:"JWT_SECRET"`
-	TokenExpiresIn        int         `json:"token_expires_in" env:"TOKEN_EXPIRES_IN"`
-	Database              Database    `json:"database" envPrefix:"DB_"`
-	Meilisearch           Meilisearch `json:"meilisearch" envPrefix:"MEILISEARCH_"`
-	Scheme                Scheme      `json:"scheme"`
-	TempDir               string      `json:"temp_dir" env:"TEMP_DIR"`
-	BleveDir              string      `json:"bleve_dir" env:"BLEVE_DIR"`
-	DistDir               string      `json:"dist_dir"`
-	Log                   LogConfig   `json:"log" envPrefix:"LOG_"`
-	DelayedStart          int         `json:"delayed_start" env:"DELAYED_START"`
-	MaxBufferLimit        int         `json:"max_buffer_limitMB" env:"MAX_BUFFER_LIMIT_MB"`
-	MmapThreshold         int         `json:"mmap_thresholdMB" env:"MMAP_THRESHOLD_MB"`
-	MaxConnections        int         `json:"max_connections" env:"MAX_CONNECTIONS"`
-	MaxConcurrency        int         `json:"max_concurrency" env:"MAX_CONCURRENCY"`
-	TlsInsecureSkipVerify bool        `json:"tls_insecure_skip_verify" env:"TLS_INSECURE_SKIP_VERIFY"`
-	Tasks                 TasksConfig `json:"tasks" envPrefix:"TASKS_"`
-	Cors                  Cors        `json:"cors" envPrefix:"CORS_"`
-	S3                    S3          `json:"s3" envPrefix:"S3_"`
-	FTP                   FTP         `json:"ftp" envPrefix:"FTP_"`
-	SFTP                  SFTP        `json:"sftp" envPrefix:"SFTP_"`
-	LastLaunchedVersion   string      `json:"last_launched_version"`
-	ProxyAddress          string      `json:"proxy_address" env:"PROXY_ADDRESS"`
+	Force                 bool         `json:"force" env:"FORCE"`
+	SiteURL               string       `json:"site_url" env:"SITE_URL"`
+	Cdn                   string       `json:"cdn" env:"CDN"`
+	JwtSecret             string       `json:"jwt_secret" env:"JWT_SECRET"`
+	TokenExpiresIn        int          `json:"token_expires_in" env:"TOKEN_EXPIRES_IN"`
+	Database              Database     `json:"database" envPrefix:"DB_"`
+	Meilisearch           Meilisearch  `json:"meilisearch" envPrefix:"MEILISEARCH_"`
+	Scheme                Scheme       `json:"scheme"`
+	TempDir               string       `json:"temp_dir" env:"TEMP_DIR"`
+	BleveDir              string       `json:"bleve_dir" env:"BLEVE_DIR"`
+	DistDir               string       `json:"dist_dir"`
+	Log                   LogConfig    `json:"log" envPrefix:"LOG_"`
+	DelayedStart          int          `json:"delayed_start" env:"DELAYED_START"`
+	MaxBufferLimit        int          `json:"max_buffer_limitMB" env:"MAX_BUFFER_LIMIT_MB"`
+	MmapThreshold         int          `json:"mmap_thresholdMB" env:"MMAP_THRESHOLD_MB"`
+	MaxConnections        int          `json:"max_connections" env:"MAX_CONNECTIONS"`
+	MaxConcurrency        int          `json:"max_concurrency" env:"MAX_CONCURRENCY"`
+	TlsInsecureSkipVerify bool         `json:"tls_insecure_skip_verify" env:"TLS_INSECURE_SKIP_VERIFY"`
+	Tasks                 TasksConfig  `json:"tasks" envPrefix:"TASKS_"`
+	Cors                  Cors         `json:"cors" envPrefix:"CORS_"`
+	S3                    S3           `json:"s3" envPrefix:"S3_"`
+	FTP                   FTP          `json:"ftp" envPrefix:"FTP_"`
+	SFTP                  SFTP         `json:"sftp" envPrefix:"SFTP_"`
+	CachePersist          CachePersist `json:"cache_persist" envPrefix:"CACHE_PERSIST_"`
+	LastLaunchedVersion   string       `json:"last_launched_version"`
+	ProxyAddress          string       `json:"proxy_address" env:"PROXY_ADDRESS"`
+	YaraPath              string       `json:"yara_path" env:"YARA_PATH"`
 }
 
 func DefaultConfig(dataDir string) *Config {
@@ -244,7 +252,12 @@ func DefaultConfig(dataDir string) *Config {
 			Enable: false,
 			Listen: ":5222",
 		},
+		CachePersist: CachePersist{
+			Enable: false,
+			File:   filepath.Join(dataDir, "cache.json.gz"),
+		},
 		LastLaunchedVersion: "",
 		ProxyAddress:        "",
+		YaraPath:            "yara",
 	}
 }
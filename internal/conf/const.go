@@ -39,6 +39,7 @@ const (
 	ReadMeAutoRender              = "readme_autorender"
 	FilterReadMeScripts           = "filter_readme_scripts"
 	NonEFSZipEncoding             = "non_efs_zip_encoding"
+	FileTypes                     = "file_types"
 
 	// global
 	HideFiles               = "hide_files"
@@ -59,6 +60,36 @@ const (
 	HandleHookAfterWriting  = "handle_hook_after_writing"
 	HandleHookRateLimit     = "handle_hook_rate_limit"
 	IgnoreSystemFiles       = "ignore_system_files"
+	KillSwitch              = "kill_switch"
+	KillSwitchExpireAt      = "kill_switch_expire_at"
+	ApiV1DeprecatedAt       = "api_v1_deprecated_at"
+	ApiV1SunsetAt           = "api_v1_sunset_at"
+	DownloadPolicy          = "download_policy"
+	AccessReviewers         = "access_reviewers"
+	DriverAuditMode         = "driver_audit_mode"
+	DriverAuditHosts        = "driver_audit_hosts"
+	UploadInspectors        = "upload_inspectors"
+	UploadInspectActions    = "upload_inspect_actions"
+	UploadQuarantinePath    = "upload_quarantine_path"
+	PrefetchHints           = "prefetch_hints"
+	PrefetchHintCount       = "prefetch_hint_count"
+
+	// abuse report
+	AbuseReportEnabled          = "abuse_report_enabled"
+	AbuseReportRateLimit        = "abuse_report_rate_limit"
+	AbuseReportCaptchaVerifyUrl = "abuse_report_captcha_verify_url"
+	AbuseReportCaptchaSiteKey   = "abuse_report_captcha_site_key"
+	AbuseReportCaptchaSecret    = "abuse_report_captcha_secret"
+
+	// smtp
+	SmtpHost     = "smtp_host"
+	SmtpPort     = "smtp_port"
+	SmtpUsername = "smtp_username"
+	SmtpPassword = "smtp_password"
+	SmtpFrom     = "smtp_from"
+
+	// privacy
+	UserDataRetention = "user_data_retention"
 
 	// index
 	SearchIndex     = "search_index"
@@ -173,6 +204,17 @@ const (
 	IMAGE
 )
 
+// preview handlers of the file_types setting
+const (
+	PreviewVideo    = "video"
+	PreviewAudio    = "audio"
+	PreviewImage    = "image"
+	PreviewText     = "text"
+	PreviewOffice   = "office"
+	PreviewExternal = "external"
+	PreviewDownload = "download"
+)
+
 // ContextKey is the type of context keys.
 type ContextKey int8
 
@@ -191,4 +233,6 @@ const (
 	PathKey
 	SharingIDKey
 	SkipHookKey
+	APIVersionKey
+	RequestIDKey
 )
@@ -23,6 +23,24 @@ var SlicesMap = make(map[string][]string)
 var FilenameCharMap = make(map[string]string)
 var PrivacyReg []*regexp.Regexp
 
+// DriverAudit is set by the driver_audit_mode and driver_audit_hosts settings
+var DriverAudit struct {
+	Mode  string
+	Hosts []string
+}
+
+// FileType is the mapping of an extension in the file_types setting
+type FileType struct {
+	// Mime overrides the Content-Type of the downloads, empty keeps the detected one
+	Mime    string `json:"mime,omitempty"`
+	Preview string `json:"preview,omitempty"`
+	// URL is the template of an external preview, with the same variables as iframe_previews
+	URL string `json:"url,omitempty"`
+}
+
+// FileTypeMap maps lower case extensions without the dot to their FileType
+var FileTypeMap = make(map[string]FileType)
+
 var (
 	// 单个Buffer最大限制
 	MaxBufferLimit = 16 * 1024 * 1024
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetAbuseReportById(id uint) (*model.AbuseReport, error) {
+	var r model.AbuseReport
+	if err := db.First(&r, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get abuse report")
+	}
+	return &r, nil
+}
+
+// GetAbuseReports returns reports in the given status, a negative status means all of them
+func GetAbuseReports(status int, pageIndex, pageSize int) (reports []model.AbuseReport, count int64, err error) {
+	reportDB := db.Model(&model.AbuseReport{})
+	if status >= 0 {
+		reportDB = reportDB.Where("status = ?", status)
+	}
+	if err := reportDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get abuse reports count")
+	}
+	if err := reportDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&reports).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get find abuse reports")
+	}
+	return reports, count, nil
+}
+
+func GetPendingAbuseReportsBySharingId(sid string) (reports []model.AbuseReport, err error) {
+	if err := db.Where("sharing_id = ? AND status = ?", sid, model.AbuseReportPending).Find(&reports).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get pending abuse reports")
+	}
+	return reports, nil
+}
+
+func CreateAbuseReport(r *model.AbuseReport) error {
+	return errors.WithStack(db.Create(r).Error)
+}
+
+func UpdateAbuseReport(r *model.AbuseReport) error {
+	return errors.WithStack(db.Save(r).Error)
+}
+
+func DeleteAbuseReportById(id uint) error {
+	return errors.WithStack(db.Delete(&model.AbuseReport{}, id).Error)
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetClientAppById(id uint) (*model.ClientApp, error) {
+	var a model.ClientApp
+	if err := db.First(&a, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get client app")
+	}
+	return &a, nil
+}
+
+func GetClientAppByClientId(clientId string) (*model.ClientApp, error) {
+	a := model.ClientApp{ClientID: clientId}
+	if err := db.Where(a).First(&a).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find client app")
+	}
+	return &a, nil
+}
+
+func GetClientApps(pageIndex, pageSize int) (apps []model.ClientApp, count int64, err error) {
+	appDB := db.Model(&model.ClientApp{})
+	if err := appDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get client apps count")
+	}
+	if err := appDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&apps).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get find client apps")
+	}
+	return apps, count, nil
+}
+
+func CreateClientApp(a *model.ClientApp) error {
+	return errors.WithStack(db.Create(a).Error)
+}
+
+func UpdateClientApp(a *model.ClientApp) error {
+	return errors.WithStack(db.Save(a).Error)
+}
+
+func DeleteClientAppById(id uint) error {
+	return errors.WithStack(db.Delete(&model.ClientApp{}, id).Error)
+}
@@ -12,7 +12,7 @@ var db *gorm.DB
 
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.SharingDB))
+	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.SharingDB), new(model.AbuseReport), new(model.ClientApp), new(model.Group), new(model.PathGrant), new(model.ScheduledDeletion), new(model.WatchRule), new(model.WatchShare), new(model.LinkPassword))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetGroupById(id uint) (*model.Group, error) {
+	var g model.Group
+	if err := db.First(&g, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get group")
+	}
+	return &g, nil
+}
+
+func GetGroupByOwnerId(ownerId uint) (*model.Group, error) {
+	g := model.Group{OwnerID: ownerId}
+	if err := db.Where(g).First(&g).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find group")
+	}
+	return &g, nil
+}
+
+func GetGroups(pageIndex, pageSize int) (groups []model.Group, count int64, err error) {
+	groupDB := db.Model(&model.Group{})
+	if err := groupDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get groups count")
+	}
+	if err := groupDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&groups).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get find groups")
+	}
+	return groups, count, nil
+}
+
+func CreateGroup(g *model.Group) error {
+	return errors.WithStack(db.Create(g).Error)
+}
+
+func UpdateGroup(g *model.Group) error {
+	return errors.WithStack(db.Save(g).Error)
+}
+
+func DeleteGroupById(id uint) error {
+	return errors.WithStack(db.Delete(&model.Group{}, id).Error)
+}
+
+// GetUsersByGroupId returns the users of the group, the owner included
+func GetUsersByGroupId(groupId uint, pageIndex, pageSize int) (users []model.User, count int64, err error) {
+	userDB := db.Model(&model.User{}).Where(model.User{GroupID: groupId})
+	if err := userDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get users count")
+	}
+	if err := userDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&users).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get find users")
+	}
+	return users, count, nil
+}
+
+func CountUsersByGroupId(groupId uint) (int64, error) {
+	var count int64
+	err := db.Model(&model.User{}).Where(model.User{GroupID: groupId}).Count(&count).Error
+	return count, errors.Wrapf(err, "failed count users of group")
+}
+
+func groupCreators(tx *gorm.DB, groupId uint) any {
+	return tx.Model(&model.User{}).Select(columnName("id")).Where(model.User{GroupID: groupId})
+}
+
+func GetSharingsByGroupId(groupId uint, pageIndex, pageSize int) (sharings []model.SharingDB, count int64, err error) {
+	sharingDB := db.Model(&model.SharingDB{}).Where(columnName("creator_id")+" IN (?)", groupCreators(db, groupId))
+	if err := sharingDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get sharings count")
+	}
+	if err := sharingDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&sharings).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get find sharings")
+	}
+	return sharings, count, nil
+}
+
+func CountSharingsByGroupId(groupId uint) (int64, error) {
+	return countSharingsByGroupId(db, groupId)
+}
+
+func countSharingsByGroupId(tx *gorm.DB, groupId uint) (int64, error) {
+	var count int64
+	err := tx.Model(&model.SharingDB{}).Where(columnName("creator_id")+" IN (?)", groupCreators(tx, groupId)).Count(&count).Error
+	return count, errors.Wrapf(err, "failed count sharings of group")
+}
+
+func CountSharingsByCreatorId(creatorId uint) (int64, error) {
+	return countSharingsByCreatorId(db, creatorId)
+}
+
+func countSharingsByCreatorId(tx *gorm.DB, creatorId uint) (int64, error) {
+	var count int64
+	err := tx.Model(&model.SharingDB{}).Where(model.SharingDB{CreatorId: creatorId}).Count(&count).Error
+	return count, errors.Wrapf(err, "failed count sharings")
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetLinkPasswordByPath(path string) (*model.LinkPassword, error) {
+	l := model.LinkPassword{Path: path}
+	if err := db.Where(l).First(&l).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find link password")
+	}
+	return &l, nil
+}
+
+func GetLinkPasswords(pageIndex, pageSize int) (links []model.LinkPassword, count int64, err error) {
+	linkDB := db.Model(&model.LinkPassword{})
+	if err := linkDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get link passwords count")
+	}
+	if err := linkDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&links).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get find link passwords")
+	}
+	return links, count, nil
+}
+
+func SaveLinkPassword(l *model.LinkPassword) error {
+	return errors.WithStack(db.Save(l).Error)
+}
+
+func DeleteLinkPasswordById(id uint) error {
+	return errors.WithStack(db.Delete(&model.LinkPassword{}, id).Error)
+}
+
+func GetLinkPasswordsByCreatorId(creatorId uint) ([]model.LinkPassword, error) {
+	var links []model.LinkPassword
+	err := db.Where(model.LinkPassword{CreatorID: creatorId}).Find(&links).Error
+	return links, errors.Wrapf(err, "failed get link passwords of creator")
+}
+
+// ClearLinkPasswordCreator removes the creator from its link passwords, the links stay protected
+func ClearLinkPasswordCreator(creatorId uint) error {
+	return errors.WithStack(db.Model(&model.LinkPassword{}).Where(columnName("creator_id")+" = ?", creatorId).Update("creator_id", 0).Error)
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetPathGrantById(id uint) (*model.PathGrant, error) {
+	var g model.PathGrant
+	if err := db.First(&g, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get path grant")
+	}
+	return &g, nil
+}
+
+// GetPathGrants returns the grants matching the non-zero fields of filter, the latest first
+func GetPathGrants(filter model.PathGrant, pageIndex, pageSize int) (grants []model.PathGrant, count int64, err error) {
+	grantDB := db.Model(&model.PathGrant{}).Where(filter)
+	if err := grantDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get path grants count")
+	}
+	if err := grantDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&grants).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get find path grants")
+	}
+	return grants, count, nil
+}
+
+func GetApprovedPathGrantsByUserId(userId uint) ([]model.PathGrant, error) {
+	var grants []model.PathGrant
+	err := db.Where(model.PathGrant{UserID: userId, Status: model.GrantApproved}).Find(&grants).Error
+	return grants, errors.Wrapf(err, "failed get path grants of user")
+}
+
+func CreatePathGrant(g *model.PathGrant) error {
+	return errors.WithStack(db.Create(g).Error)
+}
+
+func UpdatePathGrant(g *model.PathGrant) error {
+	return errors.WithStack(db.Save(g).Error)
+}
+
+// ExpirePathGrants marks the approved grants past their expiration and the
+// pending grants requested before pendingBefore as expired
+func ExpirePathGrants(now, pendingBefore time.Time) (int64, error) {
+	res := db.Model(&model.PathGrant{}).
+		Where(columnName("status")+" = ? AND "+columnName("expires_at")+" <= ?", model.GrantApproved, now).
+		Or(columnName("status")+" = ? AND ("+columnName("requested_at")+" <= ? OR "+columnName("expires_at")+" <= ?)", model.GrantPending, pendingBefore, now).
+		Updates(map[string]any{"status": model.GrantExpired, "decided_at": now})
+	return res.RowsAffected, errors.Wrapf(res.Error, "failed expire path grants")
+}
+
+// RevokePathGrantsByUserId revokes the pending and approved grants of the user
+func RevokePathGrantsByUserId(userId uint, now time.Time) (int64, error) {
+	res := db.Model(&model.PathGrant{}).
+		Where(columnName("user_id")+" = ? AND "+columnName("status")+" IN ?", userId, []string{model.GrantPending, model.GrantApproved}).
+		Updates(map[string]any{"status": model.GrantRevoked, "decided_at": now})
+	return res.RowsAffected, errors.Wrapf(res.Error, "failed revoke path grants of user")
+}
+
+// GetPathGrantsOfUser returns the grants given to, requested by or decided by the user
+func GetPathGrantsOfUser(userId uint) ([]model.PathGrant, error) {
+	var grants []model.PathGrant
+	err := db.Where(columnName("user_id")+" = ? OR "+columnName("requested_by")+" = ? OR "+columnName("decided_by")+" = ?", userId, userId, userId).
+		Order(columnName("id")).Find(&grants).Error
+	return grants, errors.Wrapf(err, "failed get path grants of user")
+}
+
+// ErasePathGrantsOfUser deletes the grants given to the user and removes it
+// from the grants it requested or decided, they stay in the audit of the others
+func ErasePathGrantsOfUser(userId uint) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(model.PathGrant{UserID: userId}).Delete(&model.PathGrant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.PathGrant{}).Where(columnName("requested_by")+" = ?", userId).Update("requested_by", 0).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.PathGrant{}).Where(columnName("decided_by")+" = ?", userId).Update("decided_by", 0).Error
+	}))
+}
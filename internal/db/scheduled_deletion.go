@@ -0,0 +1,62 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetScheduledDeletionByPath(path string) (*model.ScheduledDeletion, error) {
+	d := model.ScheduledDeletion{Path: path}
+	if err := db.Where(d).First(&d).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find scheduled deletion")
+	}
+	return &d, nil
+}
+
+func GetScheduledDeletions(pageIndex, pageSize int) (deletions []model.ScheduledDeletion, count int64, err error) {
+	deletionDB := db.Model(&model.ScheduledDeletion{})
+	if err := deletionDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get scheduled deletions count")
+	}
+	if err := deletionDB.Order(columnName("purge_at")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&deletions).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get find scheduled deletions")
+	}
+	return deletions, count, nil
+}
+
+func GetAllScheduledDeletions() ([]model.ScheduledDeletion, error) {
+	var deletions []model.ScheduledDeletion
+	err := db.Find(&deletions).Error
+	return deletions, errors.Wrapf(err, "failed get scheduled deletions")
+}
+
+func GetDueScheduledDeletions(now time.Time) ([]model.ScheduledDeletion, error) {
+	var deletions []model.ScheduledDeletion
+	err := db.Where(columnName("purge_at")+" <= ?", now).Order(columnName("purge_at")).Find(&deletions).Error
+	return deletions, errors.Wrapf(err, "failed get due scheduled deletions")
+}
+
+func CreateScheduledDeletion(d *model.ScheduledDeletion) error {
+	return errors.WithStack(db.Create(d).Error)
+}
+
+func UpdateScheduledDeletion(d *model.ScheduledDeletion) error {
+	return errors.WithStack(db.Save(d).Error)
+}
+
+func DeleteScheduledDeletionById(id uint) error {
+	return errors.WithStack(db.Delete(&model.ScheduledDeletion{}, id).Error)
+}
+
+func GetScheduledDeletionsByCreatorId(creatorId uint) ([]model.ScheduledDeletion, error) {
+	var deletions []model.ScheduledDeletion
+	err := db.Where(model.ScheduledDeletion{CreatorID: creatorId}).Find(&deletions).Error
+	return deletions, errors.Wrapf(err, "failed get scheduled deletions of creator")
+}
+
+// ClearScheduledDeletionCreator removes the creator from its scheduled deletions, they are still purged
+func ClearScheduledDeletionCreator(creatorId uint) error {
+	return errors.WithStack(db.Model(&model.ScheduledDeletion{}).Where(columnName("creator_id")+" = ?", creatorId).Update("creator_id", 0).Error)
+}
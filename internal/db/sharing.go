@@ -4,6 +4,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 func GetSharingById(id string) (*model.SharingDB, error) {
@@ -38,25 +39,52 @@ func GetSharingsByCreatorId(creator uint, pageIndex, pageSize int) (sharings []m
 }
 
 func CreateSharing(s *model.SharingDB) (string, error) {
+	return createSharing(db, s)
+}
+
+// CreateSharingInQuota counts the sharings of the creator and of its group and creates the
+// sharing in one transaction. check gets the counts and stops the creation with an error.
+func CreateSharingInQuota(s *model.SharingDB, groupId uint, check func(count, groupCount int64) error) (id string, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		count, err := countSharingsByCreatorId(tx, s.CreatorId)
+		if err != nil {
+			return err
+		}
+		var groupCount int64
+		if groupId != 0 {
+			if groupCount, err = countSharingsByGroupId(tx, groupId); err != nil {
+				return err
+			}
+		}
+		if err = check(count, groupCount); err != nil {
+			return err
+		}
+		id, err = createSharing(tx, s)
+		return err
+	})
+	return id, err
+}
+
+func createSharing(tx *gorm.DB, s *model.SharingDB) (string, error) {
 	if s.ID == "" {
 		id := random.String(8)
 		for len(id) < 12 {
 			old := model.SharingDB{
 				ID: id,
 			}
-			if err := db.Where(old).First(&old).Error; err != nil {
+			if err := tx.Where(old).First(&old).Error; err != nil {
 				s.ID = id
-				return id, errors.WithStack(db.Create(s).Error)
+				return id, errors.WithStack(tx.Create(s).Error)
 			}
 			id += random.String(1)
 		}
 		return "", errors.New("failed find valid id")
 	} else {
 		query := model.SharingDB{ID: s.ID}
-		if err := db.Where(query).First(&query).Error; err == nil {
+		if err := tx.Where(query).First(&query).Error; err == nil {
 			return "", errors.New("sharing already exist")
 		}
-		return s.ID, errors.WithStack(db.Create(s).Error)
+		return s.ID, errors.WithStack(tx.Create(s).Error)
 	}
 }
 
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetWatchRuleById(id uint) (*model.WatchRule, error) {
+	var r model.WatchRule
+	if err := db.First(&r, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get watch rule")
+	}
+	return &r, nil
+}
+
+func GetWatchRules(pageIndex, pageSize int) (rules []model.WatchRule, count int64, err error) {
+	ruleDB := db.Model(&model.WatchRule{})
+	if err := ruleDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get watch rules count")
+	}
+	if err := ruleDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&rules).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get find watch rules")
+	}
+	return rules, count, nil
+}
+
+func GetEnabledWatchRules() ([]model.WatchRule, error) {
+	var rules []model.WatchRule
+	err := db.Where(columnName("disabled")+" = ?", false).Find(&rules).Error
+	return rules, errors.Wrapf(err, "failed get enabled watch rules")
+}
+
+func CreateWatchRule(r *model.WatchRule) error {
+	return errors.WithStack(db.Create(r).Error)
+}
+
+func UpdateWatchRule(r *model.WatchRule) error {
+	return errors.WithStack(db.Save(r).Error)
+}
+
+// DeleteWatchRuleById deletes the rule and the subfolders it has seen, the sharings are kept
+func DeleteWatchRuleById(id uint) error {
+	if err := db.Where(model.WatchShare{RuleID: id}).Delete(&model.WatchShare{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Delete(&model.WatchRule{}, id).Error)
+}
+
+func GetWatchSharesByRuleId(ruleId uint) ([]model.WatchShare, error) {
+	var shares []model.WatchShare
+	err := db.Where(model.WatchShare{RuleID: ruleId}).Find(&shares).Error
+	return shares, errors.Wrapf(err, "failed get watch shares")
+}
+
+func SaveWatchShare(s *model.WatchShare) error {
+	return errors.WithStack(db.Save(s).Error)
+}
+
+func GetWatchRulesByCreatorId(creatorId uint) ([]model.WatchRule, error) {
+	var rules []model.WatchRule
+	err := db.Where(model.WatchRule{CreatorID: creatorId}).Find(&rules).Error
+	return rules, errors.Wrapf(err, "failed get watch rules of creator")
+}
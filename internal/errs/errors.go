@@ -13,6 +13,7 @@ var (
 	RelativePath = errors.New("using relative path is not allowed")
 
 	UploadNotSupported = errors.New("upload not supported")
+	UploadRejected     = errors.New("upload rejected by the content inspection")
 	MetaNotFound       = errors.New("meta not found")
 	StorageNotFound    = errors.New("storage not found")
 	StorageNotInit     = errors.New("storage not init")
@@ -26,6 +27,9 @@ var (
 	WrongShareCode  = errors.New("wrong share code")
 	InvalidSharing  = errors.New("invalid sharing")
 	SharingNotFound = errors.New("sharing not found")
+
+	InvalidAbuseReportStatus = errors.New("invalid abuse report status")
+	AbuseReportHandled       = errors.New("abuse report has already been handled")
 )
 
 // NewErr wrap constant error with an extra message
@@ -7,4 +7,7 @@ var (
 	EmptyPassword      = errors.New("password is empty")
 	WrongPassword      = errors.New("password is incorrect")
 	DeleteAdminOrGuest = errors.New("cannot delete admin or guest")
+	ShareQuotaExceeded = errors.New("share quota exceeded")
+	GroupLimitExceeded = errors.New("exceeds the allocation of the group")
+	SameReviewer       = errors.New("a grant must be approved by another reviewer than the one who requested it")
 )
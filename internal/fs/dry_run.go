@@ -0,0 +1,221 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// DryRunChange is what an operation would change, it is computed without changing anything
+type DryRunChange struct {
+	// Action is move, copy, merge, rename or remove
+	Action  string `json:"action"`
+	SrcPath string `json:"src_path"`
+	DstPath string `json:"dst_path,omitempty"`
+	IsDir   bool   `json:"is_dir"`
+	// Files and Size count the files of the object, a folder counts all the files below it
+	Files int   `json:"files"`
+	Size  int64 `json:"size"`
+	// Partial is set if a folder below the object couldn't be listed, Files and Size miss its files then
+	Partial bool `json:"partial,omitempty"`
+	// Overwrite is set if an object exists at the destination
+	Overwrite bool `json:"overwrite"`
+	// Transfer is set if the data would be streamed through OpenList, which happens between
+	// two storages or if the driver can't move or copy by itself
+	Transfer bool `json:"transfer"`
+}
+
+func DryRunMove(ctx context.Context, srcPath, dstDirPath string) (*DryRunChange, error) {
+	return dryRunTransfer(ctx, move, srcPath, dstDirPath)
+}
+
+func DryRunCopy(ctx context.Context, srcObjPath, dstDirPath string) (*DryRunChange, error) {
+	return dryRunTransfer(ctx, copy, srcObjPath, dstDirPath)
+}
+
+func DryRunMerge(ctx context.Context, srcObjPath, dstDirPath string) (*DryRunChange, error) {
+	return dryRunTransfer(ctx, merge, srcObjPath, dstDirPath)
+}
+
+func dryRunTransfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath string) (*DryRunChange, error) {
+	srcStorage, _, err := op.GetStorageAndActualPath(srcObjPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, _, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	obj, err := Get(ctx, srcObjPath, &GetArgs{NoLog: true})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get src [%s] file", srcObjPath)
+	}
+	sameStorage := srcStorage.GetStorage() == dstStorage.GetStorage()
+	if taskType == move {
+		if model.ObjHasMask(obj, model.NoMove) {
+			return nil, errors.WithStack(errs.PermissionDenied)
+		}
+		if sameStorage && utils.PathEqual(stdpath.Dir(srcObjPath), dstDirPath) {
+			return nil, errors.New("move in place")
+		}
+	}
+	change := &DryRunChange{
+		Action:  taskType.String(),
+		SrcPath: srcObjPath,
+		DstPath: stdpath.Join(dstDirPath, obj.GetName()),
+		IsDir:   obj.IsDir(),
+	}
+	if err = countFiles(ctx, change, srcObjPath, obj); err != nil {
+		return nil, err
+	}
+	if dst, _ := Get(ctx, change.DstPath, &GetArgs{NoLog: true}); dst != nil {
+		change.Overwrite = true
+	}
+	change.Transfer = !sameStorage || !canTransferInStorage(srcStorage, taskType)
+	return change, nil
+}
+
+// canTransferInStorage reports whether the driver moves or copies within the storage by itself
+func canTransferInStorage(storage driver.Driver, taskType taskType) bool {
+	if taskType == move {
+		_, ok := storage.(driver.Move)
+		_, okResult := storage.(driver.MoveResult)
+		return ok || okResult
+	}
+	_, ok := storage.(driver.Copy)
+	_, okResult := storage.(driver.CopyResult)
+	return ok || okResult
+}
+
+func DryRunRename(ctx context.Context, srcPath, dstName string) (*DryRunChange, error) {
+	obj, err := Get(ctx, srcPath, &GetArgs{NoLog: true})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get src [%s] file", srcPath)
+	}
+	if model.ObjHasMask(obj, model.NoRename) {
+		return nil, errors.WithStack(errs.PermissionDenied)
+	}
+	change := &DryRunChange{
+		Action:  "rename",
+		SrcPath: srcPath,
+		DstPath: stdpath.Join(stdpath.Dir(srcPath), dstName),
+		IsDir:   obj.IsDir(),
+	}
+	if change.DstPath != srcPath {
+		if dst, _ := Get(ctx, change.DstPath, &GetArgs{NoLog: true}); dst != nil {
+			change.Overwrite = true
+		}
+	}
+	if err = countFiles(ctx, change, srcPath, obj); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// DryRunRemove returns nil if the object doesn't exist, removing it would do nothing then
+func DryRunRemove(ctx context.Context, path string) (*DryRunChange, error) {
+	if utils.PathEqual(path, "/") {
+		return nil, errors.New("delete root folder is not allowed")
+	}
+	obj, err := Get(ctx, path, &GetArgs{NoLog: true})
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.WithMessage(err, "failed to get object")
+	}
+	if model.ObjHasMask(obj, model.NoRemove) {
+		return nil, errors.WithStack(errs.PermissionDenied)
+	}
+	change := &DryRunChange{
+		Action:  "remove",
+		SrcPath: path,
+		IsDir:   obj.IsDir(),
+	}
+	if err = countFiles(ctx, change, path, obj); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// countFiles walks a folder to add its files to change, a file counts itself.
+// A folder that can't be listed marks the change as partial, only the context ends the walk.
+func countFiles(ctx context.Context, change *DryRunChange, path string, obj model.Obj) error {
+	if !obj.IsDir() {
+		change.Files++
+		change.Size += obj.GetSize()
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+	objs, err := listWithMeta(ctx, path)
+	if err != nil {
+		change.Partial = true
+		return nil
+	}
+	for _, o := range objs {
+		if err = countFiles(ctx, change, stdpath.Join(path, o.GetName()), o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DryRunRemoveEmptyDirectories returns the folders below dir that hold no files,
+// which are the ones removing the empty directories of dir would remove
+func DryRunRemoveEmptyDirectories(ctx context.Context, dir string) ([]DryRunChange, error) {
+	objs, err := listWithMeta(ctx, dir)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed list [%s]", dir)
+	}
+	changes := []DryRunChange{}
+	for _, obj := range objs {
+		if obj.IsDir() {
+			if _, err = dryRunRemoveEmpty(ctx, stdpath.Join(dir, obj.GetName()), &changes); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return changes, nil
+}
+
+// dryRunRemoveEmpty adds the folders of path holding no files to changes, it reports whether path is one of them
+func dryRunRemoveEmpty(ctx context.Context, path string, changes *[]DryRunChange) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, errors.WithStack(err)
+	}
+	objs, err := listWithMeta(ctx, path)
+	if err != nil {
+		return false, errors.WithMessagef(err, "failed list [%s]", path)
+	}
+	empty := true
+	for _, obj := range objs {
+		if !obj.IsDir() {
+			empty = false
+			continue
+		}
+		removed, err := dryRunRemoveEmpty(ctx, stdpath.Join(path, obj.GetName()), changes)
+		if err != nil {
+			return false, err
+		}
+		empty = empty && removed
+	}
+	if empty {
+		*changes = append(*changes, DryRunChange{Action: "remove", SrcPath: path, IsDir: true})
+	}
+	return empty, nil
+}
+
+// listWithMeta lists path with its nearest meta in the context, like WalkFS does
+func listWithMeta(ctx context.Context, path string) ([]model.Obj, error) {
+	meta, _ := op.GetNearestMeta(path)
+	return List(context.WithValue(ctx, conf.MetaKey, meta), path, &ListArgs{NoLog: true})
+}
@@ -0,0 +1,53 @@
+package fs_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+)
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	mount(t, "/dry_a", `{"seed":1,"depth":2,"num_folder":1,"num_file":2,"file_size":1,"extensions":"txt"}`)
+	mount(t, "/dry_b", `{"seed":2,"depth":1,"num_folder":0,"num_file":1,"file_size":1,"extensions":"txt"}`)
+
+	change, err := fs.DryRunRemove(ctx, "/dry_a/folder_0")
+	if err != nil || change == nil || !change.IsDir || change.Files != 2 || change.Size != 2 {
+		t.Errorf("expected the files below the folder to be counted, got %+v %v", change, err)
+	}
+	if change, err = fs.DryRunRemove(ctx, "/dry_a/missing"); err != nil || change != nil {
+		t.Errorf("expected removing a missing object to change nothing, got %+v %v", change, err)
+	}
+	if _, err = fs.DryRunRemove(ctx, "/"); err == nil {
+		t.Error("expected removing the root folder to be rejected")
+	}
+
+	change, err = fs.DryRunMove(ctx, "/dry_a/file_0.txt", "/dry_b")
+	if err != nil || !change.Transfer || !change.Overwrite || change.DstPath != "/dry_b/file_0.txt" {
+		t.Errorf("expected a move between storages to be a transfer, got %+v %v", change, err)
+	}
+	change, err = fs.DryRunMove(ctx, "/dry_a/file_1.txt", "/dry_a/folder_0")
+	if err != nil || change.Transfer || !change.Overwrite {
+		t.Errorf("expected a move within the storage to overwrite without transfer, got %+v %v", change, err)
+	}
+	if _, err = fs.DryRunMove(ctx, "/dry_a/file_1.txt", "/dry_a"); err == nil {
+		t.Error("expected a move in place to be rejected")
+	}
+	change, err = fs.DryRunCopy(ctx, "/dry_a/folder_0", "/dry_b")
+	if err != nil || change.Action != "copy" || change.Overwrite || change.Files != 2 {
+		t.Errorf("unexpected dry run of copy: %+v %v", change, err)
+	}
+
+	change, err = fs.DryRunRename(ctx, "/dry_a/file_0.txt", "file_1.txt")
+	if err != nil || change.DstPath != "/dry_a/file_1.txt" || !change.Overwrite {
+		t.Errorf("expected the rename to overwrite file_1.txt, got %+v %v", change, err)
+	}
+
+	// nothing was changed
+	for _, path := range []string{"/dry_a/folder_0", "/dry_a/file_0.txt", "/dry_a/file_1.txt"} {
+		if _, err = fs.Get(ctx, path, &fs.GetArgs{NoLog: true}); err != nil {
+			t.Errorf("expected %s to be kept: %+v", path, err)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/inspect"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
@@ -193,7 +194,8 @@ func PutURL(ctx context.Context, path, dstName, urlStr string) error {
 	}
 	_, ok := storage.(driver.PutURL)
 	_, okResult := storage.(driver.PutURLResult)
-	if !ok && !okResult {
+	// the storage would fetch the url itself, download it here so the upload inspectors see it
+	if (!ok && !okResult) || inspect.Enabled() {
 		return errs.NotImplement
 	}
 	return op.PutURL(ctx, storage, dstDirActualPath, dstName, urlStr)
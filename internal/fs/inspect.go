@@ -0,0 +1,77 @@
+package fs
+
+import (
+	"context"
+	"io"
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/inspect"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// inspectUpload runs the upload inspectors on the file before it is put. It returns the
+// dir the file goes to, which is below the quarantine folder if a verdict asks for it.
+func inspectUpload(ctx context.Context, dstDirPath string, file model.FileStreamer) (string, error) {
+	if !inspect.Enabled() {
+		return dstDirPath, nil
+	}
+	content, err := file.CacheFullAndWriter(nil, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to cache the file to inspect")
+	}
+	size, err := content.Seek(0, io.SeekEnd)
+	if err == nil {
+		_, err = content.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	f := &inspect.File{
+		Path:     stdpath.Join(dstDirPath, file.GetName()),
+		Size:     size,
+		Mimetype: file.GetMimetype(),
+		Content:  content,
+	}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		f.User = user.Username
+	}
+	d := inspect.Check(ctx, f)
+	switch d.Action {
+	case inspect.ActionAllow:
+		return dstDirPath, nil
+	case inspect.ActionQuarantine:
+		if quarantine := setting.GetStr(conf.UploadQuarantinePath); quarantine != "" {
+			log.Warnf("upload [%s] of %s is quarantined: %s", f.Path, f.User, d.Reason())
+			return stdpath.Join(quarantine, dstDirPath), nil
+		}
+	}
+	log.Warnf("upload [%s] of %s is rejected: %s", f.Path, f.User, d.Reason())
+	return "", errors.WithMessage(errs.UploadRejected, d.Reason())
+}
+
+// PutInspected puts the file with op.Put after the upload inspectors passed it, for the uploads
+// that don't go through PutDirectly or PutAsTask, such as offline downloads and decompressions
+func PutInspected(ctx context.Context, storage driver.Driver, dstDirActualPath string, file model.FileStreamer, up driver.UpdateProgress) error {
+	if inspect.Enabled() {
+		dstDirPath := stdpath.Join(storage.GetStorage().MountPath, dstDirActualPath)
+		dirPath, err := inspectUpload(ctx, dstDirPath, file)
+		if err != nil {
+			_ = file.Close()
+			return err
+		}
+		if dirPath != dstDirPath {
+			if storage, dstDirActualPath, err = op.GetStorageAndActualPath(dirPath); err != nil {
+				_ = file.Close()
+				return errors.WithMessage(err, "failed get quarantine storage")
+			}
+		}
+	}
+	return op.Put(ctx, storage, dstDirActualPath, file, up)
+}
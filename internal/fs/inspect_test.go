@@ -0,0 +1,137 @@
+package fs_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/inspect"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/pkg/errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:fs?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	if conf.Conf.TempDir, err = os.MkdirTemp("", "openlist-fs-test"); err != nil {
+		panic(err)
+	}
+	db.Init(dB)
+}
+
+// contentInspector finds the words of the verdicts in the files
+type contentInspector struct{}
+
+func (contentInspector) Inspect(ctx context.Context, file *inspect.File) (inspect.Result, error) {
+	content, err := io.ReadAll(file.Reader())
+	if err != nil {
+		return inspect.Result{}, err
+	}
+	for _, verdict := range []string{inspect.VerdictMalicious, inspect.VerdictSensitive} {
+		if strings.Contains(string(content), verdict) {
+			return inspect.Result{Verdict: verdict}, nil
+		}
+	}
+	return inspect.Result{Verdict: inspect.VerdictClean}, nil
+}
+
+const emptyMock = `{"seed":1,"depth":1,"num_folder":0,"num_file":0}`
+
+func mount(t *testing.T, mountPath, addition string) {
+	t.Helper()
+	id, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Mock",
+		MountPath: mountPath,
+		Addition:  addition,
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteStorageById(context.Background(), id)
+	})
+}
+
+func setSetting(t *testing.T, key, value string) {
+	t.Helper()
+	if err := op.SaveSettingItem(&model.SettingItem{Key: key, Value: value}); err != nil {
+		t.Fatalf("failed save setting %s: %+v", key, err)
+	}
+	t.Cleanup(func() {
+		_ = op.SaveSettingItem(&model.SettingItem{Key: key, Value: ""})
+	})
+}
+
+func TestPutInspected(t *testing.T) {
+	ctx := context.Background()
+	mount(t, "/up", emptyMock)
+	mount(t, "/quarantine", emptyMock)
+	inspect.RegisterType("content", func(cfg inspect.Config) (inspect.Inspector, error) {
+		return contentInspector{}, nil
+	})
+	setSetting(t, conf.UploadInspectors, `[{"type":"content"}]`)
+	setSetting(t, conf.UploadInspectActions, `{"sensitive":"quarantine"}`)
+	setSetting(t, conf.UploadQuarantinePath, "/quarantine")
+
+	storage, actualPath, err := op.GetStorageAndActualPath("/up")
+	if err != nil {
+		t.Fatalf("failed get storage: %+v", err)
+	}
+	put := func(name, content string) error {
+		return fs.PutInspected(ctx, storage, actualPath, &stream.FileStream{
+			Ctx: ctx,
+			Obj: &model.Object{
+				Name:     name,
+				Size:     int64(len(content)),
+				Modified: time.Now(),
+			},
+			Reader: strings.NewReader(content),
+		}, nil)
+	}
+	if err = put("clean.txt", "hello"); err != nil {
+		t.Errorf("expected a clean file to be put: %+v", err)
+	}
+	if err = put("virus.txt", "malicious"); !errors.Is(err, errs.UploadRejected) {
+		t.Errorf("expected a malicious file to be rejected, got %v", err)
+	}
+	if err = put("secret.txt", "sensitive"); err != nil {
+		t.Errorf("expected a sensitive file to be quarantined: %+v", err)
+	}
+	names := func(path string) map[string]bool {
+		objs, _ := fs.List(ctx, path, &fs.ListArgs{Refresh: true, NoLog: true})
+		m := make(map[string]bool)
+		for _, obj := range objs {
+			m[obj.GetName()] = true
+		}
+		return m
+	}
+	if up := names("/up"); !up["clean.txt"] || up["virus.txt"] || up["secret.txt"] {
+		t.Errorf("unexpected files put: %v", up)
+	}
+	if !names("/quarantine/up")["secret.txt"] {
+		t.Error("expected secret.txt to be put in the quarantine folder")
+	}
+
+	// the content of direct uploads and of urls fetched by the storage can't be inspected
+	if _, err = fs.GetDirectUploadInfo(ctx, "tool", "/up", "direct.txt", 1); !errors.Is(err, errs.UploadRejected) {
+		t.Errorf("expected direct uploads to be refused, got %v", err)
+	}
+	if err = fs.PutURL(ctx, "/up", "url.txt", "http://127.0.0.1/url.txt"); !errors.Is(err, errs.NotImplement) {
+		t.Errorf("expected urls to be downloaded by the server, got %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package fs
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	log "github.com/sirupsen/logrus"
+)
+
+// PurgeScheduledDeletions removes the objects whose scheduled deletion is due at now
+func PurgeScheduledDeletions(ctx context.Context, now time.Time) {
+	deletions, err := op.GetDueScheduledDeletions(now)
+	if err != nil {
+		log.Errorf("failed get due scheduled deletions: %+v", err)
+		return
+	}
+	for i := range deletions {
+		d := &deletions[i]
+		// a missing object counts as removed
+		err := Remove(ctx, d.Path)
+		if err != nil {
+			log.Errorf("failed purge %s scheduled at %s: %+v", d.Path, d.PurgeAt.Format(time.RFC3339), err)
+		} else {
+			log.Infof("purged %s scheduled at %s", d.Path, d.PurgeAt.Format(time.RFC3339))
+		}
+		if err = op.FinishScheduledDeletion(d, err); err != nil {
+			log.Errorf("failed update scheduled deletion of %s: %+v", d.Path, err)
+		}
+	}
+}
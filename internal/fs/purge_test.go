@@ -0,0 +1,54 @@
+package fs_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestPurgeScheduledDeletions(t *testing.T) {
+	ctx := context.Background()
+	mount(t, "/purge", `{"seed":1,"depth":1,"num_folder":0,"num_file":2,"file_size":16,"extensions":"txt"}`)
+	purgeAt := time.Now().Add(time.Hour)
+	for path, at := range map[string]time.Time{
+		"/purge/file_0.txt":   purgeAt,
+		"/purge/file_1.txt":   purgeAt.Add(time.Hour),
+		"/purge_missing/file": purgeAt,
+	} {
+		if err := op.ScheduleDeletion(&model.ScheduledDeletion{Path: path, PurgeAt: at}); err != nil {
+			t.Fatalf("failed schedule deletion: %+v", err)
+		}
+	}
+	t.Cleanup(func() {
+		for _, path := range []string{"/purge/file_0.txt", "/purge/file_1.txt", "/purge_missing/file"} {
+			_ = op.CancelScheduledDeletion(path)
+		}
+	})
+	exists := func(path string) bool {
+		_, err := fs.Get(ctx, path, &fs.GetArgs{NoLog: true})
+		return err == nil
+	}
+
+	fs.PurgeScheduledDeletions(ctx, time.Now())
+	if !exists("/purge/file_0.txt") {
+		t.Fatal("expected nothing to be purged before the purge date")
+	}
+	fs.PurgeScheduledDeletions(ctx, purgeAt.Add(time.Second))
+	if exists("/purge/file_0.txt") {
+		t.Error("expected file_0.txt to be purged")
+	}
+	if _, ok := op.GetScheduledDeletion("/purge/file_0.txt"); ok {
+		t.Error("expected the schedule of a purged file to be done")
+	}
+	if !exists("/purge/file_1.txt") {
+		t.Error("expected file_1.txt to be kept until its own purge date")
+	}
+	// a failed purge keeps the schedule, so it's retried on the next run
+	if d, ok := op.GetScheduledDeletion("/purge_missing/file"); !ok || d.LastError == "" {
+		t.Errorf("expected the failed purge to be recorded, got %+v", d)
+	}
+}
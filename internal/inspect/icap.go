@@ -0,0 +1,118 @@
+package inspect
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	stdpath "path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// icap sends the file to an ICAP server (RFC 3507) as the body of a RESPMOD request.
+// The server answers 204 if the file is clean, any modification of it is a finding.
+type icap struct {
+	cfg  Config
+	host string
+}
+
+func newICAP(cfg Config) (Inspector, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if u.Scheme != "icap" || u.Hostname() == "" {
+		return nil, errors.New("url must be icap://host[:port]/service")
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "1344")
+	}
+	return &icap{cfg: cfg, host: host}, nil
+}
+
+// icapFindingHeaders are the headers ICAP servers use to name what they found
+var icapFindingHeaders = []string{"X-Infection-Found", "X-Violations-Found", "X-Virus-Id", "X-Blocked-Reason"}
+
+func (i *icap) Inspect(ctx context.Context, file *File) (Result, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", i.host)
+	if err != nil {
+		return Result{}, errors.WithStack(err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	reqHdr := fmt.Sprintf("GET /%s HTTP/1.1\r\nHost: openlist\r\n\r\n", url.PathEscape(stdpath.Base(file.Path)))
+	mimetype := file.Mimetype
+	if mimetype == "" {
+		mimetype = "application/octet-stream"
+	}
+	resHdr := fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Type: %s\r\nContent-Length: %d\r\n\r\n", mimetype, file.Size)
+	w := bufio.NewWriter(conn)
+	fmt.Fprintf(w, "RESPMOD %s ICAP/1.0\r\n", i.cfg.URL)
+	fmt.Fprintf(w, "Host: %s\r\n", i.host)
+	fmt.Fprintf(w, "Allow: 204\r\n")
+	fmt.Fprintf(w, "Encapsulated: req-hdr=0, res-hdr=%d, res-body=%d\r\n\r\n", len(reqHdr), len(reqHdr)+len(resHdr))
+	w.WriteString(reqHdr)
+	w.WriteString(resHdr)
+	// the body is sent chunked
+	buf := make([]byte, 64*1024)
+	r := file.Reader()
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			fmt.Fprintf(w, "%x\r\n", n)
+			w.Write(buf[:n])
+			w.WriteString("\r\n")
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Result{}, errors.WithStack(err)
+		}
+	}
+	w.WriteString("0\r\n\r\n")
+	if err = w.Flush(); err != nil {
+		return Result{}, errors.WithStack(err)
+	}
+
+	tp := textproto.NewReader(bufio.NewReader(conn))
+	status, err := tp.ReadLine()
+	if err != nil {
+		return Result{}, errors.WithStack(err)
+	}
+	header, err := tp.ReadMIMEHeader()
+	if err != nil && header == nil {
+		return Result{}, errors.WithStack(err)
+	}
+	fields := strings.Fields(status)
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "ICAP/") {
+		return Result{}, errors.Errorf("invalid icap response [%s]", status)
+	}
+	code, _ := strconv.Atoi(fields[1])
+	switch code {
+	case 204:
+		return Result{Verdict: VerdictClean}, nil
+	case 200:
+		reason := "the icap server modified the content"
+		for _, h := range icapFindingHeaders {
+			if v := header.Get(h); v != "" {
+				reason = v
+				break
+			}
+		}
+		return Result{Verdict: i.cfg.Verdict, Reason: reason}, nil
+	default:
+		return Result{}, errors.Errorf("icap server responded [%s]", status)
+	}
+}
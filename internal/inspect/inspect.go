@@ -0,0 +1,245 @@
+// Package inspect runs the content inspectors of the upload path, antivirus and
+// DLP scanners reached through ICAP, YARA rules or a webhook, and maps their
+// verdicts to the action taken on the upload.
+package inspect
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	VerdictClean      = "clean"
+	VerdictMalicious  = "malicious"
+	VerdictSuspicious = "suspicious"
+	VerdictSensitive  = "sensitive"
+	// VerdictError is the verdict of an inspector that failed
+	VerdictError = "error"
+)
+
+const (
+	ActionAllow      = "allow"
+	ActionReject     = "reject"
+	ActionQuarantine = "quarantine"
+)
+
+// File is an upload being inspected, it is fully cached so inspectors may read it from any offset
+type File struct {
+	// Path is the full path the file is uploaded to
+	Path     string
+	Size     int64
+	Mimetype string
+	User     string
+	Content  io.ReaderAt
+}
+
+// Reader returns a reader of the content from the start
+func (f *File) Reader() io.Reader {
+	return io.NewSectionReader(f.Content, 0, f.Size)
+}
+
+type Result struct {
+	Inspector string `json:"inspector"`
+	Verdict   string `json:"verdict"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type Inspector interface {
+	Inspect(ctx context.Context, file *File) (Result, error)
+}
+
+// Config is an entry of the upload_inspectors setting
+type Config struct {
+	Name string `json:"name"`
+	// Type is icap, yara or webhook, or a type added with RegisterType
+	Type string `json:"type"`
+	// URL is the icap://host:port/service of icap and the endpoint of webhook
+	URL string `json:"url"`
+	// Rules is the rules file of yara, the binary is yara_path of the config file
+	Rules string `json:"rules"`
+	// Headers are added to the webhook requests
+	Headers map[string]string `json:"headers"`
+	// Verdict is reported when icap or yara finds something, it defaults to malicious
+	Verdict string `json:"verdict"`
+	// MaxSize skips the files larger than it, 0 inspects all files
+	MaxSize int64 `json:"max_size"`
+	// Timeout in seconds, it defaults to 60
+	Timeout int `json:"timeout"`
+}
+
+// Factory creates an inspector from its config
+type Factory func(cfg Config) (Inspector, error)
+
+var factories = map[string]Factory{
+	"icap":    newICAP,
+	"yara":    newYara,
+	"webhook": newWebhook,
+}
+
+// RegisterType adds a type of inspector, it must be called before the settings are loaded
+func RegisterType(typ string, factory Factory) {
+	factories[typ] = factory
+}
+
+type configured struct {
+	Config
+	inspector Inspector
+}
+
+var (
+	inspectors atomic.Pointer[[]configured]
+	actions    atomic.Pointer[map[string]string]
+)
+
+func init() {
+	op.RegisterSettingItemHook(conf.UploadInspectors, func(item *model.SettingItem) error {
+		parsed, err := parseInspectors(item.Value)
+		if err != nil {
+			return err
+		}
+		inspectors.Store(&parsed)
+		return nil
+	})
+	op.RegisterSettingItemHook(conf.UploadInspectActions, func(item *model.SettingItem) error {
+		parsed, err := parseActions(item.Value)
+		if err != nil {
+			return err
+		}
+		actions.Store(&parsed)
+		return nil
+	})
+}
+
+// parseInspectors creates the inspectors of the upload_inspectors setting, which is a json array of Config
+func parseInspectors(value string) ([]configured, error) {
+	var configs []Config
+	if strings.TrimSpace(value) != "" {
+		if err := utils.Json.UnmarshalFromString(value, &configs); err != nil {
+			return nil, errors.WithMessage(err, "invalid upload inspectors")
+		}
+	}
+	parsed := make([]configured, 0, len(configs))
+	for i, cfg := range configs {
+		if cfg.Name == "" {
+			cfg.Name = cfg.Type + "#" + strconv.Itoa(i)
+		}
+		if cfg.Verdict == "" {
+			cfg.Verdict = VerdictMalicious
+		}
+		if cfg.Timeout <= 0 {
+			cfg.Timeout = 60
+		}
+		factory, ok := factories[cfg.Type]
+		if !ok {
+			return nil, errors.Errorf("inspector %s: unknown type [%s]", cfg.Name, cfg.Type)
+		}
+		inspector, err := factory(cfg)
+		if err != nil {
+			return nil, errors.Errorf("inspector %s: %v", cfg.Name, err)
+		}
+		parsed = append(parsed, configured{Config: cfg, inspector: inspector})
+	}
+	return parsed, nil
+}
+
+// parseActions parses the upload_inspect_actions setting, a json object mapping verdicts to actions
+func parseActions(value string) (map[string]string, error) {
+	parsed := make(map[string]string)
+	if strings.TrimSpace(value) == "" {
+		return parsed, nil
+	}
+	if err := utils.Json.UnmarshalFromString(value, &parsed); err != nil {
+		return nil, errors.WithMessage(err, "invalid upload inspect actions")
+	}
+	for verdict, action := range parsed {
+		switch action {
+		case ActionAllow, ActionReject, ActionQuarantine:
+		default:
+			return nil, errors.Errorf("verdict %s: unknown action [%s]", verdict, action)
+		}
+	}
+	return parsed, nil
+}
+
+// Enabled reports whether there are inspectors to run
+func Enabled() bool {
+	i := inspectors.Load()
+	return i != nil && len(*i) > 0
+}
+
+// actionOf returns the action of a verdict, verdicts without an action are rejected except clean
+func actionOf(verdict string) string {
+	if a := actions.Load(); a != nil {
+		if action, ok := (*a)[verdict]; ok {
+			return action
+		}
+	}
+	if verdict == VerdictClean {
+		return ActionAllow
+	}
+	return ActionReject
+}
+
+type Decision struct {
+	Action  string   `json:"action"`
+	Results []Result `json:"results"`
+}
+
+// Reason describes the results that led to the action
+func (d Decision) Reason() string {
+	var reasons []string
+	for _, r := range d.Results {
+		if r.Verdict == VerdictClean {
+			continue
+		}
+		reason := r.Inspector + ": " + r.Verdict
+		if r.Reason != "" {
+			reason += " (" + r.Reason + ")"
+		}
+		reasons = append(reasons, reason)
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// Check runs the inspectors in order. The strictest action of their verdicts is
+// taken, the remaining inspectors are skipped once one rejects the file.
+func Check(ctx context.Context, file *File) Decision {
+	d := Decision{Action: ActionAllow}
+	list := inspectors.Load()
+	if list == nil {
+		return d
+	}
+	for _, c := range *list {
+		if c.MaxSize > 0 && file.Size > c.MaxSize {
+			continue
+		}
+		tCtx, cancel := context.WithTimeout(ctx, time.Duration(c.Timeout)*time.Second)
+		r, err := c.inspector.Inspect(tCtx, file)
+		cancel()
+		if err != nil {
+			log.Warnf("upload inspector %s failed on [%s]: %+v", c.Name, file.Path, err)
+			r = Result{Verdict: VerdictError, Reason: err.Error()}
+		}
+		r.Inspector = c.Name
+		d.Results = append(d.Results, r)
+		switch actionOf(r.Verdict) {
+		case ActionReject:
+			d.Action = ActionReject
+			return d
+		case ActionQuarantine:
+			d.Action = ActionQuarantine
+		}
+	}
+	return d
+}
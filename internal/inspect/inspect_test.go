@@ -0,0 +1,163 @@
+package inspect_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/inspect"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:inspect?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	base.InitClient()
+	db.Init(dB)
+}
+
+func setSetting(t *testing.T, key, value string) error {
+	t.Helper()
+	err := op.SaveSettingItem(&model.SettingItem{Key: key, Value: value})
+	t.Cleanup(func() {
+		_ = op.SaveSettingItem(&model.SettingItem{Key: key, Value: ""})
+	})
+	return err
+}
+
+func file(path, content string) *inspect.File {
+	return &inspect.File{Path: path, Size: int64(len(content)), User: "admin", Content: strings.NewReader(content)}
+}
+
+func TestWebhook(t *testing.T) {
+	var mu sync.Mutex
+	paths := make(map[string]bool)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, _ := url.PathUnescape(r.Header.Get("X-OpenList-Path"))
+		mu.Lock()
+		paths[path] = true
+		mu.Unlock()
+		buf := new(bytes.Buffer)
+		_, _ = buf.ReadFrom(r.Body)
+		verdict := inspect.VerdictClean
+		switch {
+		case strings.Contains(buf.String(), "broken"):
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		case strings.Contains(buf.String(), "EICAR"):
+			verdict = inspect.VerdictMalicious
+		case strings.Contains(buf.String(), "secret"):
+			verdict = inspect.VerdictSensitive
+		}
+		_ = utils.Json.NewEncoder(w).Encode(map[string]string{"verdict": verdict, "reason": "test"})
+	}))
+	defer hook.Close()
+	if err := setSetting(t, conf.UploadInspectors, `[{"name":"dlp","type":"webhook","url":"`+hook.URL+`","max_size":64}]`); err != nil {
+		t.Fatalf("failed set inspectors: %+v", err)
+	}
+	if err := setSetting(t, conf.UploadInspectActions, `{"sensitive":"quarantine"}`); err != nil {
+		t.Fatalf("failed set actions: %+v", err)
+	}
+	if !inspect.Enabled() {
+		t.Fatal("expected the inspectors to be enabled")
+	}
+
+	ctx := context.Background()
+	for content, action := range map[string]string{
+		"hello":          inspect.ActionAllow,
+		"X5O EICAR test": inspect.ActionReject,
+		"top secret":     inspect.ActionQuarantine,
+		"broken":         inspect.ActionReject,
+	} {
+		if d := inspect.Check(ctx, file("/up/file.txt", content)); d.Action != action {
+			t.Errorf("%s: expected %s, got %+v", content, action, d)
+		}
+	}
+	d := inspect.Check(ctx, file("/up/file name.txt", "X5O EICAR test"))
+	if d.Reason() != "dlp: malicious (test)" {
+		t.Errorf("unexpected reason: %q", d.Reason())
+	}
+	if d = inspect.Check(ctx, file("/up/large.txt", strings.Repeat("EICAR", 20))); d.Action != inspect.ActionAllow {
+		t.Errorf("expected the files over the max size to be skipped, got %+v", d)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !paths["/up/file name.txt"] || paths["/up/large.txt"] {
+		t.Errorf("unexpected paths sent to the webhook: %v", paths)
+	}
+}
+
+func TestParseSettings(t *testing.T) {
+	for key, value := range map[string]string{
+		conf.UploadInspectors:     `[{"type":"unknown"}]`,
+		conf.UploadInspectActions: `{"malicious":"delete"}`,
+	} {
+		if err := setSetting(t, key, value); err == nil {
+			t.Errorf("expected %s to be rejected", value)
+		}
+	}
+	if err := setSetting(t, conf.UploadInspectors, `[{"type":"webhook","url":"not an url"}]`); err == nil {
+		t.Error("expected a webhook without a valid url to be rejected")
+	}
+}
+
+func TestYara(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake yara is a shell script")
+	}
+	dir := t.TempDir()
+	script := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+			t.Fatalf("failed write %s: %+v", name, err)
+		}
+		return path
+	}
+	// the fake yara matches a rule when the file contains EICAR, its last argument
+	yaraPath := script("yara", `for f; do :; done; grep -q EICAR "$f" && echo "eicar $f"; exit 0`)
+	marker := filepath.Join(dir, "ran")
+	other := script("other", "touch "+marker)
+	oldPath, oldTemp := conf.Conf.YaraPath, conf.Conf.TempDir
+	conf.Conf.YaraPath, conf.Conf.TempDir = yaraPath, dir
+	t.Cleanup(func() {
+		conf.Conf.YaraPath, conf.Conf.TempDir = oldPath, oldTemp
+	})
+
+	// the binary can only be chosen in the config file, a command in the setting is ignored
+	if err := setSetting(t, conf.UploadInspectors, `[{"name":"rules","type":"yara","rules":"rules.yar","command":"`+other+`"}]`); err != nil {
+		t.Fatalf("failed set inspectors: %+v", err)
+	}
+	ctx := context.Background()
+	if d := inspect.Check(ctx, file("/up/virus.txt", "X5O EICAR test")); d.Action != inspect.ActionReject || d.Reason() != "rules: malicious (matched eicar)" {
+		t.Errorf("expected the match of the configured yara, got %+v", d)
+	}
+	if d := inspect.Check(ctx, file("/up/clean.txt", "hello")); d.Action != inspect.ActionAllow {
+		t.Errorf("expected a clean file to pass, got %+v", d)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("expected the command of the setting not to run")
+	}
+
+	conf.Conf.YaraPath = filepath.Join(dir, "missing")
+	if err := setSetting(t, conf.UploadInspectors, `[{"type":"yara","rules":"rules.yar"}]`); err == nil {
+		t.Error("expected yara to be rejected when its binary is missing")
+	}
+}
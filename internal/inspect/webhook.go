@@ -0,0 +1,59 @@
+package inspect
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// webhook posts the file to an endpoint that answers with the verdict as
+// {"verdict": "clean", "reason": ""}. The metadata of the upload is sent in the
+// X-OpenList-* headers.
+type webhook struct {
+	cfg Config
+}
+
+type webhookResp struct {
+	Verdict string `json:"verdict"`
+	Reason  string `json:"reason"`
+}
+
+func newWebhook(cfg Config) (Inspector, error) {
+	if _, err := url.ParseRequestURI(cfg.URL); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &webhook{cfg: cfg}, nil
+}
+
+func (w *webhook) Inspect(ctx context.Context, file *File) (Result, error) {
+	var resp webhookResp
+	res, err := base.RestyClient.R().
+		SetContext(ctx).
+		SetHeaders(w.cfg.Headers).
+		SetHeader("Content-Type", "application/octet-stream").
+		SetHeader("X-OpenList-Path", url.PathEscape(file.Path)).
+		SetHeader("X-OpenList-Size", strconv.FormatInt(file.Size, 10)).
+		SetHeader("X-OpenList-Mimetype", file.Mimetype).
+		SetHeader("X-OpenList-Uploader", url.QueryEscape(file.User)).
+		SetBody(io.NewSectionReader(file.Content, 0, file.Size)).
+		Post(w.cfg.URL)
+	if err != nil {
+		return Result{}, errors.WithStack(err)
+	}
+	if res.IsError() {
+		return Result{}, errors.Errorf("webhook responded %s", res.Status())
+	}
+	if err = utils.Json.Unmarshal(res.Body(), &resp); err != nil {
+		return Result{}, errors.Wrap(err, "invalid webhook response")
+	}
+	// verdicts of its own are fine, upload_inspect_actions may map them
+	if resp.Verdict == "" {
+		return Result{}, errors.New("webhook returned no verdict")
+	}
+	return Result{Verdict: resp.Verdict, Reason: resp.Reason}, nil
+}
@@ -0,0 +1,79 @@
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// yara runs the yara command with the rules file on the file, every matching rule is a finding.
+// The binary comes from the config file, the setting can't make the server run another program.
+type yara struct {
+	cfg  Config
+	path string
+}
+
+func newYara(cfg Config) (Inspector, error) {
+	if cfg.Rules == "" {
+		return nil, errors.New("rules is required")
+	}
+	path := conf.Conf.YaraPath
+	if path == "" {
+		path = "yara"
+	}
+	if _, err := exec.LookPath(path); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &yara{cfg: cfg, path: path}, nil
+}
+
+func (y *yara) Inspect(ctx context.Context, file *File) (Result, error) {
+	name, cleanup, err := onDisk(file)
+	if err != nil {
+		return Result{}, err
+	}
+	defer cleanup()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, y.path, "--no-warnings", y.cfg.Rules, name)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err = cmd.Run(); err != nil {
+		return Result{}, errors.Wrapf(err, "yara failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	// every line of the output is "<rule> <file>"
+	var rules []string
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		if rule, _, _ := strings.Cut(line, " "); rule != "" {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return Result{Verdict: VerdictClean}, nil
+	}
+	return Result{Verdict: y.cfg.Verdict, Reason: "matched " + strings.Join(rules, ", ")}, nil
+}
+
+// onDisk returns the name of a file with the content, the cache file of the upload is used if there is one
+func onDisk(file *File) (string, func(), error) {
+	if f, ok := file.Content.(*os.File); ok {
+		return f.Name(), func() {}, nil
+	}
+	tmp, err := os.CreateTemp(conf.Conf.TempDir, "inspect-*")
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	cleanup := func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}
+	if _, err = utils.CopyWithBuffer(tmp, file.Reader()); err != nil {
+		cleanup()
+		return "", nil, errors.WithStack(err)
+	}
+	return tmp.Name(), cleanup, nil
+}
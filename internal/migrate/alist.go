@@ -0,0 +1,103 @@
+// Package migrate imports the data of an AList v3 instance, from its database or
+// from a backup exported in its manage page, into OpenList. What can't be imported
+// as it is, is listed in the report for the admin to look after.
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// Source is the data of an AList v3 instance. OpenList is a fork of AList v3,
+// so its tables and backups fit the models of OpenList.
+type Source struct {
+	Settings []model.SettingItem `json:"settings"`
+	Users    []model.User        `json:"users"`
+	Storages []model.Storage     `json:"storages"`
+	Metas    []model.Meta        `json:"metas"`
+	// FromBackup is set if the data comes from a backup, which has no password hashes
+	FromBackup bool `json:"-"`
+}
+
+// LoadDB reads the data from the database of an AList v3 instance. dsn is the
+// file of the database for sqlite3.
+func LoadDB(dbType, dsn, tablePrefix string) (*Source, error) {
+	var dialector gorm.Dialector
+	switch dbType {
+	case "sqlite3":
+		// don't touch the wal of a running instance
+		dialector = sqlite.Open(fmt.Sprintf("file:%s?mode=ro", dsn))
+	case "mysql":
+		dialector = mysql.Open(dsn)
+	case "postgres":
+		dialector = postgres.Open(dsn)
+	default:
+		return nil, errors.Errorf("not supported database type: %s", dbType)
+	}
+	dB, err := gorm.Open(dialector, &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{TablePrefix: tablePrefix},
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect the database")
+	}
+	if sqlDB, err := dB.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	var src Source
+	// the tables of older versions lack some columns, selecting * reads what there is
+	for name, dst := range map[string]any{
+		"setting items": &src.Settings,
+		"users":         &src.Users,
+		"storages":      &src.Storages,
+		"metas":         &src.Metas,
+	} {
+		if err = dB.Find(dst).Error; err != nil {
+			return nil, errors.Wrapf(err, "failed to read the %s", name)
+		}
+	}
+	return &src, nil
+}
+
+// LoadBackup reads a backup exported in the manage page of AList v3
+func LoadBackup(r io.Reader) (*Source, error) {
+	var backup struct {
+		Source
+		Encrypted string `json:"encrypted"`
+	}
+	if err := utils.Json.NewDecoder(r).Decode(&backup); err != nil {
+		return nil, errors.Wrap(err, "invalid backup")
+	}
+	if backup.Encrypted != "" {
+		return nil, errors.New("encrypted backups are not supported, export the backup without a password")
+	}
+	backup.FromBackup = true
+	return &backup.Source, nil
+}
+
+// driverNames maps the drivers of AList v3 that have another name in OpenList,
+// an empty name means OpenList has no such driver.
+var driverNames = map[string]string{
+	"AList V2": "",
+	"Quqi":     "",
+	"Trainbit": "",
+	"VTencent": "",
+}
+
+// brandedValue reports whether a setting still has the AList branding, like the logo
+func brandedValue(value string) bool {
+	return value == "AList" ||
+		strings.Contains(value, "alist-org") ||
+		strings.Contains(value, "alist.nn.ci")
+}
@@ -0,0 +1,186 @@
+package migrate_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/data"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/migrate"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:migrate?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	dir, err := os.MkdirTemp("", "openlist-migrate-test")
+	if err != nil {
+		panic(err)
+	}
+	conf.Conf = conf.DefaultConfig(dir)
+	db.Init(dB)
+	data.InitData()
+}
+
+// alistSchema is the schema sqlite databases of AList v3 have, it lacks the columns OpenList added since
+var alistSchema = []string{
+	"CREATE TABLE `x_users` (`id` integer,`username` text UNIQUE,`pwd_hash` text,`pwd_ts` integer,`salt` text,`password` text,`base_path` text,`role` integer,`disabled` numeric,`permission` integer,`otp_secret` text,`sso_id` text,`authn` text,PRIMARY KEY (`id`))",
+	"CREATE TABLE `x_storages` (`id` integer,`mount_path` text UNIQUE,`order` integer,`driver` text,`cache_expiration` integer,`status` text,`addition` text,`remark` text,`modified` datetime,`disabled` numeric,`disable_index` numeric,`enable_sign` numeric,`order_by` text,`order_direction` text,`extract_folder` text,`web_proxy` numeric,`webdav_policy` text,`proxy_range` numeric,`down_proxy_url` text,PRIMARY KEY (`id`))",
+	"CREATE TABLE `x_setting_items` (`key` text,`value` text,`help` text,`type` text,`options` text,`group` integer,`flag` integer,`index` integer,PRIMARY KEY (`key`))",
+	"CREATE TABLE `x_meta` (`id` integer,`path` text UNIQUE,`password` text,`p_sub` numeric,`write` numeric,`w_sub` numeric,`hide` text,`h_sub` numeric,`readme` text,`r_sub` numeric,`header` text,`header_sub` numeric,PRIMARY KEY (`id`))",
+}
+
+// createAListDB creates the database of an AList v3 instance, its users have the passwords
+// admin_pass and alist_pass, except legacy_user who has legacy_pass kept from before v3.25
+func createAListDB(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data.db")
+	dB, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed create database: %+v", err)
+	}
+	sqlDB, err := dB.DB()
+	if err != nil {
+		t.Fatalf("failed get database: %+v", err)
+	}
+	defer sqlDB.Close()
+	admin := (&model.User{}).SetPassword("admin_pass")
+	user := (&model.User{}).SetPassword("alist_pass")
+	statements := []struct {
+		sql  string
+		args []any
+	}{
+		{"INSERT INTO `x_users` VALUES (1,'admin',?,?,?,'','/',2,0,0,'','','[]')", []any{admin.PwdHash, admin.PwdTS, admin.Salt}},
+		{"INSERT INTO `x_users` VALUES (2,'guest','',0,'','','/',1,1,0,'','','[]')", nil},
+		{"INSERT INTO `x_users` VALUES (3,'alist_db_user',?,?,?,'','/alist_db',0,0,258,'','','[]')", []any{user.PwdHash, user.PwdTS, user.Salt}},
+		{"INSERT INTO `x_users` VALUES (4,'legacy_user','',0,'','legacy_pass','/',0,0,0,'','',NULL)", nil},
+		{"INSERT INTO `x_storages` VALUES (1,'/alist_db_mock',0,'Mock',30,'work','{\"num_file\":1}','','2024-05-01 10:00:00',0,0,0,'name','asc','front',0,'302_redirect',0,'')", nil},
+		{"INSERT INTO `x_storages` VALUES (2,'/alist_db_quqi',1,'Quqi',30,'work','{}','','2024-05-01 10:00:00',0,0,0,'','','',0,'302_redirect',0,'')", nil},
+		{"INSERT INTO `x_setting_items` VALUES ('site_title','AList','','string','',0,0,0)", nil},
+		{"INSERT INTO `x_setting_items` VALUES ('pagination_type','pagination','','select','all,pagination,load_more,auto_load_more',0,0,0)", nil},
+		{"INSERT INTO `x_setting_items` VALUES ('version','v3.40.0','','string','',0,2,0)", nil},
+		{"INSERT INTO `x_meta` VALUES (1,'/alist_db_mock','pass',1,0,0,'',0,'',0,'',0)", nil},
+	}
+	for _, stmt := range alistSchema {
+		if err = dB.Exec(stmt).Error; err != nil {
+			t.Fatalf("failed create table: %+v", err)
+		}
+	}
+	for _, s := range statements {
+		if err = dB.Exec(s.sql, s.args...).Error; err != nil {
+			t.Fatalf("failed insert: %+v", err)
+		}
+	}
+	return path
+}
+
+func TestLoadDB(t *testing.T) {
+	path := createAListDB(t)
+	if _, err := migrate.LoadDB("oracle", path, "x_"); err == nil {
+		t.Error("expected an unsupported database type to be rejected")
+	}
+	if _, err := migrate.LoadDB("sqlite3", filepath.Join(t.TempDir(), "missing.db"), "x_"); err == nil {
+		t.Error("expected a missing database to be rejected")
+	}
+	src, err := migrate.LoadDB("sqlite3", path, "x_")
+	if err != nil {
+		t.Fatalf("failed load database: %+v", err)
+	}
+	if src.FromBackup || len(src.Users) != 4 || len(src.Storages) != 2 || len(src.Settings) != 3 || len(src.Metas) != 1 {
+		t.Fatalf("unexpected source: %+v", src)
+	}
+	if src.Storages[0].Addition != `{"num_file":1}` || src.Storages[0].Modified.IsZero() || src.Metas[0].Password != "pass" {
+		t.Errorf("unexpected storages and metas: %+v %+v", src.Storages, src.Metas)
+	}
+
+	report := migrate.Import(src, migrate.Options{})
+	t.Cleanup(func() {
+		for _, name := range []string{"alist_db_user", "legacy_user"} {
+			if u, err := op.GetUserByName(name); err == nil {
+				_ = op.DeleteUserById(u.ID)
+			}
+		}
+		for _, path := range []string{"/alist_db_mock", "/alist_db_quqi"} {
+			if st, err := db.GetStorageByMountPath(path); err == nil {
+				_ = db.DeleteStorageById(st.ID)
+			}
+		}
+		if m, err := db.GetMetaByPath("/alist_db_mock"); err == nil {
+			_ = op.DeleteMetaById(m.ID)
+		}
+	})
+	if report.Imported[migrate.KindUser] != 4 || report.Imported[migrate.KindStorage] != 2 || report.Imported[migrate.KindMeta] != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	// the passwords of a database are kept, unlike the ones of a backup
+	for name, pwd := range map[string]string{"admin": "admin_pass", "alist_db_user": "alist_pass", "legacy_user": "legacy_pass"} {
+		u, err := op.GetUserByName(name)
+		if err != nil {
+			t.Errorf("failed get user %s: %+v", name, err)
+			continue
+		}
+		if err = u.ValidateRawPassword(pwd); err != nil {
+			t.Errorf("expected %s to log in with the password of AList: %+v", name, err)
+		}
+	}
+	if guest, err := op.GetGuest(); err != nil || !guest.Disabled {
+		t.Errorf("expected the guest to be merged, got %+v %v", guest, err)
+	}
+	if item, err := op.GetSettingItemByKey("pagination_type"); err != nil || item.Value != "pagination" {
+		t.Errorf("expected the setting to be imported, got %+v %v", item, err)
+	}
+	if item, err := op.GetSettingItemByKey(conf.SiteTitle); err != nil || item.Value == "AList" {
+		t.Errorf("expected the branded setting to be skipped, got %+v %v", item, err)
+	}
+}
+
+// backup is a backup exported in the manage page of AList v3
+const backup = `{
+	"encrypted": "",
+	"settings": [
+		{"key": "site_title", "value": "AList", "help": "", "type": "string", "options": "", "group": 0, "flag": 0, "index": 0},
+		{"key": "announcement", "value": "### repo\nhttps://github.com/alist-org/alist", "help": "", "type": "text", "options": "", "group": 0, "flag": 0, "index": 1},
+		{"key": "pagination_type", "value": "load_more", "help": "", "type": "select", "options": "all,pagination,load_more,auto_load_more", "group": 0, "flag": 0, "index": 2},
+		{"key": "alist_only", "value": "x", "help": "", "type": "string", "options": "", "group": 1, "flag": 1, "index": 0}
+	],
+	"users": [
+		{"id": 1, "username": "admin", "password": "", "base_path": "/", "role": 2, "disabled": false, "permission": 0, "sso_id": "", "otp": false},
+		{"id": 2, "username": "guest", "password": "", "base_path": "/", "role": 1, "disabled": true, "permission": 0, "sso_id": "", "otp": false},
+		{"id": 3, "username": "alist_user", "password": "", "base_path": "/alist", "role": 0, "disabled": false, "permission": 3, "sso_id": "", "otp": false}
+	],
+	"storages": [
+		{"id": 1, "mount_path": "/alist_mock", "order": 0, "driver": "Mock", "cache_expiration": 30, "status": "work", "addition": "{}", "remark": "", "modified": "2024-05-01T10:00:00.000000+08:00", "disabled": false, "disable_index": false, "enable_sign": false, "order_by": "name", "order_direction": "asc", "extract_folder": "front", "web_proxy": false, "webdav_policy": "302_redirect", "proxy_range": false, "down_proxy_url": ""},
+		{"id": 2, "mount_path": "/alist_quqi", "order": 1, "driver": "Quqi", "cache_expiration": 30, "status": "work", "addition": "{}", "remark": "", "modified": "2024-05-01T10:00:00.000000+08:00", "disabled": false, "disable_index": false, "enable_sign": false, "order_by": "", "order_direction": "", "extract_folder": "", "web_proxy": false, "webdav_policy": "302_redirect", "proxy_range": false, "down_proxy_url": ""},
+		{"id": 3, "mount_path": "/alist_taken", "order": 2, "driver": "Mock", "cache_expiration": 30, "status": "work", "addition": "{}", "remark": "", "modified": "2024-05-01T10:00:00.000000+08:00", "disabled": false, "disable_index": false, "enable_sign": false, "order_by": "", "order_direction": "", "extract_folder": "", "web_proxy": false, "webdav_policy": "302_redirect", "proxy_range": false, "down_proxy_url": ""}
+	],
+	"metas": [
+		{"id": 1, "path": "/alist_mock", "password": "pass", "p_sub": true, "write": false, "w_sub": false, "hide": "", "h_sub": false, "readme": "", "r_sub": false, "header": "", "header_sub": false}
+	]
+}`
+
+func TestLoadBackup(t *testing.T) {
+	src, err := migrate.LoadBackup(strings.NewReader(backup))
+	if err != nil {
+		t.Fatalf("failed load backup: %+v", err)
+	}
+	if !src.FromBackup || len(src.Settings) != 4 || len(src.Users) != 3 || len(src.Storages) != 3 || len(src.Metas) != 1 {
+		t.Fatalf("unexpected source: %+v", src)
+	}
+	if src.Users[2].Username != "alist_user" || src.Users[2].PwdHash != "" || src.Storages[0].Modified.IsZero() || !src.Metas[0].PSub {
+		t.Errorf("unexpected users, storages or metas: %+v %+v %+v", src.Users, src.Storages, src.Metas)
+	}
+	for _, invalid := range []string{`{"encrypted": "x"}`, `{"users": {}}`, `not json`} {
+		if _, err = migrate.LoadBackup(strings.NewReader(invalid)); err == nil {
+			t.Errorf("expected %s to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package migrate
+
+import (
+	"os"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+)
+
+const (
+	KindUser    = "user"
+	KindStorage = "storage"
+	KindMeta    = "meta"
+	KindSetting = "setting"
+)
+
+type Options struct {
+	// DryRun only reports what would be imported
+	DryRun bool
+}
+
+// Item is something the admin should look after, imported or not
+type Item struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Imported bool   `json:"imported"`
+	Message  string `json:"message"`
+}
+
+type Report struct {
+	DryRun bool `json:"dry_run"`
+	// Imported and Skipped count the items of every kind
+	Imported map[string]int `json:"imported"`
+	Skipped  map[string]int `json:"skipped"`
+	Items    []Item         `json:"items"`
+}
+
+func (r *Report) imported(kind, name, message string) {
+	r.Imported[kind]++
+	if message != "" {
+		r.Items = append(r.Items, Item{Kind: kind, Name: name, Imported: true, Message: message})
+	}
+}
+
+func (r *Report) skipped(kind, name, message string) {
+	r.Skipped[kind]++
+	r.Items = append(r.Items, Item{Kind: kind, Name: name, Message: message})
+}
+
+// Import writes the data of the AList instance to OpenList. Existing users, storages
+// and metas are kept, the admin and the guest of AList are merged into the ones of
+// OpenList. The storages are only saved, they are loaded on the next start.
+func Import(src *Source, opts Options) *Report {
+	r := &Report{
+		DryRun:   opts.DryRun,
+		Imported: make(map[string]int),
+		Skipped:  make(map[string]int),
+	}
+	importSettings(src, opts, r)
+	importUsers(src, opts, r)
+	importStorages(src, opts, r)
+	importMetas(src, opts, r)
+	return r
+}
+
+func importSettings(src *Source, opts Options, r *Report) {
+	var items []model.SettingItem
+	for _, s := range src.Settings {
+		cur, err := db.GetSettingItemByKey(s.Key)
+		if err != nil {
+			r.skipped(KindSetting, s.Key, "OpenList has no such setting")
+			continue
+		}
+		if cur.Value == s.Value || cur.Flag == model.READONLY || cur.Flag == model.DEPRECATED {
+			continue
+		}
+		if brandedValue(s.Value) {
+			r.skipped(KindSetting, s.Key, "the value is the one of AList, the one of OpenList is kept")
+			continue
+		}
+		cur.Value = s.Value
+		items = append(items, *cur)
+		r.imported(KindSetting, s.Key, "")
+	}
+	if opts.DryRun || len(items) == 0 {
+		return
+	}
+	if err := op.SaveSettingItems(items); err != nil {
+		for _, s := range items {
+			r.Imported[KindSetting]--
+			r.skipped(KindSetting, s.Key, "failed to save: "+err.Error())
+		}
+	}
+}
+
+func importUsers(src *Source, opts Options, r *Report) {
+	for _, u := range src.Users {
+		if u.IsAdmin() || u.IsGuest() {
+			mergeUser(u, src.FromBackup, opts, r)
+			continue
+		}
+		if _, err := op.GetUserByName(u.Username); err == nil {
+			r.skipped(KindUser, u.Username, "a user with the name exists")
+			continue
+		}
+		u.ID = 0
+		u.GroupID = 0
+		var notes []string
+		if u.PwdHash == "" {
+			if u.Password != "" {
+				// versions before v3.25 kept the password as it is
+				u.SetPassword(u.Password)
+			} else {
+				u.SetPassword(random.String(16))
+				notes = append(notes, "no password in the backup, set a new one")
+			}
+		}
+		u.Password = ""
+		if u.Authn == "" {
+			u.Authn = "[]"
+		}
+		notes = append(notes, userNotes(u)...)
+		if !opts.DryRun {
+			if err := op.CreateUser(&u); err != nil {
+				r.skipped(KindUser, u.Username, "failed to create: "+err.Error())
+				continue
+			}
+		}
+		r.imported(KindUser, u.Username, strings.Join(notes, "; "))
+	}
+}
+
+// mergeUser copies the admin or the guest of AList to the one of OpenList
+func mergeUser(u model.User, fromBackup bool, opts Options, r *Report) {
+	cur, err := op.GetUserByRole(u.Role)
+	if err != nil {
+		r.skipped(KindUser, u.Username, "failed to get the user of the role: "+err.Error())
+		return
+	}
+	var notes []string
+	cur.BasePath = u.BasePath
+	cur.Permission = u.Permission
+	cur.Disabled = u.Disabled
+	if u.IsAdmin() {
+		cur.Username = u.Username
+		if fromBackup || u.PwdHash == "" {
+			notes = append(notes, "no password in the backup, the password of OpenList is kept")
+		} else {
+			cur.PwdHash, cur.PwdTS, cur.Salt = u.PwdHash, u.PwdTS, u.Salt
+			cur.OtpSecret, cur.Authn, cur.SsoID = u.OtpSecret, u.Authn, u.SsoID
+			notes = append(notes, userNotes(*cur)...)
+		}
+	}
+	if !opts.DryRun {
+		if err = op.UpdateUser(cur); err != nil {
+			r.skipped(KindUser, u.Username, "failed to update: "+err.Error())
+			return
+		}
+	}
+	r.imported(KindUser, u.Username, strings.Join(notes, "; "))
+}
+
+func userNotes(u model.User) []string {
+	var notes []string
+	if u.Authn != "" && u.Authn != "[]" {
+		notes = append(notes, "passkeys only work if the site url stays the same")
+	}
+	if u.SsoID != "" {
+		notes = append(notes, "single sign-on has to be configured again")
+	}
+	return notes
+}
+
+func importStorages(src *Source, opts Options, r *Report) {
+	for _, s := range src.Storages {
+		s.MountPath = utils.FixAndCleanPath(s.MountPath)
+		if _, err := db.GetStorageByMountPath(s.MountPath); err == nil {
+			r.skipped(KindStorage, s.MountPath, "a storage is mounted at the path")
+			continue
+		}
+		s.ID = 0
+		s.Status = ""
+		var notes []string
+		name, renamed := driverNames[s.Driver]
+		if renamed && name != "" {
+			notes = append(notes, "the driver is now named "+name)
+			s.Driver = name
+		}
+		if _, err := op.GetDriver(s.Driver); err != nil || (renamed && name == "") {
+			notes = append(notes, "OpenList has no "+s.Driver+" driver, the storage is disabled")
+			s.Disabled = true
+		} else if s.Driver == "Local" {
+			var addition struct {
+				RootFolderPath string `json:"root_folder_path"`
+			}
+			_ = utils.Json.UnmarshalFromString(s.Addition, &addition)
+			if _, err := os.Stat(addition.RootFolderPath); err != nil {
+				notes = append(notes, "the root folder is not on this host, the storage is disabled")
+				s.Disabled = true
+			}
+		}
+		if !opts.DryRun {
+			if err := db.CreateStorage(&s); err != nil {
+				r.skipped(KindStorage, s.MountPath, "failed to create: "+errors.Cause(err).Error())
+				continue
+			}
+		}
+		r.imported(KindStorage, s.MountPath, strings.Join(notes, "; "))
+	}
+}
+
+func importMetas(src *Source, opts Options, r *Report) {
+	for _, m := range src.Metas {
+		m.Path = utils.FixAndCleanPath(m.Path)
+		if _, err := db.GetMetaByPath(m.Path); err == nil {
+			r.skipped(KindMeta, m.Path, "a meta of the path exists")
+			continue
+		}
+		m.ID = 0
+		if !opts.DryRun {
+			if err := op.CreateMeta(&m); err != nil {
+				r.skipped(KindMeta, m.Path, "failed to create: "+err.Error())
+				continue
+			}
+		}
+		r.imported(KindMeta, m.Path, "")
+	}
+}
@@ -0,0 +1,79 @@
+package migrate_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/migrate"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	id, err := op.CreateStorage(ctx, model.Storage{Driver: "Mock", MountPath: "/alist_taken", Addition: `{"num_file":1}`})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteStorageById(ctx, id)
+	})
+	src, err := migrate.LoadBackup(strings.NewReader(backup))
+	if err != nil {
+		t.Fatalf("failed load backup: %+v", err)
+	}
+
+	report := migrate.Import(src, migrate.Options{DryRun: true})
+	if report.Imported[migrate.KindStorage] != 2 || report.Skipped[migrate.KindStorage] != 1 {
+		t.Errorf("unexpected dry run report: %+v", report)
+	}
+	if _, err = op.GetUserByName("alist_user"); err == nil {
+		t.Fatalf("expected the dry run to import nothing")
+	}
+
+	before, err := op.GetAdmin()
+	if err != nil {
+		t.Fatalf("failed get admin: %+v", err)
+	}
+	report = migrate.Import(src, migrate.Options{})
+	t.Cleanup(func() {
+		if u, err := op.GetUserByName("alist_user"); err == nil {
+			_ = op.DeleteUserById(u.ID)
+		}
+		for _, path := range []string{"/alist_mock", "/alist_quqi"} {
+			if st, err := db.GetStorageByMountPath(path); err == nil {
+				_ = db.DeleteStorageById(st.ID)
+			}
+		}
+		if m, err := db.GetMetaByPath("/alist_mock"); err == nil {
+			_ = op.DeleteMetaById(m.ID)
+		}
+	})
+	want := map[string]int{migrate.KindSetting: 1, migrate.KindUser: 3, migrate.KindStorage: 2, migrate.KindMeta: 1}
+	for kind, n := range want {
+		if report.Imported[kind] != n {
+			t.Errorf("expected %d %ss imported, got %+v", n, kind, report)
+		}
+	}
+	if report.Skipped[migrate.KindSetting] != 3 {
+		t.Errorf("expected the unknown and the branded settings to be skipped, got %+v", report.Items)
+	}
+	if item, err := op.GetSettingItemByKey("pagination_type"); err != nil || item.Value != "load_more" {
+		t.Errorf("expected the setting to be imported, got %+v %v", item, err)
+	}
+	quqi, err := db.GetStorageByMountPath("/alist_quqi")
+	if err != nil || !quqi.Disabled {
+		t.Errorf("expected the storage of a missing driver to be disabled: %+v %+v", quqi, err)
+	}
+	user, err := op.GetUserByName("alist_user")
+	if err != nil || user.PwdHash == "" || user.BasePath != "/alist" {
+		t.Errorf("unexpected imported user: %+v %+v", user, err)
+	}
+	// a backup has no passwords, the admin keeps the one of OpenList
+	admin, err := op.GetAdmin()
+	if err != nil || admin.PwdHash != before.PwdHash {
+		t.Errorf("expected the admin to keep its password: %+v %+v", admin, err)
+	}
+}
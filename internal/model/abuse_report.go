@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+const (
+	AbuseReportPending = iota
+	AbuseReportResolved
+	AbuseReportDismissed
+)
+
+type AbuseReport struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	SharingID string     `json:"sharing_id" gorm:"index"`
+	Reason    string     `json:"reason"`
+	Detail    string     `json:"detail" gorm:"type:text"`
+	Email     string     `json:"email"`
+	IP        string     `json:"ip"`
+	Status    int        `json:"status"`
+	Note      string     `json:"note" gorm:"type:text"`
+	CreatedAt time.Time  `json:"created_at"`
+	HandledAt *time.Time `json:"handled_at"`
+}
+
+func (r *AbuseReport) IsPending() bool {
+	return r.Status == AbuseReportPending
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// scopes of the tokens issued to client apps
+const (
+	ScopeFsRead  = "fs:read"
+	ScopeFsWrite = "fs:write"
+	ScopeShare   = "share"
+)
+
+var Scopes = []string{ScopeFsRead, ScopeFsWrite, ScopeShare}
+
+// ClientApp is a trusted external application that may exchange its
+// credential and a username for a scoped, short-lived token of that user
+type ClientApp struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	Name       string `json:"name"`
+	ClientID   string `json:"client_id" gorm:"unique"`
+	SecretHash string `json:"-"`
+	// Scopes the app may ask for, comma separated
+	Scopes string `json:"scopes"`
+	// Users the app may get tokens for, comma separated, empty means every user but the admins
+	Users string `json:"users"`
+	// TokenTTL is the lifetime of the issued tokens in seconds
+	TokenTTL   int64      `json:"token_ttl"`
+	Disabled   bool       `json:"disabled"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+func splitList(s string) []string {
+	var res []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+func (a *ClientApp) GetScopes() []string {
+	return splitList(a.Scopes)
+}
+
+func (a *ClientApp) CanActAs(user *User) bool {
+	if user.IsAdmin() || user.IsGuest() || user.Disabled {
+		return false
+	}
+	users := splitList(a.Users)
+	return len(users) == 0 || utils.SliceContains(users, user.Username)
+}
+
+// SetSecret stores the hash of secret, the secret itself is only shown once
+func (a *ClientApp) SetSecret(secret string) {
+	a.SecretHash = utils.HashData(utils.SHA256, []byte(secret))
+}
+
+func (a *ClientApp) ValidateSecret(secret string) bool {
+	hash := utils.HashData(utils.SHA256, []byte(secret))
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(a.SecretHash)) == 1
+}
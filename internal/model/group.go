@@ -0,0 +1,23 @@
+package model
+
+// Group is a set of users managed by its owner, the fields are the allocation
+// set by the admin, the owner can't give the members more than that
+type Group struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	Name    string `json:"name" gorm:"unique" binding:"required"`
+	OwnerID uint   `json:"owner_id"`
+	// BasePath is the path the base paths of the members must be in
+	BasePath string `json:"base_path"`
+	// Permission holds the permission bits the owner may grant to the members
+	Permission int32 `json:"permission"`
+	// MaxMembers limits the members besides the owner, 0 means no limit
+	MaxMembers int `json:"max_members"`
+	// MaxShares limits the sharings of all the members and the owner together, 0 means no limit
+	MaxShares int `json:"max_shares"`
+}
+
+// GroupUsage is how much of the allocation of a group is used
+type GroupUsage struct {
+	Members int64 `json:"members"`
+	Shares  int64 `json:"shares"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"crypto/subtle"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+)
+
+// LinkPassword protects the direct links of a single path with its own password,
+// unlike the password of a meta it doesn't affect the listing of the folder
+type LinkPassword struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Path      string    `json:"path" gorm:"unique"`
+	PwdHash   string    `json:"-"`
+	Salt      string    `json:"-"`
+	CreatorID uint      `json:"creator_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (l *LinkPassword) SetPassword(pwd string) {
+	l.Salt = random.String(16)
+	l.PwdHash = TwoHashPwd(pwd, l.Salt)
+}
+
+func (l *LinkPassword) ValidatePassword(pwd string) bool {
+	return pwd != "" && subtle.ConstantTimeCompare([]byte(TwoHashPwd(pwd, l.Salt)), []byte(l.PwdHash)) == 1
+}
+
+// UnlockToken is kept by a browser once the password is entered,
+// it changes with the password so setting a new one locks the link again
+func (l *LinkPassword) UnlockToken() string {
+	return utils.HashData(utils.SHA256, []byte(l.Path+"-"+l.PwdHash))
+}
@@ -13,4 +13,6 @@ type Meta struct {
 	RSub      bool   `json:"r_sub"`
 	Header    string `json:"header"`
 	HeaderSub bool   `json:"header_sub"`
+	// Restricted paths and their sub paths are only accessible with an approved PathGrant
+	Restricted bool `json:"restricted"`
 }
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// status of a PathGrant
+const (
+	GrantPending  = "pending"
+	GrantApproved = "approved"
+	GrantRejected = "rejected"
+	GrantRevoked  = "revoked"
+	GrantExpired  = "expired"
+)
+
+// PathGrant lets a user into a restricted path. It's requested by a reviewer
+// and only takes effect once a different reviewer approves it.
+// Grants are never deleted, they are the audit trail of the restricted paths.
+type PathGrant struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Path        string    `json:"path" gorm:"index" binding:"required"`
+	UserID      uint      `json:"user_id" gorm:"index" binding:"required"`
+	Reason      string    `json:"reason"`
+	Status      string    `json:"status" gorm:"index"`
+	RequestedBy uint      `json:"requested_by"`
+	RequestedAt time.Time `json:"requested_at"`
+	// DecidedBy is the reviewer that approved, rejected or revoked the grant
+	DecidedBy uint       `json:"decided_by"`
+	DecidedAt *time.Time `json:"decided_at"`
+	// ExpiresAt ends the access, nil means it lasts until it's revoked
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (g *PathGrant) IsActive(now time.Time) bool {
+	return g.Status == GrantApproved && (g.ExpiresAt == nil || now.Before(*g.ExpiresAt))
+}
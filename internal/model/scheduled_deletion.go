@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// ScheduledDeletion removes a file or folder once PurgeAt is reached,
+// e.g. when the license of the content ends. It's deleted after the purge or when canceled.
+type ScheduledDeletion struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Path      string    `json:"path" gorm:"unique" binding:"required"`
+	PurgeAt   time.Time `json:"purge_at" gorm:"index" binding:"required"`
+	Reason    string    `json:"reason"`
+	CreatorID uint      `json:"creator_id"`
+	CreatedAt time.Time `json:"created_at"`
+	// LastError is the error of the last failed purge, it's retried on the next run
+	LastError string `json:"last_error"`
+}
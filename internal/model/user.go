@@ -18,6 +18,7 @@ const (
 	GENERAL = iota
 	GUEST   // only one exists
 	ADMIN
+	GROUPOWNER // manages the members of its group within the allocation of the admin
 )
 
 const StaticHashSalt = "https://github.com/alist-org/alist"
@@ -60,6 +61,9 @@ type User struct {
 	SsoID      string `json:"sso_id"` // unique by sso platform
 	Authn      string `gorm:"type:text" json:"-"`
 	AllowLdap  bool   `json:"allow_ldap" gorm:"default:true"`
+	GroupID    uint   `json:"group_id" gorm:"index"`
+	// MaxShares limits the sharings of the user, 0 means only the limit of the group applies
+	MaxShares int `json:"max_shares"`
 }
 
 func (u *User) IsGuest() bool {
@@ -70,6 +74,10 @@ func (u *User) IsAdmin() bool {
 	return u.Role == ADMIN
 }
 
+func (u *User) IsGroupOwner() bool {
+	return u.Role == GROUPOWNER
+}
+
 func (u *User) ValidateRawPassword(password string) error {
 	return u.ValidatePwdStaticHash(StaticHash(password))
 }
@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// SharingTemplate is the policy of the sharings created by a watch rule
+type SharingTemplate struct {
+	Pwd string `json:"pwd"`
+	// RandomPwd gives every sharing its own random password, Pwd is ignored then
+	RandomPwd bool `json:"random_pwd"`
+	// ExpireHours is the lifetime of a sharing from its creation or refresh, 0 means it never expires
+	ExpireHours int    `json:"expire_hours"`
+	MaxAccessed int    `json:"max_accessed"`
+	Remark      string `json:"remark"`
+	Readme      string `json:"readme" gorm:"type:text"`
+	Header      string `json:"header" gorm:"type:text"`
+}
+
+// WatchRule shares the subfolders appearing under Path and posts the links to the notification channels
+type WatchRule struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name" binding:"required"`
+	Path     string `json:"path" binding:"required"`
+	Disabled bool   `json:"disabled"`
+	// Interval between two scans in minutes
+	Interval int `json:"interval"`
+	// CreatorID is the user the sharings are created for
+	CreatorID uint `json:"creator_id"`
+	// Refresh renews the sharing of a subfolder modified since it was shared
+	Refresh bool `json:"refresh"`
+	// IncludeExisting shares the subfolders found by the first scan too, otherwise only the later ones are shared
+	IncludeExisting bool            `json:"include_existing"`
+	Template        SharingTemplate `json:"template" gorm:"embedded;embeddedPrefix:template_"`
+	// WebhookURL receives a json POST with the links of every scan that shared something
+	WebhookURL  string     `json:"webhook_url"`
+	NotifyEmail string     `json:"notify_email"`
+	LastScanAt  *time.Time `json:"last_scan_at"`
+	LastError   string     `json:"last_error"`
+}
+
+// WatchShare is a subfolder seen by a watch rule, SharingID is empty
+// for the subfolders that existed before the rule and were skipped
+type WatchShare struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	RuleID    uint      `json:"rule_id" gorm:"index"`
+	Path      string    `json:"path"`
+	SharingID string    `json:"sharing_id"`
+	Modified  time.Time `json:"modified"`
+}
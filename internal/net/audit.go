@@ -0,0 +1,118 @@
+package net
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+const (
+	AuditModeOff       = "off"
+	AuditModeHeader    = "header"
+	AuditModeUserAgent = "user_agent"
+)
+
+const (
+	AuditUserHeader      = "X-OpenList-User"
+	AuditRequestIDHeader = "X-OpenList-Request-Id"
+	AuditClientIPHeader  = "X-OpenList-Client-Ip"
+)
+
+// AuditInfo identifies the request of a user that caused a request to a storage provider,
+// so the audit logs of the provider can be correlated with OpenList users
+type AuditInfo struct {
+	User      string
+	RequestID string
+	ClientIP  string
+}
+
+// GetAuditInfo reads the audit info from the context values set by the server
+func GetAuditInfo(ctx context.Context) AuditInfo {
+	var info AuditInfo
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok && user != nil {
+		info.User = user.Username
+	}
+	info.RequestID, _ = ctx.Value(conf.RequestIDKey).(string)
+	info.ClientIP, _ = ctx.Value(conf.ClientIPKey).(string)
+	return info
+}
+
+func (a AuditInfo) userAgentSuffix() string {
+	var parts []string
+	if a.User != "" {
+		parts = append(parts, "user="+url.QueryEscape(a.User))
+	}
+	if a.RequestID != "" {
+		parts = append(parts, "req="+url.QueryEscape(a.RequestID))
+	}
+	if a.ClientIP != "" {
+		parts = append(parts, "ip="+url.QueryEscape(a.ClientIP))
+	}
+	return "OpenList-Audit (" + strings.Join(parts, "; ") + ")"
+}
+
+// auditAllowed reports whether the host is one of the driver_audit_hosts or a subdomain of them
+func auditAllowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range conf.DriverAudit.Hosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAuditInfo adds the audit info of the request context to the request, as headers
+// or as a suffix of the user agent depending on the driver_audit_mode setting.
+// Nothing is added for hosts that are not in the driver_audit_hosts setting.
+func SetAuditInfo(req *http.Request) {
+	mode := conf.DriverAudit.Mode
+	if mode != AuditModeHeader && mode != AuditModeUserAgent {
+		return
+	}
+	if req.URL == nil || !auditAllowed(req.URL.Hostname()) {
+		return
+	}
+	info := GetAuditInfo(req.Context())
+	if info == (AuditInfo{}) {
+		return
+	}
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	if mode == AuditModeUserAgent {
+		ua := req.Header.Get("User-Agent")
+		if ua != "" {
+			ua += " "
+		}
+		req.Header.Set("User-Agent", ua+info.userAgentSuffix())
+		return
+	}
+	if info.User != "" {
+		req.Header.Set(AuditUserHeader, url.QueryEscape(info.User))
+	}
+	if info.RequestID != "" {
+		req.Header.Set(AuditRequestIDHeader, info.RequestID)
+	}
+	if info.ClientIP != "" {
+		req.Header.Set(AuditClientIPHeader, info.ClientIP)
+	}
+}
+
+// auditTransport calls SetAuditInfo on a copy of every request before sending it
+type auditTransport struct {
+	http.RoundTripper
+}
+
+func (t auditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if conf.DriverAudit.Mode == AuditModeHeader || conf.DriverAudit.Mode == AuditModeUserAgent {
+		// a RoundTripper must not modify the request
+		req = req.Clone(req.Context())
+		SetAuditInfo(req)
+	}
+	return t.RoundTripper.RoundTrip(req)
+}
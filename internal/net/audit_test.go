@@ -0,0 +1,51 @@
+package net
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestSetAuditInfo(t *testing.T) {
+	old := conf.DriverAudit
+	t.Cleanup(func() { conf.DriverAudit = old })
+	conf.DriverAudit.Hosts = []string{"example.com"}
+
+	ctx := context.WithValue(context.Background(), conf.UserKey, &model.User{Username: "alice"})
+	ctx = context.WithValue(ctx, conf.RequestIDKey, "req1")
+	ctx = context.WithValue(ctx, conf.ClientIPKey, "10.0.0.1")
+	newReq := func(url string) *http.Request {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		req.Header.Set("User-Agent", "OpenList")
+		return req
+	}
+
+	conf.DriverAudit.Mode = AuditModeOff
+	req := newReq("https://api.example.com/files")
+	SetAuditInfo(req)
+	if req.Header.Get(AuditUserHeader) != "" || req.Header.Get("User-Agent") != "OpenList" {
+		t.Fatalf("audit info added while off: %v", req.Header)
+	}
+
+	conf.DriverAudit.Mode = AuditModeHeader
+	req = newReq("https://api.example.com/files")
+	SetAuditInfo(req)
+	if req.Header.Get(AuditUserHeader) != "alice" || req.Header.Get(AuditRequestIDHeader) != "req1" || req.Header.Get(AuditClientIPHeader) != "10.0.0.1" {
+		t.Fatalf("unexpected audit headers: %v", req.Header)
+	}
+	req = newReq("https://notexample.com/files")
+	SetAuditInfo(req)
+	if req.Header.Get(AuditUserHeader) != "" {
+		t.Fatalf("audit info sent to a host not allowed: %v", req.Header)
+	}
+
+	conf.DriverAudit.Mode = AuditModeUserAgent
+	req = newReq("https://example.com/files")
+	SetAuditInfo(req)
+	if ua := req.Header.Get("User-Agent"); ua != "OpenList OpenList-Audit (user=alice; req=req1; ip=10.0.0.1)" {
+		t.Fatalf("unexpected user agent %q", ua)
+	}
+}
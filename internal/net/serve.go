@@ -292,6 +292,6 @@ func NewHttpClient() *http.Client {
 
 	return &http.Client{
 		Timeout:   time.Hour * 48,
-		Transport: transport,
+		Transport: auditTransport{transport},
 	}
 }
@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/pkg/errors"
+)
+
+func MailEnabled() bool {
+	return setting.GetStr(conf.SmtpHost) != ""
+}
+
+// SendMail sends a plain text mail with the smtp server configured in settings.
+// Port 465 uses implicit TLS, other ports upgrade with STARTTLS when the server supports it.
+func SendMail(to, subject, body string) error {
+	host := setting.GetStr(conf.SmtpHost)
+	if host == "" {
+		return errors.New("smtp is not configured")
+	}
+	port := setting.GetInt(conf.SmtpPort, 587)
+	username := setting.GetStr(conf.SmtpUsername)
+	from := setting.GetStr(conf.SmtpFrom, username)
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, setting.GetStr(conf.SmtpPassword), host)
+	}
+	msg := buildMail(from, to, subject, body)
+	if port != 465 {
+		return errors.WithStack(smtp.SendMail(addr, auth, from, []string{to}, msg))
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", addr, &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: conf.Conf.TlsInsecureSkipVerify,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return errors.WithStack(err)
+	}
+	defer c.Close()
+	if auth != nil {
+		if err = c.Auth(auth); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err = c.Mail(from); err != nil {
+		return errors.WithStack(err)
+	}
+	if err = c.Rcpt(to); err != nil {
+		return errors.WithStack(err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err = w.Write(msg); err != nil {
+		return errors.WithStack(err)
+	}
+	if err = w.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(c.Quit())
+}
+
+func buildMail(from, to, subject, body string) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", from)
+	fmt.Fprintf(&sb, "To: %s\r\n", to)
+	fmt.Fprintf(&sb, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&sb, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(sb.String())
+}
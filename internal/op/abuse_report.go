@@ -0,0 +1,76 @@
+package op
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateAbuseReport(r *model.AbuseReport) error {
+	r.Status = model.AbuseReportPending
+	r.CreatedAt = time.Now()
+	r.HandledAt = nil
+	return db.CreateAbuseReport(r)
+}
+
+func GetAbuseReports(status int, pageIndex, pageSize int) ([]model.AbuseReport, int64, error) {
+	return db.GetAbuseReports(status, pageIndex, pageSize)
+}
+
+func GetAbuseReportById(id uint) (*model.AbuseReport, error) {
+	return db.GetAbuseReportById(id)
+}
+
+func DeleteAbuseReportById(id uint) error {
+	return db.DeleteAbuseReportById(id)
+}
+
+// HandleAbuseReport closes the report with the given status.
+// If disableSharing is set, the reported sharing is disabled and every other pending
+// report against it is resolved as well. All reports closed by this call are returned.
+func HandleAbuseReport(id uint, status int, note string, disableSharing bool) ([]model.AbuseReport, error) {
+	if status != model.AbuseReportResolved && status != model.AbuseReportDismissed {
+		return nil, errors.WithStack(errs.InvalidAbuseReportStatus)
+	}
+	r, err := db.GetAbuseReportById(id)
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsPending() {
+		return nil, errors.WithStack(errs.AbuseReportHandled)
+	}
+	reports := []model.AbuseReport{*r}
+	if disableSharing {
+		s, err := GetSharingById(r.SharingID, true)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed get reported sharing")
+		}
+		s.Disabled = true
+		if err = UpdateSharing(s, true); err != nil {
+			return nil, errors.WithMessage(err, "failed disable reported sharing")
+		}
+		status = model.AbuseReportResolved
+		others, err := db.GetPendingAbuseReportsBySharingId(r.SharingID)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range others {
+			if o.ID != r.ID {
+				reports = append(reports, o)
+			}
+		}
+	}
+	now := time.Now()
+	for i := range reports {
+		reports[i].Status = status
+		reports[i].Note = note
+		reports[i].HandledAt = &now
+		if err = db.UpdateAbuseReport(&reports[i]); err != nil {
+			return nil, err
+		}
+	}
+	return reports, nil
+}
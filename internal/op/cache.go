@@ -63,6 +63,14 @@ func (cm *CacheManager) DeleteDirectory(storage driver.Driver, dirPath string) {
 	cm.dirCache.Delete(Key(storage, dirPath))
 }
 
+// GetDirectory returns the cached objects of a directory, it never lists the storage
+func (cm *CacheManager) GetDirectory(storage driver.Driver, dirPath string) ([]model.Obj, bool) {
+	if dirCache, exists := cm.dirCache.Get(Key(storage, utils.FixAndCleanPath(dirPath))); exists {
+		return dirCache.GetSortedObjects(storage), true
+	}
+	return nil, false
+}
+
 // remove object from dirCache.
 // if it's a directory, remove all its children from dirCache too.
 // if it's a file, remove its link from linkCache.
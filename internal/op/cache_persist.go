@@ -0,0 +1,337 @@
+package op
+
+import (
+	"compress/gzip"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const cacheSnapshotVersion = 1
+
+// The snapshot only keeps what can be rebuilt exactly. Objects of the common model
+// types are kept, a directory with an object of a driver specific type is skipped,
+// because the driver would fail to assert its type when the object is used later.
+// Links are kept if they are plain urls with a known expiration.
+type cacheSnapshot struct {
+	Version  int                         `json:"version"`
+	SavedAt  time.Time                   `json:"saved_at"`
+	Storages map[string]*storageSnapshot `json:"storages"`
+}
+
+type storageSnapshot struct {
+	// Driver and Modified make sure the storage hasn't changed since the snapshot
+	Driver   string         `json:"driver"`
+	Modified time.Time      `json:"modified"`
+	Dirs     []dirSnapshot  `json:"dirs"`
+	Links    []linkSnapshot `json:"links"`
+}
+
+type dirSnapshot struct {
+	Key      string        `json:"key"`
+	ExpireAt time.Time     `json:"expire_at"`
+	Objs     []objSnapshot `json:"objs"`
+}
+
+type linkSnapshot struct {
+	Key           string      `json:"key"`
+	TypeKey       string      `json:"type_key"`
+	ExpireAt      time.Time   `json:"expire_at"`
+	URL           string      `json:"url"`
+	Header        http.Header `json:"header"`
+	Concurrency   int         `json:"concurrency"`
+	PartSize      int         `json:"part_size"`
+	ContentLength int64       `json:"content_length"`
+	Obj           objSnapshot `json:"obj"`
+}
+
+type objSnapshot struct {
+	// WrapName is the mapped name if the object was wrapped by model.WrapObjName
+	WrapName  string        `json:"wrap_name,omitempty"`
+	ID        string        `json:"id"`
+	Path      string        `json:"path"`
+	Name      string        `json:"name"`
+	Size      int64         `json:"size"`
+	Modified  time.Time     `json:"modified"`
+	Ctime     time.Time     `json:"ctime"`
+	IsFolder  bool          `json:"is_folder"`
+	Hash      string        `json:"hash,omitempty"`
+	Mask      model.ObjMask `json:"mask,omitempty"`
+	Thumbnail string        `json:"thumbnail,omitempty"`
+	Url       string        `json:"url,omitempty"`
+	// Kind is object, thumb, url or thumb_url
+	Kind string `json:"kind"`
+}
+
+func snapshotObj(obj model.Obj) (objSnapshot, bool) {
+	var s objSnapshot
+	if w, ok := obj.(*model.ObjWrapName); ok {
+		s.WrapName = w.Name
+		obj = w.Obj
+	}
+	var o *model.Object
+	switch v := obj.(type) {
+	case *model.Object:
+		o, s.Kind = v, "object"
+	case *model.ObjThumb:
+		o, s.Kind, s.Thumbnail = &v.Object, "thumb", v.Thumbnail.Thumbnail
+	case *model.ObjectURL:
+		o, s.Kind, s.Url = &v.Object, "url", v.Url.Url
+	case *model.ObjThumbURL:
+		o, s.Kind, s.Thumbnail, s.Url = &v.Object, "thumb_url", v.Thumbnail.Thumbnail, v.Url.Url
+	default:
+		return s, false
+	}
+	s.ID, s.Path, s.Name, s.Size = o.ID, o.Path, o.Name, o.Size
+	s.Modified, s.Ctime, s.IsFolder, s.Mask = o.Modified, o.Ctime, o.IsFolder, o.Mask
+	if len(o.HashInfo.Export()) > 0 {
+		s.Hash = o.HashInfo.String()
+	}
+	return s, true
+}
+
+func (s objSnapshot) restore() model.Obj {
+	o := model.Object{
+		ID:       s.ID,
+		Path:     s.Path,
+		Name:     s.Name,
+		Size:     s.Size,
+		Modified: s.Modified,
+		Ctime:    s.Ctime,
+		IsFolder: s.IsFolder,
+		Mask:     s.Mask,
+	}
+	if s.Hash != "" {
+		o.HashInfo = utils.FromString(s.Hash)
+	}
+	var obj model.Obj
+	switch s.Kind {
+	case "thumb":
+		obj = &model.ObjThumb{Object: o, Thumbnail: model.Thumbnail{Thumbnail: s.Thumbnail}}
+	case "url":
+		obj = &model.ObjectURL{Object: o, Url: model.Url{Url: s.Url}}
+	case "thumb_url":
+		obj = &model.ObjThumbURL{Object: o, Thumbnail: model.Thumbnail{Thumbnail: s.Thumbnail}, Url: model.Url{Url: s.Url}}
+	default:
+		obj = &o
+	}
+	if s.WrapName != "" {
+		obj = &model.ObjWrapName{Name: s.WrapName, Obj: obj}
+	}
+	return obj
+}
+
+// storageOfKey returns the mount path of the storage a cache key belongs to,
+// mountPaths must be sorted from the longest
+func storageOfKey(mountPaths []string, key string) (string, bool) {
+	for _, mountPath := range mountPaths {
+		if key == mountPath || strings.HasPrefix(key, strings.TrimSuffix(mountPath, "/")+"/") {
+			return mountPath, true
+		}
+	}
+	return "", false
+}
+
+func takeCacheSnapshot() *cacheSnapshot {
+	snapshot := &cacheSnapshot{
+		Version:  cacheSnapshotVersion,
+		SavedAt:  time.Now(),
+		Storages: map[string]*storageSnapshot{},
+	}
+	var mountPaths []string
+	for _, storage := range GetAllStorages() {
+		s := storage.GetStorage()
+		if s.Status != WORK || storage.Config().NoCache {
+			continue
+		}
+		mountPaths = append(mountPaths, s.MountPath)
+		snapshot.Storages[s.MountPath] = &storageSnapshot{Driver: s.Driver, Modified: s.Modified}
+	}
+	sort.Slice(mountPaths, func(i, j int) bool {
+		return len(mountPaths[i]) > len(mountPaths[j])
+	})
+	Cache.dirCache.Range(func(key string, dc *directoryCache, expireAt time.Time) bool {
+		mountPath, ok := storageOfKey(mountPaths, key)
+		if !ok {
+			return true
+		}
+		dc.mu.RLock()
+		objs := make([]objSnapshot, 0, len(dc.objs))
+		for _, obj := range dc.objs {
+			o, ok := snapshotObj(obj)
+			if !ok {
+				objs = nil
+				break
+			}
+			objs = append(objs, o)
+		}
+		dc.mu.RUnlock()
+		if objs != nil {
+			s := snapshot.Storages[mountPath]
+			s.Dirs = append(s.Dirs, dirSnapshot{Key: key, ExpireAt: expireAt, Objs: objs})
+		}
+		return true
+	})
+	Cache.linkCache.Range(func(key, typeKey string, ol *objWithLink, expireAt time.Time) bool {
+		mountPath, ok := storageOfKey(mountPaths, key)
+		if !ok {
+			return true
+		}
+		link := ol.link
+		if link.URL == "" || link.RangeReader != nil || link.RequireReference || link.Expiration == nil {
+			return true
+		}
+		obj, ok := snapshotObj(ol.obj)
+		if !ok {
+			return true
+		}
+		s := snapshot.Storages[mountPath]
+		s.Links = append(s.Links, linkSnapshot{
+			Key:           key,
+			TypeKey:       typeKey,
+			ExpireAt:      expireAt,
+			URL:           link.URL,
+			Header:        link.Header,
+			Concurrency:   link.Concurrency,
+			PartSize:      link.PartSize,
+			ContentLength: link.ContentLength,
+			Obj:           obj,
+		})
+		return true
+	})
+	return snapshot
+}
+
+// SaveCache writes the directory and link caches of the working storages to file
+func SaveCache(file string) error {
+	snapshot := takeCacheSnapshot()
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+	// write to a temp file first, so a crash never leaves a half written snapshot
+	tmp := file + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	zw := gzip.NewWriter(f)
+	err = utils.Json.NewEncoder(zw).Encode(snapshot)
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return errors.Wrap(err, "failed write cache snapshot")
+	}
+	if err = os.Rename(tmp, file); err != nil {
+		return errors.WithStack(err)
+	}
+	dirs, links := 0, 0
+	for _, s := range snapshot.Storages {
+		dirs += len(s.Dirs)
+		links += len(s.Links)
+	}
+	log.Infof("saved %d cached directories and %d cached links to %s", dirs, links, file)
+	return nil
+}
+
+var (
+	pendingCache     map[string]*storageSnapshot
+	pendingCacheLock sync.Mutex
+)
+
+// LoadCache reads a snapshot written by SaveCache. The entries of a storage are
+// restored when the storage is initialized, if it hasn't changed since the snapshot.
+// The file is removed, a snapshot is only used by the next start.
+func LoadCache(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.WithStack(err)
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(file)
+	}()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return errors.Wrap(err, "invalid cache snapshot")
+	}
+	var snapshot cacheSnapshot
+	if err = utils.Json.NewDecoder(zr).Decode(&snapshot); err != nil {
+		return errors.Wrap(err, "invalid cache snapshot")
+	}
+	if snapshot.Version != cacheSnapshotVersion {
+		return errors.Errorf("unsupported cache snapshot version %d", snapshot.Version)
+	}
+	pendingCacheLock.Lock()
+	pendingCache = snapshot.Storages
+	pendingCacheLock.Unlock()
+	log.Infof("loaded cache snapshot of %d storages saved at %s", len(snapshot.Storages), snapshot.SavedAt.Format(time.RFC3339))
+	return nil
+}
+
+// restoreStorageCache fills the caches of a storage that was just initialized from the pending snapshot
+func restoreStorageCache(storage driver.Driver) {
+	s := storage.GetStorage()
+	pendingCacheLock.Lock()
+	snapshot, ok := pendingCache[s.MountPath]
+	delete(pendingCache, s.MountPath)
+	pendingCacheLock.Unlock()
+	if !ok || storage.Config().NoCache {
+		return
+	}
+	if snapshot.Driver != s.Driver || snapshot.Modified.Unix() != s.Modified.Unix() {
+		log.Infof("storage %s changed since the cache snapshot, skip restoring its cache", s.MountPath)
+		return
+	}
+	now := time.Now()
+	dirs, links := 0, 0
+	for _, d := range snapshot.Dirs {
+		ttl := d.ExpireAt.Sub(now)
+		if ttl <= 0 {
+			continue
+		}
+		objs := make([]model.Obj, 0, len(d.Objs))
+		for _, o := range d.Objs {
+			objs = append(objs, o.restore())
+		}
+		dc := newDirectoryCache(objs)
+		// the objects may have been updated after they were sorted, sort them again on the first read
+		dc.dirtyFlags = dirtyUpdate
+		Cache.dirCache.SetWithTTL(d.Key, dc, ttl)
+		dirs++
+	}
+	for _, l := range snapshot.Links {
+		ttl := l.ExpireAt.Sub(now)
+		if ttl <= 0 {
+			continue
+		}
+		link := &model.Link{
+			URL:           l.URL,
+			Header:        l.Header,
+			Expiration:    &ttl,
+			Concurrency:   l.Concurrency,
+			PartSize:      l.PartSize,
+			ContentLength: l.ContentLength,
+		}
+		Cache.linkCache.SetTypeWithTTL(l.Key, l.TypeKey, &objWithLink{link: link, obj: l.Obj.restore()}, ttl)
+		links++
+	}
+	log.Infof("restored %d cached directories and %d cached links of storage %s", dirs, links, s.MountPath)
+}
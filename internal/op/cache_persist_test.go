@@ -0,0 +1,83 @@
+package op_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestCachePersist(t *testing.T) {
+	ctx := context.Background()
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:          "Mock",
+		MountPath:       "/persist",
+		CacheExpiration: 30,
+		Addition:        `{"seed":3,"depth":1,"num_folder":0,"num_file":4}`,
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteStorageById(ctx, id)
+	})
+	storage, err := op.GetStorageByMountPath("/persist")
+	if err != nil {
+		t.Fatalf("failed get storage: %+v", err)
+	}
+	objs, err := op.List(ctx, storage, "/", model.ListArgs{})
+	if err != nil || len(objs) != 4 {
+		t.Fatalf("failed list: %d %+v", len(objs), err)
+	}
+
+	file := filepath.Join(t.TempDir(), "cache.json.gz")
+	if err = op.SaveCache(file); err != nil {
+		t.Fatalf("failed save cache: %+v", err)
+	}
+	op.Cache.ClearAll()
+	if err = op.LoadCache(file); err != nil {
+		t.Fatalf("failed load cache: %+v", err)
+	}
+	if _, err = os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("expected the snapshot to be removed after loading")
+	}
+	// reloading the storage restores its cache from the snapshot
+	if err = op.DisableStorage(ctx, id); err != nil {
+		t.Fatalf("failed disable storage: %+v", err)
+	}
+	if err = op.EnableStorage(ctx, id); err != nil {
+		t.Fatalf("failed enable storage: %+v", err)
+	}
+
+	// the storage fails every listing now, so only the restored cache can answer
+	dev := flags.Dev
+	flags.Dev = true
+	t.Cleanup(func() {
+		_ = op.SetChaosRules(nil)
+		flags.Dev = dev
+	})
+	if err = op.SetChaosRules([]op.ChaosRule{{MountPath: "/persist", Ops: []string{op.ChaosList}, Probability: 1, Error: "offline"}}); err != nil {
+		t.Fatalf("failed set chaos rules: %+v", err)
+	}
+	storage, err = op.GetStorageByMountPath("/persist")
+	if err != nil {
+		t.Fatalf("failed get storage: %+v", err)
+	}
+	restored, err := op.List(ctx, storage, "/", model.ListArgs{})
+	if err != nil {
+		t.Fatalf("expected the listing to be restored: %+v", err)
+	}
+	if len(restored) != len(objs) {
+		t.Fatalf("expected %d objects, got %d", len(objs), len(restored))
+	}
+	for i := range objs {
+		if restored[i].GetName() != objs[i].GetName() || restored[i].GetSize() != objs[i].GetSize() {
+			t.Errorf("expected %s, got %s", objs[i].GetName(), restored[i].GetName())
+		}
+	}
+}
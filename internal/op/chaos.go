@@ -0,0 +1,194 @@
+package op
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ChaosList   = "list"
+	ChaosLink   = "link"
+	ChaosMkdir  = "mkdir"
+	ChaosMove   = "move"
+	ChaosRename = "rename"
+	ChaosCopy   = "copy"
+	ChaosRemove = "remove"
+	ChaosPut    = "put"
+)
+
+var chaosOps = []string{ChaosList, ChaosLink, ChaosMkdir, ChaosMove, ChaosRename, ChaosCopy, ChaosRemove, ChaosPut}
+
+// ChaosRule injects faults into the driver calls of the storages it matches,
+// so that retries, circuit breakers and failover mirrors can be verified.
+type ChaosRule struct {
+	// Driver and MountPath select the storages, empty matches every storage
+	Driver    string `json:"driver"`
+	MountPath string `json:"mount_path"`
+	// Ops limits latency and errors to these operations, empty means all of them
+	Ops []string `json:"ops"`
+	// Probability in [0, 1] that each fault is injected into a call
+	Probability float64 `json:"probability"`
+	// Latency in milliseconds added to the call, 0 disables it
+	Latency int64 `json:"latency"`
+	// Error is the message of the injected error, empty disables it
+	Error string `json:"error"`
+	// Truncate cuts proxied reads after this many bytes, 0 disables it
+	Truncate int64 `json:"truncate"`
+}
+
+var chaosRules atomic.Pointer[[]ChaosRule]
+
+// ChaosAllowed reports whether faults may be injected, which is never the case in production
+func ChaosAllowed() bool {
+	return flags.Dev || flags.Debug
+}
+
+func GetChaosRules() []ChaosRule {
+	if rules := chaosRules.Load(); rules != nil {
+		return *rules
+	}
+	return []ChaosRule{}
+}
+
+// SetChaosRules replaces the rules, they are kept in memory only and are gone after a restart
+func SetChaosRules(rules []ChaosRule) error {
+	if !ChaosAllowed() {
+		return errors.New("chaos rules are only available in dev or debug mode")
+	}
+	for i, r := range rules {
+		if r.Probability < 0 || r.Probability > 1 {
+			return errors.Errorf("rule %d: probability must be between 0 and 1", i)
+		}
+		if r.Latency < 0 || r.Truncate < 0 {
+			return errors.Errorf("rule %d: latency and truncate must not be negative", i)
+		}
+		for _, op := range r.Ops {
+			if !utils.SliceContains(chaosOps, op) {
+				return errors.Errorf("rule %d: unknown op [%s]", i, op)
+			}
+		}
+		if r.MountPath != "" {
+			rules[i].MountPath = utils.FixAndCleanPath(r.MountPath)
+		}
+	}
+	chaosRules.Store(&rules)
+	// cached links were created without the new rules
+	Cache.linkCache.Clear()
+	log.Warnf("chaos rules updated, %d rules are active", len(rules))
+	return nil
+}
+
+func (r *ChaosRule) match(storage driver.Driver) bool {
+	return (r.Driver == "" || r.Driver == storage.Config().Name) &&
+		(r.MountPath == "" || r.MountPath == storage.GetStorage().MountPath)
+}
+
+func (r *ChaosRule) hit() bool {
+	return rand.Float64() < r.Probability
+}
+
+func matchChaosRules(storage driver.Driver) []ChaosRule {
+	rules := chaosRules.Load()
+	if rules == nil || len(*rules) == 0 {
+		return nil
+	}
+	var res []ChaosRule
+	for _, r := range *rules {
+		if r.match(storage) {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// injectChaos is called right before the driver is called for op
+func injectChaos(ctx context.Context, storage driver.Driver, op string) error {
+	for _, r := range matchChaosRules(storage) {
+		if len(r.Ops) > 0 && !utils.SliceContains(r.Ops, op) {
+			continue
+		}
+		if r.Latency > 0 && r.hit() {
+			t := time.NewTimer(time.Duration(r.Latency) * time.Millisecond)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			}
+		}
+		if r.Error != "" && r.hit() {
+			log.Debugf("chaos: inject error into %s of [%s]", op, storage.GetStorage().MountPath)
+			return errors.Errorf("chaos: %s", r.Error)
+		}
+	}
+	return nil
+}
+
+// chaosLink makes the reads of the link truncated at random if a rule asks for it.
+// Links that are redirected to can't be truncated, only proxied reads are affected.
+func chaosLink(storage driver.Driver, file model.Obj, link *model.Link) {
+	truncate := false
+	for _, r := range matchChaosRules(storage) {
+		if r.Truncate > 0 {
+			truncate = true
+			break
+		}
+	}
+	if !truncate {
+		return
+	}
+	rr, err := stream.GetRangeReaderFromLink(file.GetSize(), link)
+	if err != nil {
+		log.Warnf("chaos: failed get range reader of [%s]: %+v", file.GetName(), err)
+		return
+	}
+	link.RangeReader = &chaosRangeReader{storage: storage, rr: rr}
+}
+
+type chaosRangeReader struct {
+	storage driver.Driver
+	rr      model.RangeReaderIF
+}
+
+func (c *chaosRangeReader) RangeRead(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+	rc, err := c.rr.RangeRead(ctx, httpRange)
+	if err != nil {
+		return nil, err
+	}
+	// rules are looked up again, so removing them stops the truncation at once
+	for _, r := range matchChaosRules(c.storage) {
+		if r.Truncate > 0 && r.hit() {
+			return &truncatedReader{ReadCloser: rc, remain: r.Truncate}, nil
+		}
+	}
+	return rc, nil
+}
+
+type truncatedReader struct {
+	io.ReadCloser
+	remain int64
+}
+
+func (t *truncatedReader) Read(p []byte) (int, error) {
+	if t.remain <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if int64(len(p)) > t.remain {
+		p = p[:t.remain]
+	}
+	n, err := t.ReadCloser.Read(p)
+	t.remain -= int64(n)
+	return n, err
+}
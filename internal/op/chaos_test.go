@@ -0,0 +1,153 @@
+package op_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+	"github.com/pkg/errors"
+)
+
+func mountChaos(t *testing.T, mountPath string) driver.Driver {
+	t.Helper()
+	ctx := context.Background()
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Mock",
+		MountPath: mountPath,
+		Addition:  `{"seed":4,"depth":1,"num_folder":0,"num_file":1,"file_size":64,"extensions":"txt"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteStorageById(ctx, id)
+	})
+	storage, err := op.GetStorageByMountPath(mountPath)
+	if err != nil {
+		t.Fatalf("failed get storage: %+v", err)
+	}
+	return storage
+}
+
+// allowChaos turns on the dev mode for the test, the rules are removed when it finishes
+func allowChaos(t *testing.T) {
+	dev, debug := flags.Dev, flags.Debug
+	flags.Dev, flags.Debug = true, false
+	t.Cleanup(func() {
+		_ = op.SetChaosRules(nil)
+		flags.Dev, flags.Debug = dev, debug
+	})
+}
+
+func setChaosRules(t *testing.T, rules ...op.ChaosRule) {
+	t.Helper()
+	if err := op.SetChaosRules(rules); err != nil {
+		t.Fatalf("failed set chaos rules: %+v", err)
+	}
+}
+
+func TestSetChaosRules(t *testing.T) {
+	dev, debug := flags.Dev, flags.Debug
+	flags.Dev, flags.Debug = false, false
+	err := op.SetChaosRules([]op.ChaosRule{{Probability: 1, Error: "down"}})
+	flags.Dev, flags.Debug = dev, debug
+	if err == nil {
+		t.Fatal("expected chaos rules to be refused outside of dev and debug mode")
+	}
+
+	allowChaos(t)
+	for _, r := range []op.ChaosRule{
+		{Probability: 1.5},
+		{Probability: 1, Latency: -1},
+		{Probability: 1, Truncate: -1},
+		{Probability: 1, Ops: []string{"upload"}},
+	} {
+		if err = op.SetChaosRules([]op.ChaosRule{r}); err == nil {
+			t.Errorf("expected %+v to be rejected", r)
+		}
+	}
+	setChaosRules(t, op.ChaosRule{MountPath: "chaos/", Probability: 1, Error: "down"})
+	if rules := op.GetChaosRules(); len(rules) != 1 || rules[0].MountPath != "/chaos" {
+		t.Errorf("expected the mount path to be cleaned, got %+v", rules)
+	}
+}
+
+func TestInjectChaos(t *testing.T) {
+	allowChaos(t)
+	ctx := context.Background()
+	storage := mountChaos(t, "/chaos")
+	other := mountChaos(t, "/chaos_other")
+	list := func(storage driver.Driver) error {
+		_, err := op.List(ctx, storage, "/", model.ListArgs{Refresh: true})
+		return err
+	}
+
+	setChaosRules(t, op.ChaosRule{MountPath: "/chaos", Ops: []string{op.ChaosMkdir}, Probability: 1, Error: "down"})
+	if err := op.MakeDir(ctx, storage, "/dir"); err == nil || !strings.Contains(err.Error(), "chaos: down") {
+		t.Errorf("expected the mkdir to fail, got %v", err)
+	}
+	if err := list(storage); err != nil {
+		t.Errorf("expected the other ops to be left alone, got %v", err)
+	}
+	if err := op.MakeDir(ctx, other, "/dir"); err != nil {
+		t.Errorf("expected the other storages to be left alone, got %v", err)
+	}
+
+	setChaosRules(t, op.ChaosRule{Driver: "Mock", Probability: 1, Error: "down"})
+	if list(storage) == nil || list(other) == nil {
+		t.Error("expected every storage of the driver to fail")
+	}
+	setChaosRules(t, op.ChaosRule{Driver: "Local", Probability: 1, Error: "down"}, op.ChaosRule{Probability: 0, Error: "down"})
+	if err := list(storage); err != nil {
+		t.Errorf("expected no fault from rules that don't match or never hit, got %v", err)
+	}
+
+	setChaosRules(t, op.ChaosRule{MountPath: "/chaos", Ops: []string{op.ChaosList}, Probability: 1, Latency: 200})
+	start := time.Now()
+	if err := list(storage); err != nil || time.Since(start) < 200*time.Millisecond {
+		t.Errorf("expected the listing to be delayed, got %v after %s", err, time.Since(start))
+	}
+	// the latency is cut short by the context
+	tCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := op.List(tCtx, storage, "/", model.ListArgs{Refresh: true}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the delayed listing to be canceled, got %v", err)
+	}
+}
+
+func TestChaosTruncate(t *testing.T) {
+	allowChaos(t)
+	ctx := context.Background()
+	storage := mountChaos(t, "/chaos_truncate")
+	read := func(link *model.Link) ([]byte, error) {
+		rc, err := link.RangeReader.RangeRead(ctx, http_range.Range{Length: -1})
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+
+	setChaosRules(t, op.ChaosRule{MountPath: "/chaos_truncate", Probability: 1, Truncate: 10})
+	link, file, err := op.Link(ctx, storage, "/file_0.txt", model.LinkArgs{})
+	if err != nil {
+		t.Fatalf("failed get link: %+v", err)
+	}
+	data, err := read(link)
+	if !errors.Is(err, io.ErrUnexpectedEOF) || len(data) != 10 {
+		t.Errorf("expected the read to be cut after 10 bytes, got %d bytes and %v", len(data), err)
+	}
+	// removing the rules stops the truncation of the links made before
+	setChaosRules(t)
+	if data, err = read(link); err != nil || int64(len(data)) != file.GetSize() {
+		t.Errorf("expected the full file of %d bytes, got %d bytes and %v", file.GetSize(), len(data), err)
+	}
+}
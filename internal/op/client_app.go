@@ -0,0 +1,130 @@
+package op
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/OpenListTeam/go-cache"
+	"github.com/pkg/errors"
+)
+
+const DefaultClientTokenTTL = 3600
+
+var clientAppCache = cache.NewMemCache(cache.WithShards[*model.ClientApp](2))
+
+func GetClientApps(pageIndex, pageSize int) ([]model.ClientApp, int64, error) {
+	return db.GetClientApps(pageIndex, pageSize)
+}
+
+func GetClientAppById(id uint) (*model.ClientApp, error) {
+	return db.GetClientAppById(id)
+}
+
+// GetClientAppByClientId is called for every request with an exchanged token, so it is cached
+func GetClientAppByClientId(clientId string) (*model.ClientApp, error) {
+	if a, ok := clientAppCache.Get(clientId); ok {
+		return a, nil
+	}
+	a, err := db.GetClientAppByClientId(clientId)
+	if err != nil {
+		return nil, err
+	}
+	clientAppCache.Set(clientId, a, cache.WithEx[*model.ClientApp](time.Minute*10))
+	return a, nil
+}
+
+func validateClientApp(a *model.ClientApp) error {
+	if a.Name == "" {
+		return errors.New("name is required")
+	}
+	for _, scope := range a.GetScopes() {
+		if !utils.SliceContains(model.Scopes, scope) {
+			return errors.Errorf("unknown scope [%s]", scope)
+		}
+	}
+	if a.TokenTTL <= 0 {
+		a.TokenTTL = DefaultClientTokenTTL
+	}
+	// the tokens of client apps never live longer than the ones of a login
+	if maxTTL := int64(conf.Conf.TokenExpiresIn) * 3600; a.TokenTTL > maxTTL {
+		return errors.Errorf("token ttl must not be longer than %d seconds", maxTTL)
+	}
+	return nil
+}
+
+// CreateClientApp creates the app with a new client id and returns its secret
+func CreateClientApp(a *model.ClientApp) (string, error) {
+	if err := validateClientApp(a); err != nil {
+		return "", err
+	}
+	a.ID = 0
+	a.ClientID = random.String(24)
+	a.CreatedAt = time.Now()
+	a.LastUsedAt = nil
+	secret := random.String(48)
+	a.SetSecret(secret)
+	if err := db.CreateClientApp(a); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+func UpdateClientApp(a *model.ClientApp) error {
+	old, err := db.GetClientAppById(a.ID)
+	if err != nil {
+		return err
+	}
+	if err = validateClientApp(a); err != nil {
+		return err
+	}
+	a.ClientID = old.ClientID
+	a.SecretHash = old.SecretHash
+	a.CreatedAt = old.CreatedAt
+	a.LastUsedAt = old.LastUsedAt
+	clientAppCache.Del(old.ClientID)
+	return db.UpdateClientApp(a)
+}
+
+// ResetClientAppSecret replaces the secret, tokens issued before stay valid until they expire
+func ResetClientAppSecret(id uint) (string, error) {
+	a, err := db.GetClientAppById(id)
+	if err != nil {
+		return "", err
+	}
+	secret := random.String(48)
+	a.SetSecret(secret)
+	clientAppCache.Del(a.ClientID)
+	return secret, db.UpdateClientApp(a)
+}
+
+func DeleteClientAppById(id uint) error {
+	a, err := db.GetClientAppById(id)
+	if err != nil {
+		return err
+	}
+	clientAppCache.Del(a.ClientID)
+	return db.DeleteClientAppById(id)
+}
+
+// VerifyClientApp checks the credential of an app, the same error is returned
+// whether the app doesn't exist or the secret is wrong
+func VerifyClientApp(clientId, secret string) (*model.ClientApp, error) {
+	invalid := errors.New("invalid client credentials")
+	if clientId == "" || secret == "" {
+		return nil, invalid
+	}
+	a, err := db.GetClientAppByClientId(clientId)
+	if err != nil || a.Disabled || !a.ValidateSecret(secret) {
+		return nil, invalid
+	}
+	now := time.Now()
+	a.LastUsedAt = &now
+	if err = db.UpdateClientApp(a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
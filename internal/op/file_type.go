@@ -0,0 +1,49 @@
+package op
+
+import (
+	"mime"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+var previewHandlers = []string{
+	conf.PreviewVideo, conf.PreviewAudio, conf.PreviewImage, conf.PreviewText,
+	conf.PreviewOffice, conf.PreviewExternal, conf.PreviewDownload,
+}
+
+// ParseFileTypes parses the value of the file_types setting, a json object whose
+// keys are comma separated extensions, like the ones of iframe_previews
+func ParseFileTypes(value string) (map[string]conf.FileType, error) {
+	var raw map[string]conf.FileType
+	if err := utils.Json.UnmarshalFromString(value, &raw); err != nil {
+		return nil, errors.WithMessage(err, "invalid file types")
+	}
+	fileTypes := make(map[string]conf.FileType)
+	for exts, ft := range raw {
+		if ft.Mime != "" {
+			if _, _, err := mime.ParseMediaType(ft.Mime); err != nil {
+				return nil, errors.Errorf("%s: invalid mime type [%s]", exts, ft.Mime)
+			}
+		}
+		if ft.Preview != "" && !utils.SliceContains(previewHandlers, ft.Preview) {
+			return nil, errors.Errorf("%s: unknown preview [%s]", exts, ft.Preview)
+		}
+		if ft.Preview == conf.PreviewExternal && ft.URL == "" {
+			return nil, errors.Errorf("%s: external preview needs an url", exts)
+		}
+		for _, ext := range strings.Split(exts, ",") {
+			ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+			if ext == "" {
+				continue
+			}
+			if _, ok := fileTypes[ext]; ok {
+				return nil, errors.Errorf("extension [%s] is mapped more than once", ext)
+			}
+			fileTypes[ext] = ft
+		}
+	}
+	return fileTypes, nil
+}
@@ -0,0 +1,33 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestParseFileTypes(t *testing.T) {
+	fileTypes, err := op.ParseFileTypes(`{"txt": {"mime": "text/x-custom"}, "DAT, .bin,": {"preview": "video"}}`)
+	if err != nil {
+		t.Fatalf("failed parse file types: %+v", err)
+	}
+	if len(fileTypes) != 3 || fileTypes["txt"].Mime != "text/x-custom" {
+		t.Errorf("unexpected file types: %+v", fileTypes)
+	}
+	if fileTypes["dat"].Preview != conf.PreviewVideo || fileTypes["bin"].Preview != conf.PreviewVideo {
+		t.Errorf("expected the extensions to be split and normalized, got %+v", fileTypes)
+	}
+
+	for _, value := range []string{
+		`{"txt": {"mime": "not a mime"}}`,
+		`{"txt": {"preview": "unknown"}}`,
+		`{"txt": {"preview": "external"}}`,
+		`{"txt,md": {}, "md": {}}`,
+		`["txt"]`,
+	} {
+		if _, err := op.ParseFileTypes(value); err == nil {
+			t.Errorf("expected %s to be rejected", value)
+		}
+	}
+}
@@ -57,6 +57,9 @@ func list(ctx context.Context, storage driver.Driver, path string, args model.Li
 		if !dir.IsDir() {
 			return nil, errors.WithStack(errs.NotFolder)
 		}
+		if err = injectChaos(ctx, storage, ChaosList); err != nil {
+			return nil, errors.WithMessage(err, "failed to list objs")
+		}
 		files, err := storage.List(ctx, dir, args)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to list objs")
@@ -258,10 +261,14 @@ func Link(ctx context.Context, storage driver.Driver, path string, args model.Li
 			return nil, errors.WithStack(errs.NotFile)
 		}
 
+		if err = injectChaos(ctx, storage, ChaosLink); err != nil {
+			return nil, errors.WithMessage(err, "failed get link")
+		}
 		link, err := storage.Link(ctx, file, args)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed get link")
 		}
+		chaosLink(storage, file, link)
 		ol := &objWithLink{link: link, obj: file}
 		if link.Expiration != nil {
 			Cache.linkCache.SetTypeWithTTL(key, typeKey, ol, *link.Expiration)
@@ -334,6 +341,9 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string) error {
 			return nil, errors.WithStack(errs.PermissionDenied)
 		}
 
+		if err = injectChaos(ctx, storage, ChaosMkdir); err != nil {
+			return nil, err
+		}
 		var newObj model.Obj
 		switch s := storage.(type) {
 		case driver.MkdirResult:
@@ -396,6 +406,9 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 		return errors.WithStack(errs.PermissionDenied)
 	}
 
+	if err = injectChaos(ctx, storage, ChaosMove); err != nil {
+		return err
+	}
 	var newObj model.Obj
 	switch s := storage.(type) {
 	case driver.MoveResult:
@@ -408,6 +421,8 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	mountPath := utils.GetActualMountPath(storage.GetStorage().MountPath)
+	moveScheduledDeletions(stdpath.Join(mountPath, srcPath), stdpath.Join(mountPath, dstDirPath, srcObj.GetName()))
 
 	srcKey := Key(storage, srcDirPath)
 	dstKey := Key(storage, dstDirPath)
@@ -460,6 +475,9 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string)
 	}
 	srcObj := model.UnwrapObjName(srcRawObj)
 
+	if err = injectChaos(ctx, storage, ChaosRename); err != nil {
+		return err
+	}
 	var newObj model.Obj
 	switch s := storage.(type) {
 	case driver.RenameResult:
@@ -472,6 +490,8 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string)
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	mountPath := utils.GetActualMountPath(storage.GetStorage().MountPath)
+	moveScheduledDeletions(stdpath.Join(mountPath, srcPath), stdpath.Join(mountPath, stdpath.Dir(srcPath), dstName))
 
 	dirKey := Key(storage, stdpath.Dir(srcPath))
 	if !srcRawObj.IsDir() {
@@ -529,6 +549,9 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 		return errors.WithStack(errs.PermissionDenied)
 	}
 
+	if err = injectChaos(ctx, storage, ChaosCopy); err != nil {
+		return err
+	}
 	var newObj model.Obj
 	switch s := storage.(type) {
 	case driver.CopyResult:
@@ -590,11 +613,15 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 	}
 	dirPath := stdpath.Dir(path)
 
+	if err = injectChaos(ctx, storage, ChaosRemove); err != nil {
+		return err
+	}
 	switch s := storage.(type) {
 	case driver.Remove:
 		err = s.Remove(ctx, model.UnwrapObjName(rawObj))
 		if err == nil {
 			Cache.removeDirectoryObject(storage, dirPath, rawObj)
+			moveScheduledDeletions(stdpath.Join(utils.GetActualMountPath(storage.GetStorage().MountPath), path), "")
 		}
 	default:
 		return errs.NotImplement
@@ -662,6 +689,9 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		file.CacheFullAndWriter(nil, nil)
 	}
 
+	if err = injectChaos(ctx, storage, ChaosPut); err != nil {
+		return err
+	}
 	var newObj model.Obj
 	switch s := storage.(type) {
 	case driver.PutResult:
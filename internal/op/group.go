@@ -0,0 +1,180 @@
+package op
+
+import (
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+func GetGroupById(id uint) (*model.Group, error) {
+	return db.GetGroupById(id)
+}
+
+func GetGroupByOwnerId(ownerId uint) (*model.Group, error) {
+	return db.GetGroupByOwnerId(ownerId)
+}
+
+func GetGroups(pageIndex, pageSize int) ([]model.Group, int64, error) {
+	return db.GetGroups(pageIndex, pageSize)
+}
+
+// getNewGroupOwner checks the user that will own the group
+func getNewGroupOwner(g *model.Group) (*model.User, error) {
+	owner, err := db.GetUserById(g.OwnerID)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get group owner")
+	}
+	if !owner.IsGroupOwner() {
+		return nil, errors.Errorf("user [%s] is not a group owner", owner.Username)
+	}
+	if owner.GroupID != 0 && owner.GroupID != g.ID {
+		return nil, errors.Errorf("user [%s] is already in another group", owner.Username)
+	}
+	return owner, nil
+}
+
+func CreateGroup(g *model.Group) error {
+	g.BasePath = utils.FixAndCleanPath(g.BasePath)
+	owner, err := getNewGroupOwner(g)
+	if err != nil {
+		return err
+	}
+	if err = db.CreateGroup(g); err != nil {
+		return err
+	}
+	owner.GroupID = g.ID
+	return UpdateUser(owner)
+}
+
+func UpdateGroup(g *model.Group) error {
+	old, err := db.GetGroupById(g.ID)
+	if err != nil {
+		return err
+	}
+	g.BasePath = utils.FixAndCleanPath(g.BasePath)
+	if old.OwnerID != g.OwnerID {
+		owner, err := getNewGroupOwner(g)
+		if err != nil {
+			return err
+		}
+		if oldOwner, err := db.GetUserById(old.OwnerID); err == nil && oldOwner.GroupID == g.ID {
+			oldOwner.GroupID = 0
+			if err = UpdateUser(oldOwner); err != nil {
+				return err
+			}
+		}
+		owner.GroupID = g.ID
+		if err = UpdateUser(owner); err != nil {
+			return err
+		}
+	}
+	// a smaller allocation doesn't touch the members, the next change of a member has to fit in again
+	return db.UpdateGroup(g)
+}
+
+func DeleteGroupById(id uint) error {
+	g, err := db.GetGroupById(id)
+	if err != nil {
+		return err
+	}
+	usage, err := GetGroupUsage(g)
+	if err != nil {
+		return err
+	}
+	if usage.Members > 0 {
+		return errors.Errorf("group [%s] still has %d members", g.Name, usage.Members)
+	}
+	if owner, err := db.GetUserById(g.OwnerID); err == nil && owner.GroupID == g.ID {
+		owner.GroupID = 0
+		if err = UpdateUser(owner); err != nil {
+			return err
+		}
+	}
+	return db.DeleteGroupById(id)
+}
+
+// GetGroupUsage counts the members of the group, not the owner, and the sharings of all of them
+func GetGroupUsage(g *model.Group) (*model.GroupUsage, error) {
+	users, err := db.CountUsersByGroupId(g.ID)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := db.CountSharingsByGroupId(g.ID)
+	if err != nil {
+		return nil, err
+	}
+	members := users
+	if owner, err := db.GetUserById(g.OwnerID); err == nil && owner.GroupID == g.ID {
+		members--
+	}
+	return &model.GroupUsage{Members: members, Shares: shares}, nil
+}
+
+func GetGroupMembers(g *model.Group, pageIndex, pageSize int) ([]model.User, int64, error) {
+	return db.GetUsersByGroupId(g.ID, pageIndex, pageSize)
+}
+
+// IsGroupMemberOf reports whether user is a member of the group owned by owner
+func IsGroupMemberOf(owner, user *model.User) bool {
+	return owner.IsGroupOwner() && owner.GroupID != 0 && user.GroupID == owner.GroupID && user.ID != owner.ID
+}
+
+// CheckGroupMember makes sure a member created or updated by the owner of
+// the group stays within the allocation of the group
+func CheckGroupMember(g *model.Group, u *model.User, create bool) error {
+	if u.Role != model.GENERAL {
+		return errors.New("group members must be general users")
+	}
+	u.GroupID = g.ID
+	u.BasePath = utils.FixAndCleanPath(u.BasePath)
+	if !utils.IsSubPath(g.BasePath, u.BasePath) {
+		return errors.WithMessagef(errs.GroupLimitExceeded, "base path must be in [%s]", g.BasePath)
+	}
+	if extra := u.Permission &^ g.Permission; extra != 0 {
+		return errors.WithMessagef(errs.GroupLimitExceeded, "permission bits %b are not allowed", extra)
+	}
+	if g.MaxShares > 0 && (u.MaxShares == 0 || u.MaxShares > g.MaxShares) {
+		return errors.WithMessagef(errs.GroupLimitExceeded, "max shares must be between 1 and %d", g.MaxShares)
+	}
+	if create && g.MaxMembers > 0 {
+		usage, err := GetGroupUsage(g)
+		if err != nil {
+			return err
+		}
+		if usage.Members >= int64(g.MaxMembers) {
+			return errors.WithMessagef(errs.GroupLimitExceeded, "the group has at most %d members", g.MaxMembers)
+		}
+	}
+	return nil
+}
+
+// shareQuotaLock serializes the creations of sharings within a quota, the transaction
+// alone doesn't stop two parallel creates from both counting the sharings before either insert
+var shareQuotaLock sync.Mutex
+
+// createSharingInQuota creates the sharing if its creator may create one more,
+// both the limit of the user and the limit of its group apply
+func createSharingInQuota(s *model.SharingDB, u *model.User) (string, error) {
+	shareQuotaLock.Lock()
+	defer shareQuotaLock.Unlock()
+	var g *model.Group
+	if u.GroupID != 0 {
+		var err error
+		if g, err = db.GetGroupById(u.GroupID); err != nil {
+			return "", err
+		}
+	}
+	return db.CreateSharingInQuota(s, u.GroupID, func(count, groupCount int64) error {
+		if u.MaxShares > 0 && count >= int64(u.MaxShares) {
+			return errors.WithMessagef(errs.ShareQuotaExceeded, "at most %d sharings are allowed", u.MaxShares)
+		}
+		if g != nil && g.MaxShares > 0 && groupCount >= int64(g.MaxShares) {
+			return errors.WithMessagef(errs.ShareQuotaExceeded, "the group has at most %d sharings", g.MaxShares)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,72 @@
+package op_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestShareQuota(t *testing.T) {
+	owner := &model.User{Username: "quota_owner", BasePath: "/", Role: model.GROUPOWNER, Authn: "[]"}
+	if err := op.CreateUser(owner); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	g := &model.Group{Name: "quota", OwnerID: owner.ID, BasePath: "/", MaxShares: 3}
+	if err := db.CreateGroup(g); err != nil {
+		t.Fatalf("failed create group: %+v", err)
+	}
+	member := &model.User{Username: "quota_member", BasePath: "/", GroupID: g.ID, MaxShares: 2, Authn: "[]"}
+	if err := op.CreateUser(member); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	owner.GroupID = g.ID
+	if err := op.UpdateUser(owner); err != nil {
+		t.Fatalf("failed update user: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteSharingsByCreatorId(member.ID)
+		_ = op.DeleteSharingsByCreatorId(owner.ID)
+		_ = db.DeleteUserById(member.ID)
+		_ = db.DeleteUserById(owner.ID)
+		_ = db.DeleteGroupById(g.ID)
+	})
+
+	// parallel creates must not get past the limit of the member
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created, exceeded := 0, 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := op.CreateSharing(&model.Sharing{SharingDB: &model.SharingDB{}, Files: []string{"/a"}, Creator: member})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				created++
+			case errors.Is(err, errs.ShareQuotaExceeded):
+				exceeded++
+			default:
+				t.Errorf("failed create sharing: %+v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if created != 2 || exceeded != 6 {
+		t.Errorf("expected 2 sharings within the quota of the member, got %d created and %d exceeded", created, exceeded)
+	}
+
+	// the owner has no limit of its own, but the group has
+	if _, err := op.CreateSharing(&model.Sharing{SharingDB: &model.SharingDB{}, Files: []string{"/b"}, Creator: owner}); err != nil {
+		t.Fatalf("failed create sharing within the group quota: %+v", err)
+	}
+	if _, err := op.CreateSharing(&model.Sharing{SharingDB: &model.SharingDB{}, Files: []string{"/c"}, Creator: owner}); !errors.Is(err, errs.ShareQuotaExceeded) {
+		t.Errorf("expected the group quota to be exceeded, got %v", err)
+	}
+}
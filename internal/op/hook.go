@@ -79,10 +79,32 @@ var settingItemHooks = map[string]SettingItemHook{
 		log.Debugf("filename char mapping: %+v", conf.FilenameCharMap)
 		return nil
 	},
+	conf.FileTypes: func(item *model.SettingItem) error {
+		fileTypes, err := ParseFileTypes(item.Value)
+		if err != nil {
+			return err
+		}
+		conf.FileTypeMap = fileTypes
+		return nil
+	},
 	conf.IgnoreDirectLinkParams: func(item *model.SettingItem) error {
 		conf.SlicesMap[conf.IgnoreDirectLinkParams] = strings.Split(item.Value, ",")
 		return nil
 	},
+	conf.DriverAuditMode: func(item *model.SettingItem) error {
+		conf.DriverAudit.Mode = item.Value
+		return nil
+	},
+	conf.DriverAuditHosts: func(item *model.SettingItem) error {
+		var hosts []string
+		for _, host := range strings.Split(item.Value, ",") {
+			if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+				hosts = append(hosts, host)
+			}
+		}
+		conf.DriverAudit.Hosts = hosts
+		return nil
+	},
 }
 
 func RegisterSettingItemHook(key string, hook SettingItemHook) {
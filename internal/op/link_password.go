@@ -0,0 +1,73 @@
+package op
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/cache"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// linkPasswordCache also keeps nil for the paths without a password, every download looks it up
+var linkPasswordCache = cache.NewKeyedCache[*model.LinkPassword](time.Hour)
+
+// GetLinkPassword returns the password protecting the direct links of path, nil if there is none.
+// The error must not be ignored, the protected links would be served without the password.
+func GetLinkPassword(path string) (*model.LinkPassword, error) {
+	path = utils.FixAndCleanPath(path)
+	if l, ok := linkPasswordCache.Get(path); ok {
+		return l, nil
+	}
+	l, err := db.GetLinkPasswordByPath(path)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		l = nil
+	}
+	linkPasswordCache.Set(path, l)
+	return l, nil
+}
+
+func GetLinkPasswords(pageIndex, pageSize int) ([]model.LinkPassword, int64, error) {
+	return db.GetLinkPasswords(pageIndex, pageSize)
+}
+
+// SetLinkPassword protects the direct links of path with pwd, replacing its previous password.
+// A replaced password keeps its creator.
+func SetLinkPassword(path, pwd string, creatorId uint) error {
+	if pwd == "" {
+		return errors.New("password is empty")
+	}
+	path = utils.FixAndCleanPath(path)
+	l, err := db.GetLinkPasswordByPath(path)
+	if err != nil {
+		l = &model.LinkPassword{Path: path, CreatorID: creatorId}
+	}
+	l.SetPassword(pwd)
+	linkPasswordCache.Delete(path)
+	return db.SaveLinkPassword(l)
+}
+
+func DeleteLinkPassword(path string) error {
+	path = utils.FixAndCleanPath(path)
+	l, err := db.GetLinkPasswordByPath(path)
+	if err != nil {
+		return err
+	}
+	linkPasswordCache.Delete(path)
+	return db.DeleteLinkPasswordById(l.ID)
+}
+
+func GetLinkPasswordsByCreatorId(creatorId uint) ([]model.LinkPassword, error) {
+	return db.GetLinkPasswordsByCreatorId(creatorId)
+}
+
+// ClearLinkPasswordCreator keeps the links of the creator protected, only the admin may change them then
+func ClearLinkPasswordCreator(creatorId uint) error {
+	defer linkPasswordCache.Clear()
+	return db.ClearLinkPasswordCreator(creatorId)
+}
@@ -0,0 +1,188 @@
+package op
+
+import (
+	stdpath "path"
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/cache"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// PendingGrantExpire is how long a grant waits for the approval of a second reviewer
+var PendingGrantExpire = 7 * 24 * time.Hour
+
+// grantCache keeps the approved grants of the users by user id
+var grantCache = cache.NewKeyedCache[[]model.PathGrant](time.Hour)
+
+// GetRestrictedPath returns the restricted path that path is in, if any
+func GetRestrictedPath(path string) (string, bool) {
+	path = utils.FixAndCleanPath(path)
+	for {
+		meta, err := GetMetaByPath(path)
+		if err == nil && meta.Restricted {
+			return path, true
+		}
+		if path == "/" {
+			return "", false
+		}
+		path = stdpath.Dir(path)
+	}
+}
+
+func getApprovedPathGrants(userId uint) []model.PathGrant {
+	key := strconv.Itoa(int(userId))
+	if grants, ok := grantCache.Get(key); ok {
+		return grants
+	}
+	grants, err := db.GetApprovedPathGrantsByUserId(userId)
+	if err != nil {
+		log.Errorf("failed get path grants: %+v", err)
+		return nil
+	}
+	grantCache.Set(key, grants)
+	return grants
+}
+
+// CanAccessRestricted checks whether user may access path regarding the restricted paths,
+// the admin always may, the others need an active grant covering path
+func CanAccessRestricted(user *model.User, path string) bool {
+	if user.IsAdmin() {
+		return true
+	}
+	if _, ok := GetRestrictedPath(path); !ok {
+		return true
+	}
+	now := time.Now()
+	for _, g := range getApprovedPathGrants(user.ID) {
+		if g.IsActive(now) && utils.IsSubPath(g.Path, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func ExpirePathGrants() error {
+	now := time.Now()
+	n, err := db.ExpirePathGrants(now, now.Add(-PendingGrantExpire))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Infof("%d path grants expired", n)
+		grantCache.Clear()
+	}
+	return nil
+}
+
+func GetPathGrants(filter model.PathGrant, pageIndex, pageSize int) ([]model.PathGrant, int64, error) {
+	if err := ExpirePathGrants(); err != nil {
+		return nil, 0, err
+	}
+	return db.GetPathGrants(filter, pageIndex, pageSize)
+}
+
+// RequestPathGrant files a grant by requester, it waits for the approval of another reviewer
+func RequestPathGrant(requester *model.User, g *model.PathGrant) error {
+	g.Path = utils.FixAndCleanPath(g.Path)
+	if _, ok := GetRestrictedPath(g.Path); !ok {
+		return errors.Errorf("path [%s] is not restricted", g.Path)
+	}
+	user, err := db.GetUserById(g.UserID)
+	if err != nil {
+		return errors.WithMessage(err, "failed get user of the grant")
+	}
+	if user.IsAdmin() || user.IsGuest() {
+		return errors.New("admin or guest user can not be granted")
+	}
+	now := time.Now()
+	if g.ExpiresAt != nil && !g.ExpiresAt.After(now) {
+		return errors.New("the grant expires in the past")
+	}
+	g.ID = 0
+	g.Status = model.GrantPending
+	g.RequestedBy = requester.ID
+	g.RequestedAt = now
+	g.DecidedBy = 0
+	g.DecidedAt = nil
+	if err = db.CreatePathGrant(g); err != nil {
+		return err
+	}
+	log.Infof("%s requested access of %s to %s", requester.Username, user.Username, g.Path)
+	return nil
+}
+
+func decidePathGrant(reviewer *model.User, id uint, from, to string) (*model.PathGrant, error) {
+	if err := ExpirePathGrants(); err != nil {
+		return nil, err
+	}
+	g, err := db.GetPathGrantById(id)
+	if err != nil {
+		return nil, err
+	}
+	if g.Status != from {
+		return nil, errors.Errorf("the grant is %s", g.Status)
+	}
+	now := time.Now()
+	g.Status = to
+	g.DecidedBy = reviewer.ID
+	g.DecidedAt = &now
+	if err = db.UpdatePathGrant(g); err != nil {
+		return nil, err
+	}
+	grantCache.Delete(strconv.Itoa(int(g.UserID)))
+	log.Infof("%s changed the grant %d of %s from %s to %s", reviewer.Username, g.ID, g.Path, from, to)
+	return g, nil
+}
+
+// revokeUserPathGrants ends the grants of a deleted user, the id of the user
+// may be given to a later one that must not inherit them
+func revokeUserPathGrants(userId uint) error {
+	n, err := db.RevokePathGrantsByUserId(userId, time.Now())
+	if err != nil {
+		return err
+	}
+	grantCache.Delete(strconv.Itoa(int(userId)))
+	if n > 0 {
+		log.Infof("%d path grants of the deleted user %d are revoked", n, userId)
+	}
+	return nil
+}
+
+// ApprovePathGrant approves a pending grant, the approver must not be the requester
+func ApprovePathGrant(approver *model.User, id uint) (*model.PathGrant, error) {
+	g, err := db.GetPathGrantById(id)
+	if err != nil {
+		return nil, err
+	}
+	if g.RequestedBy == approver.ID {
+		return nil, errs.SameReviewer
+	}
+	return decidePathGrant(approver, id, model.GrantPending, model.GrantApproved)
+}
+
+func RejectPathGrant(reviewer *model.User, id uint) (*model.PathGrant, error) {
+	return decidePathGrant(reviewer, id, model.GrantPending, model.GrantRejected)
+}
+
+// RevokePathGrant ends an approved grant, taking access away needs no second reviewer
+func RevokePathGrant(reviewer *model.User, id uint) (*model.PathGrant, error) {
+	return decidePathGrant(reviewer, id, model.GrantApproved, model.GrantRevoked)
+}
+
+// GetPathGrantsOfUser returns the grants given to, requested by or decided by the user
+func GetPathGrantsOfUser(userId uint) ([]model.PathGrant, error) {
+	return db.GetPathGrantsOfUser(userId)
+}
+
+// ErasePathGrantsOfUser deletes the grants given to the user, the grants it
+// requested or decided for the others are kept without it
+func ErasePathGrantsOfUser(userId uint) error {
+	defer grantCache.Delete(strconv.Itoa(int(userId)))
+	return db.ErasePathGrantsOfUser(userId)
+}
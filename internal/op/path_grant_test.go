@@ -0,0 +1,67 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestDeleteUserRevokesGrants(t *testing.T) {
+	meta := &model.Meta{Path: "/grant_restricted", Restricted: true}
+	if err := op.CreateMeta(meta); err != nil {
+		t.Fatalf("failed create meta: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteMetaById(meta.ID)
+	})
+	users := make([]*model.User, 3)
+	for i, name := range []string{"grant_user", "grant_requester", "grant_approver"} {
+		users[i] = &model.User{Username: name, BasePath: "/", Authn: "[]"}
+		if err := op.CreateUser(users[i]); err != nil {
+			t.Fatalf("failed create user: %+v", err)
+		}
+		id := users[i].ID
+		t.Cleanup(func() {
+			_ = db.DeleteUserById(id)
+		})
+	}
+	user, requester, approver := users[0], users[1], users[2]
+	approved := &model.PathGrant{Path: "/grant_restricted", UserID: user.ID}
+	pending := &model.PathGrant{Path: "/grant_restricted/sub", UserID: user.ID}
+	for _, g := range []*model.PathGrant{approved, pending} {
+		if err := op.RequestPathGrant(requester, g); err != nil {
+			t.Fatalf("failed request grant: %+v", err)
+		}
+	}
+	if _, err := op.ApprovePathGrant(approver, approved.ID); err != nil {
+		t.Fatalf("failed approve grant: %+v", err)
+	}
+	if !op.CanAccessRestricted(user, "/grant_restricted/file") {
+		t.Fatal("expected the approved grant to give access")
+	}
+
+	if err := op.DeleteUserById(user.ID); err != nil {
+		t.Fatalf("failed delete user: %+v", err)
+	}
+	grants, _, err := op.GetPathGrants(model.PathGrant{UserID: user.ID}, 1, -1)
+	if err != nil {
+		t.Fatalf("failed get grants: %+v", err)
+	}
+	for _, g := range grants {
+		if g.Status != model.GrantRevoked {
+			t.Errorf("expected the grants of the deleted user to be revoked, got %+v", g)
+		}
+	}
+	if len(grants) != 2 {
+		t.Errorf("expected the grants to be kept for the audit, got %d", len(grants))
+	}
+	// a later user given the same id inherits nothing
+	if op.CanAccessRestricted(&model.User{ID: user.ID, Username: "grant_reused"}, "/grant_restricted/file") {
+		t.Error("expected no access from the grants of the deleted user")
+	}
+	if _, err = op.ApprovePathGrant(approver, pending.ID); err == nil {
+		t.Error("expected the pending grant of the deleted user not to be approved")
+	}
+}
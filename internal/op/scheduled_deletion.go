@@ -0,0 +1,169 @@
+package op
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// scheduledDeletions keeps every scheduled deletion by path, so the listings
+// can show the purge date without asking the database for each object.
+// scheduledDeletionsGen is increased on every reset, a map loaded across a reset
+// may miss the change and is not kept.
+var (
+	scheduledDeletions     map[string]*model.ScheduledDeletion
+	scheduledDeletionsGen  uint64
+	scheduledDeletionsLock sync.RWMutex
+)
+
+func loadScheduledDeletions() map[string]*model.ScheduledDeletion {
+	scheduledDeletionsLock.RLock()
+	m, gen := scheduledDeletions, scheduledDeletionsGen
+	scheduledDeletionsLock.RUnlock()
+	if m != nil {
+		return m
+	}
+	deletions, err := db.GetAllScheduledDeletions()
+	if err != nil {
+		log.Errorf("failed load scheduled deletions: %+v", err)
+		return nil
+	}
+	m = make(map[string]*model.ScheduledDeletion, len(deletions))
+	for i := range deletions {
+		m[deletions[i].Path] = &deletions[i]
+	}
+	scheduledDeletionsLock.Lock()
+	if scheduledDeletionsGen == gen {
+		scheduledDeletions = m
+	}
+	scheduledDeletionsLock.Unlock()
+	return m
+}
+
+func resetScheduledDeletions() {
+	scheduledDeletionsLock.Lock()
+	scheduledDeletions = nil
+	scheduledDeletionsGen++
+	scheduledDeletionsLock.Unlock()
+}
+
+// moveScheduledDeletions follows a rename or a move of path to newPath, the schedules of path
+// and of the objects below it go along, and the ones of the replaced objects at newPath are dropped.
+// An empty newPath drops the schedules, the objects are removed.
+func moveScheduledDeletions(path, newPath string) {
+	m := loadScheduledDeletions()
+	if len(m) == 0 || path == newPath {
+		return
+	}
+	var drop, move []*model.ScheduledDeletion
+	for p, d := range m {
+		if newPath != "" && utils.IsSubPath(newPath, p) {
+			drop = append(drop, d)
+		} else if utils.IsSubPath(path, p) {
+			if newPath == "" {
+				drop = append(drop, d)
+			} else {
+				move = append(move, d)
+			}
+		}
+	}
+	if len(drop) == 0 && len(move) == 0 {
+		return
+	}
+	defer resetScheduledDeletions()
+	for _, d := range drop {
+		if err := db.DeleteScheduledDeletionById(d.ID); err != nil {
+			log.Errorf("failed drop scheduled deletion of %s: %+v", d.Path, err)
+		}
+	}
+	for _, d := range move {
+		moved := *d
+		moved.Path = newPath + strings.TrimPrefix(d.Path, path)
+		if err := db.UpdateScheduledDeletion(&moved); err != nil {
+			log.Errorf("failed move scheduled deletion of %s to %s: %+v", d.Path, moved.Path, err)
+		}
+	}
+}
+
+// GetScheduledDeletion returns the scheduled deletion of exactly path
+func GetScheduledDeletion(path string) (*model.ScheduledDeletion, bool) {
+	d, ok := loadScheduledDeletions()[utils.FixAndCleanPath(path)]
+	return d, ok
+}
+
+// GetScheduledDeletionByPath asks the database for the scheduled deletion of path, it returns nil if
+// the path isn't scheduled. Unlike GetScheduledDeletion, a failed lookup is returned as an error.
+func GetScheduledDeletionByPath(path string) (*model.ScheduledDeletion, error) {
+	d, err := db.GetScheduledDeletionByPath(utils.FixAndCleanPath(path))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return d, err
+}
+
+func GetScheduledDeletions(pageIndex, pageSize int) ([]model.ScheduledDeletion, int64, error) {
+	return db.GetScheduledDeletions(pageIndex, pageSize)
+}
+
+// ScheduleDeletion schedules the deletion of d.Path, an existing schedule of the path is moved to d.PurgeAt
+func ScheduleDeletion(d *model.ScheduledDeletion) error {
+	d.Path = utils.FixAndCleanPath(d.Path)
+	if d.Path == "/" {
+		return errors.New("delete root folder is not allowed")
+	}
+	if !d.PurgeAt.After(time.Now()) {
+		return errors.New("the purge date must be in the future")
+	}
+	defer resetScheduledDeletions()
+	if old, err := db.GetScheduledDeletionByPath(d.Path); err == nil {
+		old.PurgeAt = d.PurgeAt
+		old.Reason = d.Reason
+		old.LastError = ""
+		*d = *old
+		return db.UpdateScheduledDeletion(d)
+	}
+	d.ID = 0
+	d.LastError = ""
+	return db.CreateScheduledDeletion(d)
+}
+
+func CancelScheduledDeletion(path string) error {
+	d, err := db.GetScheduledDeletionByPath(utils.FixAndCleanPath(path))
+	if err != nil {
+		return err
+	}
+	defer resetScheduledDeletions()
+	return db.DeleteScheduledDeletionById(d.ID)
+}
+
+func GetDueScheduledDeletions(now time.Time) ([]model.ScheduledDeletion, error) {
+	return db.GetDueScheduledDeletions(now)
+}
+
+// FinishScheduledDeletion removes the schedule after the purge succeeded,
+// or records the error so the purge is retried on the next run
+func FinishScheduledDeletion(d *model.ScheduledDeletion, purgeErr error) error {
+	defer resetScheduledDeletions()
+	if purgeErr == nil {
+		return db.DeleteScheduledDeletionById(d.ID)
+	}
+	d.LastError = purgeErr.Error()
+	return db.UpdateScheduledDeletion(d)
+}
+
+func GetScheduledDeletionsByCreatorId(creatorId uint) ([]model.ScheduledDeletion, error) {
+	return db.GetScheduledDeletionsByCreatorId(creatorId)
+}
+
+// ClearScheduledDeletionCreator keeps the deletions scheduled by the creator, only the admin may cancel them then
+func ClearScheduledDeletionCreator(creatorId uint) error {
+	defer resetScheduledDeletions()
+	return db.ClearScheduledDeletionCreator(creatorId)
+}
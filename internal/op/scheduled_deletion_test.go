@@ -0,0 +1,91 @@
+package op_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestScheduledDeletionFollowsObjects(t *testing.T) {
+	ctx := context.Background()
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Mock",
+		MountPath: "/scheduled",
+		Addition:  `{"seed":1,"depth":2,"num_folder":2,"num_file":2,"file_size":1,"extensions":"txt"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteStorageById(ctx, id)
+	})
+	storage, err := op.GetStorageByMountPath("/scheduled")
+	if err != nil {
+		t.Fatalf("failed get storage: %+v", err)
+	}
+	purgeAt := time.Now().Add(time.Hour)
+	for _, path := range []string{"/scheduled/file_0.txt", "/scheduled/file_1.txt", "/scheduled/folder_0/file_0.txt", "/scheduled/folder_1/file_0.txt"} {
+		if err = op.ScheduleDeletion(&model.ScheduledDeletion{Path: path, PurgeAt: purgeAt}); err != nil {
+			t.Fatalf("failed schedule deletion: %+v", err)
+		}
+	}
+	t.Cleanup(func() {
+		for _, path := range []string{"/scheduled/renamed.txt", "/scheduled/folder_1/folder_0/file_0.txt", "/scheduled/folder_1/file_0.txt"} {
+			_ = op.CancelScheduledDeletion(path)
+		}
+	})
+	scheduled := func(path string) bool {
+		_, ok := op.GetScheduledDeletion(path)
+		return ok
+	}
+
+	if err = op.Rename(ctx, storage, "/file_0.txt", "renamed.txt"); err != nil {
+		t.Fatalf("failed rename: %+v", err)
+	}
+	if scheduled("/scheduled/file_0.txt") || !scheduled("/scheduled/renamed.txt") {
+		t.Error("expected the schedule to follow the renamed file")
+	}
+	if err = op.Move(ctx, storage, "/folder_0", "/folder_1"); err != nil {
+		t.Fatalf("failed move: %+v", err)
+	}
+	if scheduled("/scheduled/folder_0/file_0.txt") || !scheduled("/scheduled/folder_1/folder_0/file_0.txt") {
+		t.Error("expected the schedules below the moved folder to follow it")
+	}
+	if err = op.Remove(ctx, storage, "/file_1.txt"); err != nil {
+		t.Fatalf("failed remove: %+v", err)
+	}
+	if scheduled("/scheduled/file_1.txt") {
+		t.Error("expected the schedule of the removed file to be dropped")
+	}
+	if !scheduled("/scheduled/folder_1/file_0.txt") {
+		t.Error("expected the other schedules to be kept")
+	}
+}
+
+func TestScheduledDeletionCache(t *testing.T) {
+	purgeAt := time.Now().Add(time.Hour)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			op.GetScheduledDeletion("/cache/file")
+		}()
+	}
+	if err := op.ScheduleDeletion(&model.ScheduledDeletion{Path: "/cache/file", PurgeAt: purgeAt}); err != nil {
+		t.Fatalf("failed schedule deletion: %+v", err)
+	}
+	wg.Wait()
+	t.Cleanup(func() {
+		_ = op.CancelScheduledDeletion("/cache/file")
+	})
+	// a load racing with the schedule must not keep a map without it
+	if _, ok := op.GetScheduledDeletion("/cache/file"); !ok {
+		t.Error("expected the cache to have the new schedule")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/singleflight"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -87,6 +88,14 @@ func GetSharingsByCreatorId(userId uint, pageIndex, pageSize int) ([]model.Shari
 	return makeJoined(s), cnt, nil
 }
 
+func GetSharingsByGroupId(groupId uint, pageIndex, pageSize int) ([]model.Sharing, int64, error) {
+	s, cnt, err := db.GetSharingsByGroupId(groupId, pageIndex, pageSize)
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+	return makeJoined(s), cnt, nil
+}
+
 func GetSharingUnwrapPath(sharing *model.Sharing, path string) (unwrapPath string, err error) {
 	if len(sharing.Files) == 0 {
 		return "", errors.New("cannot get actual path of an invalid sharing")
@@ -112,17 +121,36 @@ func GetSharingUnwrapPath(sharing *model.Sharing, path string) (unwrapPath strin
 	return stdpath.Join(mapPath, rest), nil
 }
 
+// checkSharingFiles makes sure the creator has a grant of the restricted files it shares
+func checkSharingFiles(sharing *model.Sharing) error {
+	for _, f := range sharing.Files {
+		if !CanAccessRestricted(sharing.Creator, f) {
+			return errors.WithMessagef(errs.PermissionDenied, "no grant to share the restricted path [%s]", f)
+		}
+	}
+	return nil
+}
+
 func CreateSharing(sharing *model.Sharing) (id string, err error) {
+	if err = checkSharingFiles(sharing); err != nil {
+		return "", err
+	}
 	sharing.CreatorId = sharing.Creator.ID
 	sharing.FilesRaw, err = utils.Json.MarshalToString(utils.MustSliceConvert(sharing.Files, utils.FixAndCleanPath))
 	if err != nil {
 		return "", errors.WithStack(err)
 	}
-	return db.CreateSharing(sharing.SharingDB)
+	if sharing.Creator.IsAdmin() {
+		return db.CreateSharing(sharing.SharingDB)
+	}
+	return createSharingInQuota(sharing.SharingDB, sharing.Creator)
 }
 
 func UpdateSharing(sharing *model.Sharing, skipMarshal ...bool) (err error) {
 	if !utils.IsBool(skipMarshal...) {
+		if err = checkSharingFiles(sharing); err != nil {
+			return err
+		}
 		sharing.CreatorId = sharing.Creator.ID
 		sharing.FilesRaw, err = utils.Json.MarshalToString(utils.MustSliceConvert(sharing.Files, utils.FixAndCleanPath))
 		if err != nil {
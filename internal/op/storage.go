@@ -145,6 +145,7 @@ func initStorage(ctx context.Context, storage model.Storage, storageDriver drive
 		err = errors.Wrap(err, "failed init storage")
 	} else {
 		driverStorage.SetStatus(WORK)
+		restoreStorageCache(storageDriver)
 	}
 	MustSaveDriverStorage(storageDriver)
 	return err
@@ -78,10 +78,16 @@ func DeleteUserById(id uint) error {
 	if old.IsAdmin() || old.IsGuest() {
 		return errs.DeleteAdminOrGuest
 	}
+	if old.IsGroupOwner() && old.GroupID != 0 {
+		return errors.New("cannot delete the owner of a group, change the owner of the group first")
+	}
 	Cache.DeleteUser(old.Username)
 	if err := DeleteSharingsByCreatorId(id); err != nil {
 		return errors.WithMessage(err, "failed to delete user's sharings")
 	}
+	if err := revokeUserPathGrants(id); err != nil {
+		return errors.WithMessage(err, "failed to revoke user's path grants")
+	}
 	return db.DeleteUserById(id)
 }
 
@@ -0,0 +1,196 @@
+// Package policy evaluates the download policy, rules written by admins in a
+// sandboxed expression language that can allow, deny or annotate every download.
+package policy
+
+import (
+	stdpath "path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/expr"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ActionAllow    = "allow"
+	ActionDeny     = "deny"
+	ActionAnnotate = "annotate"
+)
+
+type Rule struct {
+	Name string `json:"name"`
+	// When is an expression returning a bool, the rule applies if it is true
+	When   string `json:"when"`
+	Action string `json:"action"`
+	// Message is shown to the client when the rule denies the download
+	Message string `json:"message"`
+	// Annotations are added to the response headers as X-Policy-<key>
+	Annotations map[string]string `json:"annotations"`
+
+	program *expr.Program
+}
+
+type Input struct {
+	User      *model.User
+	Path      string
+	IP        string
+	UserAgent string
+	// Sharing is the id of the sharing the file is downloaded from
+	Sharing string
+	Time    time.Time
+}
+
+type Decision struct {
+	Action string `json:"action"`
+	// Rule is the name of the rule that allowed or denied, empty if no rule did
+	Rule        string            `json:"rule"`
+	Message     string            `json:"message,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Errors      []string          `json:"errors,omitempty"`
+}
+
+var rules atomic.Pointer[[]Rule]
+
+func init() {
+	op.RegisterSettingItemHook(conf.DownloadPolicy, func(item *model.SettingItem) error {
+		parsed, err := Parse(item.Value)
+		if err != nil {
+			return err
+		}
+		rules.Store(&parsed)
+		return nil
+	})
+}
+
+// Parse parses the rules of the download_policy setting, which is a json array of Rule
+func Parse(value string) ([]Rule, error) {
+	var parsed []Rule
+	if strings.TrimSpace(value) == "" {
+		return parsed, nil
+	}
+	if err := utils.Json.UnmarshalFromString(value, &parsed); err != nil {
+		return nil, errors.WithMessage(err, "invalid download policy")
+	}
+	for i := range parsed {
+		r := &parsed[i]
+		if r.Name == "" {
+			r.Name = "#" + strconv.Itoa(i)
+		}
+		switch r.Action {
+		case ActionAllow, ActionDeny, ActionAnnotate:
+		default:
+			return nil, errors.Errorf("rule %s: unknown action [%s]", r.Name, r.Action)
+		}
+		p, err := expr.Compile(r.When)
+		if err != nil {
+			return nil, errors.Errorf("rule %s: %v", r.Name, err)
+		}
+		r.program = p
+	}
+	return parsed, nil
+}
+
+// Enabled reports whether there are rules to evaluate
+func Enabled() bool {
+	r := rules.Load()
+	return r != nil && len(*r) > 0
+}
+
+// Check evaluates the rules of the download policy
+func Check(in Input) Decision {
+	r := rules.Load()
+	if r == nil {
+		return Decision{Action: ActionAllow}
+	}
+	return Evaluate(*r, in)
+}
+
+// Evaluate runs the rules in order. Annotate rules add their annotations and go on,
+// the first allow or deny rule decides. Downloads no rule decides are allowed,
+// the built-in permissions have already been checked for them.
+// A deny rule that fails to evaluate denies, so a mistake never opens access.
+func Evaluate(list []Rule, in Input) Decision {
+	d := Decision{Action: ActionAllow}
+	env := newEnv(in)
+	for _, r := range list {
+		ok, err := r.program.EvalBool(env)
+		if err != nil {
+			log.Warnf("download policy rule %s failed on [%s]: %v", r.Name, in.Path, err)
+			d.Errors = append(d.Errors, r.Name+": "+err.Error())
+			if r.Action != ActionDeny {
+				continue
+			}
+			ok = true
+		}
+		if !ok {
+			continue
+		}
+		for k, v := range r.Annotations {
+			if d.Annotations == nil {
+				d.Annotations = make(map[string]string)
+			}
+			d.Annotations[k] = v
+		}
+		if r.Action == ActionAnnotate {
+			continue
+		}
+		d.Action = r.Action
+		d.Rule = r.Name
+		d.Message = r.Message
+		break
+	}
+	return d
+}
+
+func newEnv(in Input) expr.Env {
+	user := map[string]any{
+		"id":         0,
+		"name":       "",
+		"role":       model.GUEST,
+		"base_path":  "/",
+		"permission": 0,
+		"is_admin":   false,
+		"is_guest":   true,
+	}
+	if in.User != nil {
+		user = map[string]any{
+			"id":         in.User.ID,
+			"name":       in.User.Username,
+			"role":       in.User.Role,
+			"base_path":  in.User.BasePath,
+			"permission": in.User.Permission,
+			"is_admin":   in.User.IsAdmin(),
+			"is_guest":   in.User.IsGuest(),
+		}
+	}
+	t := in.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return expr.Env{
+		"user":    user,
+		"path":    in.Path,
+		"name":    stdpath.Base(in.Path),
+		"ext":     strings.ToLower(strings.TrimPrefix(stdpath.Ext(in.Path), ".")),
+		"ip":      in.IP,
+		"ua":      in.UserAgent,
+		"sharing": in.Sharing,
+		"time": map[string]any{
+			"unix":    t.Unix(),
+			"year":    t.Year(),
+			"month":   int(t.Month()),
+			"day":     t.Day(),
+			"hour":    t.Hour(),
+			"minute":  t.Minute(),
+			"weekday": int(t.Weekday()),
+			"date":    t.Format("2006-01-02"),
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/policy"
+)
+
+func TestParse(t *testing.T) {
+	for _, value := range []string{
+		`[{"when": "path ==", "action": "deny"}]`,
+		`[{"when": "true", "action": "block"}]`,
+		`{"when": "true", "action": "deny"}`,
+	} {
+		if _, err := policy.Parse(value); err == nil {
+			t.Errorf("expected %s to be rejected", value)
+		}
+	}
+	rules, err := policy.Parse(`[{"when": "true", "action": "allow"}]`)
+	if err != nil || len(rules) != 1 || rules[0].Name != "#0" {
+		t.Errorf("expected the unnamed rule to be named after its index, got %+v %v", rules, err)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	rules, err := policy.Parse(`[
+		{"name": "tag", "when": "ext == \"jpg\"", "action": "annotate", "annotations": {"Kind": "image"}},
+		{"name": "no-curl", "when": "startsWith(lower(ua), \"curl/\")", "action": "deny", "message": "no scripts"},
+		{"name": "guests", "when": "user.is_guest && glob(path, \"/policy/*.mp4\")", "action": "deny"},
+		{"name": "members", "when": "user.name == \"member\"", "action": "allow"},
+		{"name": "broken", "when": "user.missing.field == 1", "action": "deny"}
+	]`)
+	if err != nil {
+		t.Fatalf("failed parse rules: %+v", err)
+	}
+	member := &model.User{Username: "member", Role: model.GENERAL}
+	other := &model.User{Username: "other", Role: model.GENERAL}
+	for name, c := range map[string]struct {
+		in          policy.Input
+		action      string
+		rule        string
+		annotations bool
+	}{
+		"ua":             {in: policy.Input{Path: "/policy/file.txt", UserAgent: "curl/8.0", User: member}, action: policy.ActionDeny, rule: "no-curl"},
+		"guest":          {in: policy.Input{Path: "/policy/file.mp4", UserAgent: "Mozilla/5.0"}, action: policy.ActionDeny, rule: "guests"},
+		"user":           {in: policy.Input{Path: "/policy/file.mp4", UserAgent: "Mozilla/5.0", User: member}, action: policy.ActionAllow, rule: "members"},
+		"annotate":       {in: policy.Input{Path: "/policy/file.jpg", UserAgent: "Mozilla/5.0", User: member}, action: policy.ActionAllow, rule: "members", annotations: true},
+		"failed to deny": {in: policy.Input{Path: "/policy/file.txt", UserAgent: "Mozilla/5.0", User: other}, action: policy.ActionDeny, rule: "broken"},
+	} {
+		d := policy.Evaluate(rules, c.in)
+		if d.Action != c.action || d.Rule != c.rule {
+			t.Errorf("%s: expected %s by %s, got %+v", name, c.action, c.rule, d)
+		}
+		if c.annotations != (d.Annotations["Kind"] == "image") {
+			t.Errorf("%s: unexpected annotations %+v", name, d.Annotations)
+		}
+	}
+	if d := policy.Evaluate(nil, policy.Input{Path: "/file"}); d.Action != policy.ActionAllow || d.Rule != "" {
+		t.Errorf("expected the downloads no rule decides to be allowed, got %+v", d)
+	}
+}
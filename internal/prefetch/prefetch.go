@@ -0,0 +1,170 @@
+// Package prefetch guesses the files a client is likely to fetch next, so players
+// can be hinted to preload them. The guesses come from the files accessed next by
+// earlier sessions and from the order of the files in their folder.
+package prefetch
+
+import (
+	"context"
+	stdpath "path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/cache"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+const (
+	ReasonOftenNext    = "often_next"
+	ReasonNextInFolder = "next_in_folder"
+)
+
+const (
+	// sessionGap is the longest pause between two accesses of a session that still counts as going on
+	sessionGap = 6 * time.Hour
+	// transitionExpire is how long an access pattern is remembered without being seen again
+	transitionExpire = 30 * 24 * time.Hour
+	// maxNextPerFile bounds the files remembered as accessed after a file
+	maxNextPerFile = 8
+	// minOftenNext is how often a file must have been accessed after another to be hinted
+	minOftenNext = 2
+)
+
+type Hint struct {
+	Path string `json:"path"`
+	Name string `json:"name"`
+	// Reason is often_next or next_in_folder
+	Reason string `json:"reason"`
+}
+
+type lastAccess struct {
+	path string
+	at   time.Time
+}
+
+type nextCounts struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+var (
+	sessions    = cache.NewKeyedCache[lastAccess](sessionGap)
+	transitions = cache.NewKeyedCache[*nextCounts](transitionExpire)
+	recordLock  sync.Mutex
+)
+
+// Record remembers that the session, a user or a client ip, accessed path.
+// Accessing another file within the session counts as a transition to it.
+func Record(session, path string, now time.Time) {
+	recordLock.Lock()
+	defer recordLock.Unlock()
+	last, ok := sessions.Get(session)
+	sessions.Set(session, lastAccess{path: path, at: now})
+	if !ok || last.path == path || now.Sub(last.at) > sessionGap {
+		return
+	}
+	next, ok := transitions.Get(last.path)
+	if !ok {
+		next = &nextCounts{counts: make(map[string]int)}
+	}
+	next.mu.Lock()
+	if _, ok := next.counts[path]; !ok && len(next.counts) >= maxNextPerFile {
+		// forget the rarest file to make room
+		rarest := ""
+		for p, n := range next.counts {
+			if rarest == "" || n < next.counts[rarest] {
+				rarest = p
+			}
+		}
+		delete(next.counts, rarest)
+	}
+	next.counts[path]++
+	next.mu.Unlock()
+	// refresh the expiration
+	transitions.Set(last.path, next)
+}
+
+// Next returns up to limit files likely fetched after path, the files accessed
+// after it by earlier sessions first, then the files following it in its folder
+// with the same extension. allow filters the files the client may access.
+func Next(ctx context.Context, path string, limit int, allow func(path string) bool) []Hint {
+	return next(path, limit, allow, func(dir string) []model.Obj {
+		objs, _ := fs.List(ctx, dir, &fs.ListArgs{NoLog: true})
+		return objs
+	})
+}
+
+// NextCached is like Next, but only looks at the folder if its listing is cached,
+// so it can run on every download without listing the storage.
+func NextCached(path string, limit int, allow func(path string) bool) []Hint {
+	return next(path, limit, allow, func(dir string) []model.Obj {
+		storage, actualPath, err := op.GetStorageAndActualPath(dir)
+		if err != nil {
+			return nil
+		}
+		objs, _ := op.Cache.GetDirectory(storage, actualPath)
+		return objs
+	})
+}
+
+func next(path string, limit int, allow func(path string) bool, list func(dir string) []model.Obj) []Hint {
+	hints := make([]Hint, 0, limit)
+	seen := map[string]bool{path: true}
+	add := func(p, reason string) bool {
+		if seen[p] || !allow(p) {
+			return len(hints) < limit
+		}
+		seen[p] = true
+		hints = append(hints, Hint{Path: p, Name: stdpath.Base(p), Reason: reason})
+		return len(hints) < limit
+	}
+	if limit <= 0 {
+		return hints
+	}
+	if next, ok := transitions.Get(path); ok {
+		next.mu.Lock()
+		paths := make([]string, 0, len(next.counts))
+		for p, n := range next.counts {
+			if n >= minOftenNext {
+				paths = append(paths, p)
+			}
+		}
+		sort.Slice(paths, func(i, j int) bool {
+			if next.counts[paths[i]] != next.counts[paths[j]] {
+				return next.counts[paths[i]] > next.counts[paths[j]]
+			}
+			return paths[i] < paths[j]
+		})
+		next.mu.Unlock()
+		for _, p := range paths {
+			if !add(p, ReasonOftenNext) {
+				return hints
+			}
+		}
+	}
+	dir, name := stdpath.Split(path)
+	objs := list(dir)
+	// the listing is cached, sort a copy
+	files := make([]model.Obj, 0, len(objs))
+	ext := utils.Ext(name)
+	for _, obj := range objs {
+		if !obj.IsDir() && utils.Ext(obj.GetName()) == ext {
+			files = append(files, obj)
+		}
+	}
+	model.SortFiles(files, "name", "asc")
+	after := false
+	for _, obj := range files {
+		if after {
+			if !add(stdpath.Join(dir, obj.GetName()), ReasonNextInFolder) {
+				break
+			}
+		} else if obj.GetName() == name {
+			after = true
+		}
+	}
+	return hints
+}
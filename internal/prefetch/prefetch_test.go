@@ -0,0 +1,90 @@
+package prefetch_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/prefetch"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:prefetch?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+// runs gives every run of the tests its own files, the learned patterns are kept by the process
+var runs atomic.Int32
+
+func TestNext(t *testing.T) {
+	ctx := context.Background()
+	mount := fmt.Sprintf("/prefetch_%d", runs.Add(1))
+	file := func(name string) string {
+		return mount + "/" + name
+	}
+	session := func(name string) string {
+		return mount + ":" + name
+	}
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:          "Mock",
+		MountPath:       mount,
+		CacheExpiration: 30,
+		Addition:        `{"seed":1,"depth":1,"num_folder":0,"num_file":4,"file_size":16,"extensions":"mp4"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteStorageById(ctx, id)
+	})
+	all := func(string) bool { return true }
+	expect := func(hints []prefetch.Hint, expected ...string) {
+		t.Helper()
+		got := make([]string, 0, len(hints))
+		for _, h := range hints {
+			got = append(got, h.Name+":"+h.Reason)
+		}
+		if strings.Join(got, ",") != strings.Join(expected, ",") {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	}
+
+	// the cached variant doesn't list the folder itself
+	expect(prefetch.NextCached(file("file_1.mp4"), 2, all))
+	expect(prefetch.Next(ctx, file("file_1.mp4"), 2, all),
+		"file_2.mp4:"+prefetch.ReasonNextInFolder, "file_3.mp4:"+prefetch.ReasonNextInFolder)
+	expect(prefetch.NextCached(file("file_1.mp4"), 2, all),
+		"file_2.mp4:"+prefetch.ReasonNextInFolder, "file_3.mp4:"+prefetch.ReasonNextInFolder)
+	expect(prefetch.Next(ctx, file("file_1.mp4"), 2, func(path string) bool { return path != file("file_2.mp4") }),
+		"file_3.mp4:"+prefetch.ReasonNextInFolder)
+
+	// a single session going back to the first episode isn't a pattern yet
+	now := time.Now()
+	prefetch.Record(session("a"), file("file_3.mp4"), now)
+	prefetch.Record(session("a"), file("file_0.mp4"), now.Add(time.Minute))
+	expect(prefetch.Next(ctx, file("file_3.mp4"), 2, all))
+	prefetch.Record(session("b"), file("file_3.mp4"), now)
+	prefetch.Record(session("b"), file("file_0.mp4"), now.Add(time.Minute))
+	expect(prefetch.Next(ctx, file("file_3.mp4"), 2, all), "file_0.mp4:"+prefetch.ReasonOftenNext)
+
+	// an access after the session ended doesn't count
+	prefetch.Record(session("c"), file("file_0.mp4"), now)
+	prefetch.Record(session("c"), file("file_2.mp4"), now.Add(7*time.Hour))
+	prefetch.Record(session("d"), file("file_0.mp4"), now)
+	prefetch.Record(session("d"), file("file_2.mp4"), now.Add(7*time.Hour))
+	expect(prefetch.Next(ctx, file("file_0.mp4"), 1, all), "file_1.mp4:"+prefetch.ReasonNextInFolder)
+}
@@ -0,0 +1,151 @@
+package privacy
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/pkg/errors"
+)
+
+// accessLogFiles returns the active log file followed by the rotated backups
+// that lumberjack keeps next to it (name-<timestamp>.ext, optionally gzipped)
+func accessLogFiles() (active string, backups []string) {
+	if !conf.Conf.Log.Enable || conf.Conf.Log.Name == "" {
+		return "", nil
+	}
+	active = conf.Conf.Log.Name
+	ext := filepath.Ext(active)
+	prefix := strings.TrimSuffix(active, ext) + "-"
+	for _, pattern := range []string{prefix + "*" + ext, prefix + "*" + ext + ".gz"} {
+		matches, _ := filepath.Glob(pattern)
+		backups = append(backups, matches...)
+	}
+	sort.Strings(backups)
+	return active, backups
+}
+
+func matchUser(line, username string) bool {
+	return strings.Contains(line, "用户："+username+" 行为：")
+}
+
+func openLog(name string) (io.ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(name, ".gz") {
+		return f, nil
+	}
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gr, f}, nil
+}
+
+// writeAccessLog copies the media access entries of the user from every log file to w
+func writeAccessLog(ctx context.Context, username string, w io.Writer) error {
+	active, backups := accessLogFiles()
+	if active == "" {
+		return nil
+	}
+	for _, name := range append(backups, active) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r, err := openLog(name)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return errors.Wrapf(err, "failed open log file %s", name)
+		}
+		err = filterLines(r, func(line string) error {
+			if !matchUser(line, username) {
+				return nil
+			}
+			_, err := io.WriteString(w, line+"\n")
+			return err
+		})
+		_ = r.Close()
+		if err != nil {
+			return errors.Wrapf(err, "failed read log file %s", name)
+		}
+	}
+	return nil
+}
+
+// eraseAccessLog removes the media access entries of the user from the rotated
+// plain text backups. The active file is still written by the logger and compressed
+// backups can't be rewritten in place, so both of them are left untouched.
+func eraseAccessLog(username string) (skipped []string, err error) {
+	active, backups := accessLogFiles()
+	if active == "" {
+		return nil, nil
+	}
+	skipped = append(skipped, active)
+	for _, name := range backups {
+		if strings.HasSuffix(name, ".gz") {
+			skipped = append(skipped, name)
+			continue
+		}
+		if err = rewriteLog(name, username); err != nil {
+			return skipped, err
+		}
+	}
+	return skipped, nil
+}
+
+func rewriteLog(name, username string) error {
+	r, err := os.Open(name)
+	if err != nil {
+		return errors.Wrapf(err, "failed open log file %s", name)
+	}
+	defer r.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.Remove(tmp.Name())
+	bw := bufio.NewWriter(tmp)
+	err = filterLines(r, func(line string) error {
+		if matchUser(line, username) {
+			return nil
+		}
+		_, err := bw.WriteString(line + "\n")
+		return err
+	})
+	if err == nil {
+		err = bw.Flush()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed rewrite log file %s", name)
+	}
+	_ = r.Close()
+	return errors.WithStack(os.Rename(tmp.Name(), name))
+}
+
+func filterLines(r io.Reader, fn func(line string) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		if err := fn(sc.Text()); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
@@ -0,0 +1,197 @@
+package privacy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/pkg/errors"
+)
+
+const (
+	Tasks              = "tasks"
+	SSHKeys            = "ssh_keys"
+	Credentials        = "credentials"
+	Sharings           = "sharings"
+	PathGrants         = "path_grants"
+	LinkPasswords      = "link_passwords"
+	ScheduledDeletions = "scheduled_deletions"
+	WatchRules         = "watch_rules"
+	AccessLogs         = "access_logs"
+	Account            = "account"
+)
+
+type EraseResult struct {
+	Erased   []string `json:"erased"`
+	Retained []string `json:"retained"`
+	Notes    []string `json:"notes"`
+}
+
+// Retained returns the data categories that the retention policy keeps on erasure
+func Retained() map[string]bool {
+	retained := make(map[string]bool)
+	for _, v := range strings.Split(setting.GetStr(conf.UserDataRetention), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			retained[v] = true
+		}
+	}
+	return retained
+}
+
+// Erase removes the data stored about the user, except the categories
+// listed in the retention setting. The account itself is deleted last.
+func Erase(user *model.User) (*EraseResult, error) {
+	if user.IsAdmin() || user.IsGuest() {
+		return nil, errs.DeleteAdminOrGuest
+	}
+	retained := Retained()
+	// the account can't be deleted, nothing is erased then
+	if !retained[Sharings] && user.IsGroupOwner() && user.GroupID != 0 {
+		return nil, errors.New("cannot erase the owner of a group, change the owner of the group first")
+	}
+	res := &EraseResult{}
+	step := func(name string, fn func() error) error {
+		if retained[name] {
+			res.Retained = append(res.Retained, name)
+			return nil
+		}
+		if err := fn(); err != nil {
+			return errors.WithMessagef(err, "failed erase %s", name)
+		}
+		res.Erased = append(res.Erased, name)
+		return nil
+	}
+
+	err := step(Tasks, func() error {
+		if !taskAvailable() {
+			res.Notes = append(res.Notes, "task managers are not running, tasks were not touched")
+			return nil
+		}
+		removeTasks(user.ID)
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	err = step(SSHKeys, func() error {
+		keys, _, err := op.GetSSHPublicKeyByUserId(user.ID, 1, -1)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err = op.DeleteSSHPublicKeyById(k.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	err = step(Credentials, func() error {
+		user.OtpSecret = ""
+		user.Authn = "[]"
+		user.SsoID = ""
+		return op.UpdateUser(user)
+	})
+	if err != nil {
+		return res, err
+	}
+	err = step(Sharings, func() error {
+		sharings, _, err := op.GetSharingsByCreatorId(user.ID, 1, -1)
+		if err != nil {
+			return err
+		}
+		for _, s := range sharings {
+			if err = op.DeleteSharing(s.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	err = step(PathGrants, func() error {
+		return op.ErasePathGrantsOfUser(user.ID)
+	})
+	if err != nil {
+		return res, err
+	}
+	// the links and the deletions concern the files, they are kept without their creator
+	err = step(LinkPasswords, func() error {
+		return op.ClearLinkPasswordCreator(user.ID)
+	})
+	if err != nil {
+		return res, err
+	}
+	err = step(ScheduledDeletions, func() error {
+		return op.ClearScheduledDeletionCreator(user.ID)
+	})
+	if err != nil {
+		return res, err
+	}
+	err = step(WatchRules, func() error {
+		rules, err := db.GetWatchRulesByCreatorId(user.ID)
+		if err != nil {
+			return err
+		}
+		for _, r := range rules {
+			if err = db.DeleteWatchRuleById(r.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	err = step(AccessLogs, func() error {
+		skipped, err := eraseAccessLog(user.Username)
+		for _, name := range skipped {
+			res.Notes = append(res.Notes, fmt.Sprintf("log file %s is kept until it is rotated out", name))
+		}
+		return err
+	})
+	if err != nil {
+		return res, err
+	}
+
+	// deleting the account also deletes its sharings
+	if retained[Sharings] {
+		res.Retained = append(res.Retained, Account)
+		res.Notes = append(res.Notes, "the account is kept because its sharings are retained")
+		return res, nil
+	}
+	if err = op.DeleteUserById(user.ID); err != nil {
+		return res, errors.WithMessage(err, "failed delete account")
+	}
+	res.Erased = append(res.Erased, Account)
+	return res, nil
+}
+
+func removeTasks(uid uint) {
+	removeUserTasks(fs.UploadTaskManager, uid)
+	removeUserTasks(fs.CopyTaskManager, uid)
+	removeUserTasks(fs.MoveTaskManager, uid)
+	removeUserTasks(tool.DownloadTaskManager, uid)
+	removeUserTasks(tool.TransferTaskManager, uid)
+	removeUserTasks(fs.ArchiveDownloadTaskManager, uid)
+	removeUserTasks(fs.ArchiveContentUploadTaskManager, uid)
+}
+
+func removeUserTasks[T task.TaskExtensionInfo](m task.Manager[T], uid uint) {
+	cond := func(t T) bool {
+		return isCreator(t, uid)
+	}
+	m.CancelByCondition(cond)
+	m.RemoveByCondition(cond)
+}
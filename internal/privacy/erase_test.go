@@ -0,0 +1,248 @@
+package privacy_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/privacy"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:privacy?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	dir, err := os.MkdirTemp("", "openlist-privacy-test")
+	if err != nil {
+		panic(err)
+	}
+	conf.Conf = conf.DefaultConfig(dir)
+	conf.Conf.Log.Enable = true
+	conf.Conf.Log.Name = filepath.Join(dir, "log", "log.log")
+	db.Init(dB)
+}
+
+func setRetention(t *testing.T, value string) {
+	t.Helper()
+	if err := op.SaveSettingItem(&model.SettingItem{Key: conf.UserDataRetention, Value: value}); err != nil {
+		t.Fatalf("failed save setting: %+v", err)
+	}
+}
+
+// createUser creates a user with an ssh key, a sharing and credentials
+func createUser(t *testing.T, username string) *model.User {
+	t.Helper()
+	u := &model.User{Username: username, BasePath: "/", Permission: 1 << 14, OtpSecret: "otp", SsoID: username, Authn: "[]"}
+	if err := op.CreateUser(u); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteUserById(u.ID)
+	})
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed generate key: %+v", err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("failed convert key: %+v", err)
+	}
+	if err, _ = op.CreateSSHPublicKey(&model.SSHPublicKey{UserId: u.ID, Title: "laptop", KeyStr: string(ssh.MarshalAuthorizedKey(sshPub))}); err != nil {
+		t.Fatalf("failed create ssh key: %+v", err)
+	}
+	if _, err = op.CreateSharing(&model.Sharing{SharingDB: &model.SharingDB{}, Files: []string{"/" + username}, Creator: u}); err != nil {
+		t.Fatalf("failed create sharing: %+v", err)
+	}
+	return u
+}
+
+// createUserFiles gives u a grant requested by other, a link password, a scheduled deletion
+// and a watch rule, and returns a grant u requested for other
+func createUserFiles(t *testing.T, u, other *model.User) *model.PathGrant {
+	t.Helper()
+	meta := &model.Meta{Path: "/privacy_" + u.Username, Restricted: true}
+	if err := op.CreateMeta(meta); err != nil {
+		t.Fatalf("failed create meta: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteMetaById(meta.ID)
+		_ = op.DeleteLinkPassword("/privacy/file")
+		_ = op.CancelScheduledDeletion("/privacy/file")
+	})
+	if err := op.RequestPathGrant(other, &model.PathGrant{Path: meta.Path, UserID: u.ID}); err != nil {
+		t.Fatalf("failed request grant: %+v", err)
+	}
+	grant := &model.PathGrant{Path: meta.Path, UserID: other.ID}
+	if err := op.RequestPathGrant(u, grant); err != nil {
+		t.Fatalf("failed request grant: %+v", err)
+	}
+	if err := op.SetLinkPassword("/privacy/file", "secret", u.ID); err != nil {
+		t.Fatalf("failed set link password: %+v", err)
+	}
+	if err := op.ScheduleDeletion(&model.ScheduledDeletion{Path: "/privacy/file", PurgeAt: time.Now().Add(time.Hour), CreatorID: u.ID}); err != nil {
+		t.Fatalf("failed schedule deletion: %+v", err)
+	}
+	rule := &model.WatchRule{Name: "privacy", Path: "/privacy", CreatorID: u.ID}
+	if err := db.CreateWatchRule(rule); err != nil {
+		t.Fatalf("failed create watch rule: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.DeleteWatchRuleById(rule.ID)
+	})
+	return grant
+}
+
+func counts(t *testing.T, u *model.User) (keys, sharings int64) {
+	t.Helper()
+	_, keys, err := op.GetSSHPublicKeyByUserId(u.ID, 1, -1)
+	if err != nil {
+		t.Fatalf("failed get ssh keys: %+v", err)
+	}
+	_, sharings, err = op.GetSharingsByCreatorId(u.ID, 1, -1)
+	if err != nil {
+		t.Fatalf("failed get sharings: %+v", err)
+	}
+	return keys, sharings
+}
+
+func logLine(username string) string {
+	return "时间：2024-01-01 00:00:00 访问IP：127.0.0.1 用户：" + username + " 行为：下载 访问路径：/file"
+}
+
+func TestErase(t *testing.T) {
+	setRetention(t, "")
+	erased := createUser(t, "erase_user")
+	kept := createUser(t, "erase_other")
+	logs := logLine("erase_user") + "\n" + logLine("erase_other") + "\n"
+	backup := strings.TrimSuffix(conf.Conf.Log.Name, ".log") + "-2024-01-01T00-00-00.000.log"
+	compressed := backup + ".gz"
+	for _, name := range []string{conf.Conf.Log.Name, backup, compressed} {
+		if err := os.MkdirAll(filepath.Dir(name), 0o777); err != nil {
+			t.Fatalf("failed create log dir: %+v", err)
+		}
+		if err := os.WriteFile(name, []byte(logs), 0o666); err != nil {
+			t.Fatalf("failed write log: %+v", err)
+		}
+		t.Cleanup(func() {
+			_ = os.Remove(name)
+		})
+	}
+
+	grant := createUserFiles(t, erased, kept)
+
+	res, err := privacy.Erase(erased)
+	if err != nil {
+		t.Fatalf("failed erase: %+v", err)
+	}
+	if len(res.Retained) != 0 || len(res.Erased) != 10 {
+		t.Errorf("expected everything to be erased, got %+v", res)
+	}
+	if _, err = op.GetUserById(erased.ID); err == nil {
+		t.Error("expected the account to be deleted")
+	}
+	if keys, sharings := counts(t, erased); keys != 0 || sharings != 0 {
+		t.Errorf("expected the ssh keys and sharings to be erased, got %d keys and %d sharings", keys, sharings)
+	}
+	content, err := os.ReadFile(backup)
+	if err != nil {
+		t.Fatalf("failed read log: %+v", err)
+	}
+	if strings.Contains(string(content), logLine("erase_user")) || !strings.Contains(string(content), logLine("erase_other")) {
+		t.Errorf("expected only the entries of the user to be erased from the rotated log, got %q", content)
+	}
+	// the active and the compressed logs can't be rewritten
+	for _, name := range []string{conf.Conf.Log.Name, compressed} {
+		if content, _ := os.ReadFile(name); string(content) != logs {
+			t.Errorf("expected %s to be left untouched", name)
+		}
+	}
+	if len(res.Notes) < 2 {
+		t.Errorf("expected the untouched logs to be noted, got %+v", res.Notes)
+	}
+
+	if grants, err := op.GetPathGrantsOfUser(erased.ID); err != nil || len(grants) != 0 {
+		t.Errorf("expected the grants of the user to be erased, got %+v %v", grants, err)
+	}
+	if g, err := db.GetPathGrantById(grant.ID); err != nil || g.UserID != kept.ID || g.RequestedBy != 0 {
+		t.Errorf("expected the grant requested for the other user to be kept without the requester, got %+v %v", g, err)
+	}
+	if l, err := op.GetLinkPassword("/privacy/file"); err != nil || l == nil || l.CreatorID != 0 {
+		t.Errorf("expected the link to stay protected without its creator, got %+v %v", l, err)
+	}
+	if d, ok := op.GetScheduledDeletion("/privacy/file"); !ok || d.CreatorID != 0 {
+		t.Errorf("expected the deletion to stay scheduled without its creator, got %+v", d)
+	}
+	if rules, err := db.GetWatchRulesByCreatorId(erased.ID); err != nil || len(rules) != 0 {
+		t.Errorf("expected the watch rules of the user to be erased, got %+v %v", rules, err)
+	}
+
+	// everyone else keeps their data
+	other, err := op.GetUserById(kept.ID)
+	if err != nil || other.OtpSecret != "otp" || other.SsoID != "erase_other" {
+		t.Errorf("expected the other user to be kept, got %+v %v", other, err)
+	}
+	if keys, sharings := counts(t, kept); keys != 1 || sharings != 1 {
+		t.Errorf("expected the data of the other user to be kept, got %d keys and %d sharings", keys, sharings)
+	}
+}
+
+func TestEraseRetained(t *testing.T) {
+	setRetention(t, "sharings, access_logs")
+	u := createUser(t, "retain_user")
+
+	res, err := privacy.Erase(u)
+	if err != nil {
+		t.Fatalf("failed erase: %+v", err)
+	}
+	if strings.Join(res.Retained, ",") != "sharings,access_logs,account" {
+		t.Errorf("unexpected retained data: %+v", res)
+	}
+	// the account is kept for its sharings, without the erased credentials
+	kept, err := op.GetUserById(u.ID)
+	if err != nil || kept.OtpSecret != "" || kept.SsoID != "" {
+		t.Errorf("expected the account to be kept without credentials, got %+v %v", kept, err)
+	}
+	if keys, sharings := counts(t, u); keys != 0 || sharings != 1 {
+		t.Errorf("expected the sharing to be kept, got %d keys and %d sharings", keys, sharings)
+	}
+
+	// nothing is erased of the owner of a group, its account can't be deleted
+	setRetention(t, "")
+	owner := createUser(t, "erase_owner")
+	g := &model.Group{Name: "erase_group", OwnerID: owner.ID, BasePath: "/"}
+	owner.Role = model.GROUPOWNER
+	if err = op.UpdateUser(owner); err != nil {
+		t.Fatalf("failed update user: %+v", err)
+	}
+	if err = op.CreateGroup(g); err != nil {
+		t.Fatalf("failed create group: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteGroupById(g.ID)
+	})
+	owner, _ = op.GetUserById(owner.ID)
+	if _, err = privacy.Erase(owner); err == nil {
+		t.Error("expected the owner of a group not to be erased")
+	}
+	if keys, sharings := counts(t, owner); keys != 1 || sharings != 1 {
+		t.Errorf("expected the data of the owner to be kept, got %d keys and %d sharings", keys, sharings)
+	}
+
+	for _, role := range []int{model.ADMIN, model.GUEST} {
+		if _, err = privacy.Erase(&model.User{Username: "erase_role", Role: role}); err == nil {
+			t.Errorf("expected the user of role %d not to be erased", role)
+		}
+	}
+}
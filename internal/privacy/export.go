@@ -0,0 +1,202 @@
+package privacy
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+type Manifest struct {
+	Username    string    `json:"username"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Files       []string  `json:"files"`
+	Notes       []string  `json:"notes"`
+}
+
+type Profile struct {
+	model.User
+	TwoFactorEnabled    bool `json:"two_factor_enabled"`
+	WebAuthnCredentials int  `json:"webauthn_credentials"`
+}
+
+// GroupRecord is the group the user is a member or the owner of
+type GroupRecord struct {
+	model.Group
+	Owner bool `json:"owner"`
+}
+
+type TaskRecord struct {
+	Type       string     `json:"type"`
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	StartTime  *time.Time `json:"start_time"`
+	EndTime    *time.Time `json:"end_time"`
+	TotalBytes int64      `json:"total_bytes"`
+	Error      string     `json:"error"`
+}
+
+// Export writes a zip archive with everything stored about the user to w
+func Export(ctx context.Context, user *model.User, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	manifest := Manifest{
+		Username:    user.Username,
+		GeneratedAt: time.Now(),
+	}
+	writeJSON := func(name string, v any) error {
+		f, err := zw.Create(name)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		manifest.Files = append(manifest.Files, name)
+		return errors.WithStack(utils.Json.NewEncoder(f).Encode(v))
+	}
+
+	profile := Profile{
+		User:             *user,
+		TwoFactorEnabled: user.OtpSecret != "",
+	}
+	if user.Authn != "" {
+		profile.WebAuthnCredentials = len(user.WebAuthnCredentials())
+	}
+	profile.Password = ""
+	if err := writeJSON("profile.json", profile); err != nil {
+		return err
+	}
+
+	sharings, _, err := op.GetSharingsByCreatorId(user.ID, 1, -1)
+	if err != nil {
+		return errors.WithMessage(err, "failed get sharings")
+	}
+	if err = writeJSON("sharings.json", sharings); err != nil {
+		return err
+	}
+
+	keys, _, err := op.GetSSHPublicKeyByUserId(user.ID, 1, -1)
+	if err != nil {
+		return errors.WithMessage(err, "failed get ssh public keys")
+	}
+	if err = writeJSON("ssh_keys.json", keys); err != nil {
+		return err
+	}
+
+	grants, err := op.GetPathGrantsOfUser(user.ID)
+	if err != nil {
+		return errors.WithMessage(err, "failed get path grants")
+	}
+	if err = writeJSON("path_grants.json", grants); err != nil {
+		return err
+	}
+
+	links, err := op.GetLinkPasswordsByCreatorId(user.ID)
+	if err != nil {
+		return errors.WithMessage(err, "failed get link passwords")
+	}
+	if err = writeJSON("link_passwords.json", links); err != nil {
+		return err
+	}
+
+	deletions, err := op.GetScheduledDeletionsByCreatorId(user.ID)
+	if err != nil {
+		return errors.WithMessage(err, "failed get scheduled deletions")
+	}
+	if err = writeJSON("scheduled_deletions.json", deletions); err != nil {
+		return err
+	}
+
+	rules, err := db.GetWatchRulesByCreatorId(user.ID)
+	if err != nil {
+		return errors.WithMessage(err, "failed get watch rules")
+	}
+	if err = writeJSON("watch_rules.json", rules); err != nil {
+		return err
+	}
+
+	if user.GroupID != 0 {
+		g, err := op.GetGroupById(user.GroupID)
+		if err != nil {
+			return errors.WithMessage(err, "failed get group")
+		}
+		if err = writeJSON("group.json", GroupRecord{Group: *g, Owner: g.OwnerID == user.ID}); err != nil {
+			return err
+		}
+	}
+
+	if tasks, ok := collectTasks(user.ID); ok {
+		if err = writeJSON("tasks.json", tasks); err != nil {
+			return err
+		}
+	} else {
+		manifest.Notes = append(manifest.Notes, "tasks (including uploads) are only available while the server is running")
+	}
+
+	f, err := zw.Create("access_log.txt")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	manifest.Files = append(manifest.Files, "access_log.txt")
+	if err = writeAccessLog(ctx, user.Username, f); err != nil {
+		return err
+	}
+	manifest.Notes = append(manifest.Notes, "the access grants the user requested or decided are the audit of the restricted paths, there is no other audit besides the access log")
+
+	if err = writeJSON("manifest.json", manifest); err != nil {
+		return err
+	}
+	return errors.WithStack(zw.Close())
+}
+
+func taskAvailable() bool {
+	return fs.UploadTaskManager != nil && tool.DownloadTaskManager != nil
+}
+
+func collectTasks(uid uint) ([]TaskRecord, bool) {
+	if !taskAvailable() {
+		return nil, false
+	}
+	var records []TaskRecord
+	records = append(records, userTasks("upload", fs.UploadTaskManager, uid)...)
+	records = append(records, userTasks("copy", fs.CopyTaskManager, uid)...)
+	records = append(records, userTasks("move", fs.MoveTaskManager, uid)...)
+	records = append(records, userTasks("offline_download", tool.DownloadTaskManager, uid)...)
+	records = append(records, userTasks("offline_download_transfer", tool.TransferTaskManager, uid)...)
+	records = append(records, userTasks("decompress", fs.ArchiveDownloadTaskManager, uid)...)
+	records = append(records, userTasks("decompress_upload", fs.ArchiveContentUploadTaskManager, uid)...)
+	return records, true
+}
+
+func isCreator[T task.TaskExtensionInfo](t T, uid uint) bool {
+	return t.GetCreator() != nil && t.GetCreator().ID == uid
+}
+
+func userTasks[T task.TaskExtensionInfo](typ string, m task.Manager[T], uid uint) []TaskRecord {
+	tasks := m.GetByCondition(func(t T) bool {
+		return isCreator(t, uid)
+	})
+	return utils.MustSliceConvert(tasks, func(t T) TaskRecord {
+		errMsg := ""
+		if t.GetErr() != nil {
+			errMsg = t.GetErr().Error()
+		}
+		return TaskRecord{
+			Type:       typ,
+			ID:         t.GetID(),
+			Name:       t.GetName(),
+			Status:     t.GetStatus(),
+			StartTime:  t.GetStartTime(),
+			EndTime:    t.GetEndTime(),
+			TotalBytes: t.GetTotalBytes(),
+			Error:      errMsg,
+		}
+	})
+}
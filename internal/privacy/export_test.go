@@ -0,0 +1,63 @@
+package privacy_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/privacy"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+func TestExport(t *testing.T) {
+	u := createUser(t, "export_user")
+	other := createUser(t, "export_other")
+	createUserFiles(t, u, other)
+
+	var buf bytes.Buffer
+	if err := privacy.Export(context.Background(), u, &buf); err != nil {
+		t.Fatalf("failed export: %+v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed read archive: %+v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed open %s: %+v", f.Name, err)
+		}
+		b, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatalf("failed read %s: %+v", f.Name, err)
+		}
+		files[f.Name] = string(b)
+	}
+
+	var grants []model.PathGrant
+	if err = utils.Json.UnmarshalFromString(files["path_grants.json"], &grants); err != nil || len(grants) != 2 {
+		t.Errorf("expected the grant given to and the one requested by the user, got %+v %v", grants, err)
+	}
+	for name, want := range map[string]string{
+		"link_passwords.json":      `"path":"/privacy/file"`,
+		"scheduled_deletions.json": `"path":"/privacy/file"`,
+		"watch_rules.json":         `"name":"privacy"`,
+	} {
+		if !strings.Contains(files[name], want) {
+			t.Errorf("expected %s to contain %s, got %q", name, want, files[name])
+		}
+	}
+	var manifest privacy.Manifest
+	if err = utils.Json.UnmarshalFromString(files["manifest.json"], &manifest); err != nil {
+		t.Fatalf("failed decode manifest: %+v", err)
+	}
+	if len(manifest.Files) != len(files)-1 {
+		t.Errorf("expected the manifest to list the other files, got %+v", manifest.Files)
+	}
+}
@@ -0,0 +1,99 @@
+package soak
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Stats struct {
+	Name     string        `json:"name"`
+	Requests int64         `json:"requests"`
+	Errors   int64         `json:"errors"`
+	Bytes    int64         `json:"bytes"`
+	Min      time.Duration `json:"min"`
+	Avg      time.Duration `json:"avg"`
+	P50      time.Duration `json:"p50"`
+	P95      time.Duration `json:"p95"`
+	P99      time.Duration `json:"p99"`
+	Max      time.Duration `json:"max"`
+	// LastError is the last error seen, to give a hint when Errors is not zero
+	LastError string `json:"last_error,omitempty"`
+}
+
+type recorder struct {
+	name      string
+	mu        sync.Mutex
+	latencies []time.Duration
+	errors    int64
+	bytes     int64
+	lastErr   string
+}
+
+func (r *recorder) record(d time.Duration, n int64, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies = append(r.latencies, d)
+	r.bytes += n
+	if err != nil {
+		r.errors++
+		r.lastErr = err.Error()
+	}
+}
+
+func (r *recorder) stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := Stats{
+		Name:      r.name,
+		Requests:  int64(len(r.latencies)),
+		Errors:    r.errors,
+		Bytes:     r.bytes,
+		LastError: r.lastErr,
+	}
+	if len(r.latencies) == 0 {
+		return s
+	}
+	sorted := make([]time.Duration, len(r.latencies))
+	copy(sorted, r.latencies)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	var sum time.Duration
+	for _, d := range sorted {
+		sum += d
+	}
+	percentile := func(p float64) time.Duration {
+		return sorted[int(float64(len(sorted)-1)*p)]
+	}
+	s.Min = sorted[0]
+	s.Max = sorted[len(sorted)-1]
+	s.Avg = sum / time.Duration(len(sorted))
+	s.P50 = percentile(0.50)
+	s.P95 = percentile(0.95)
+	s.P99 = percentile(0.99)
+	return s
+}
+
+// logCounter counts the log entries written while the traffic is running
+type logCounter struct {
+	mediaAccess atomic.Int64
+	errors      atomic.Int64
+}
+
+func (h *logCounter) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *logCounter) Fire(e *logrus.Entry) error {
+	if e.Data["type"] == "media_access" {
+		h.mediaAccess.Add(1)
+	}
+	if e.Level <= logrus.ErrorLevel {
+		h.errors.Add(1)
+	}
+	return nil
+}
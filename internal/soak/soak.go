@@ -0,0 +1,270 @@
+// Package soak runs the server against the mock driver and generates synthetic
+// traffic, to catch performance regressions in the proxy and the caches.
+package soak
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	stdpath "path"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const mountPath = "/soak"
+
+type Config struct {
+	Duration time.Duration
+	// number of concurrent clients of each kind of traffic
+	ListWorkers  int
+	RangeWorkers int
+	ShareWorkers int
+	// RangeSize is the size of every range request, the whole file is read if it is not positive
+	RangeSize int64
+	// Shares is the number of single file shares to hit
+	Shares int
+	// Refresh makes listings skip the cache
+	Refresh bool
+	Storage mock.Addition
+}
+
+type Report struct {
+	Duration  time.Duration `json:"duration"`
+	Scenarios []Stats       `json:"scenarios"`
+	// MediaRequests is the number of successful requests of media files,
+	// compare it with MediaAccessLogs to see how much the dedupe cache saves
+	MediaRequests   int64  `json:"media_requests"`
+	MediaAccessLogs int64  `json:"media_access_logs"`
+	ErrorLogs       int64  `json:"error_logs"`
+	Goroutines      int    `json:"goroutines"`
+	HeapAlloc       uint64 `json:"heap_alloc"`
+	NumGC           uint32 `json:"num_gc"`
+}
+
+type runner struct {
+	cfg    Config
+	url    string
+	client *http.Client
+	token  string
+	dirs   []string
+	files  []string
+	shares []string
+	media  atomic.Int64
+}
+
+// Run starts the server, generates traffic for cfg.Duration and returns what was measured
+func Run(ctx context.Context, cfg Config) (*Report, error) {
+	e, err := servertest.Setup()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed init server")
+	}
+	counter := &logCounter{}
+	logrus.AddHook(counter)
+
+	r := &runner{cfg: cfg}
+	if err = r.prepare(ctx); err != nil {
+		return nil, err
+	}
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+	r.url = srv.URL
+	workers := cfg.ListWorkers + cfg.RangeWorkers + cfg.ShareWorkers
+	r.client = &http.Client{
+		Transport: &http.Transport{
+			MaxIdleConnsPerHost: workers,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+	start := time.Now()
+	list := &recorder{name: "list"}
+	rng := &recorder{name: "range"}
+	share := &recorder{name: "share"}
+	var wg sync.WaitGroup
+	var workerID int64
+	spawn := func(n int, rec *recorder, fn func(ctx context.Context, rnd *rand.Rand) (int64, error)) {
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			// every worker has its own random source, so a run can be replayed
+			workerID++
+			seed := cfg.Storage.Seed + workerID
+			go func() {
+				defer wg.Done()
+				r.loop(runCtx, rand.New(rand.NewSource(seed)), rec, fn)
+			}()
+		}
+	}
+	spawn(cfg.ListWorkers, list, r.list)
+	spawn(cfg.RangeWorkers, rng, r.rangeStream)
+	if len(r.shares) > 0 {
+		spawn(cfg.ShareWorkers, share, r.shareHit)
+	}
+	wg.Wait()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return &Report{
+		Duration:        time.Since(start),
+		Scenarios:       []Stats{list.stats(), rng.stats(), share.stats()},
+		MediaRequests:   r.media.Load(),
+		MediaAccessLogs: counter.mediaAccess.Load(),
+		ErrorLogs:       counter.errors.Load(),
+		Goroutines:      runtime.NumGoroutine(),
+		HeapAlloc:       mem.HeapAlloc,
+		NumGC:           mem.NumGC,
+	}, nil
+}
+
+// prepare mounts the mock storage and creates the shares
+func (r *runner) prepare(ctx context.Context) error {
+	addition, err := utils.Json.MarshalToString(r.cfg.Storage)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = op.CreateStorage(ctx, model.Storage{
+		MountPath: mountPath,
+		Driver:    "Mock",
+		Addition:  addition,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "failed mount mock storage")
+	}
+	storage, err := op.GetStorageByMountPath(mountPath)
+	if err != nil {
+		return err
+	}
+	r.dirs = append(r.dirs, mountPath)
+	storage.(*mock.Mock).Walk(func(obj model.Obj) {
+		path := stdpath.Join(mountPath, obj.GetPath())
+		if obj.IsDir() {
+			r.dirs = append(r.dirs, path)
+		} else {
+			r.files = append(r.files, path)
+		}
+	})
+	if len(r.files) == 0 {
+		return errors.New("the mock storage has no files, raise the number of files")
+	}
+	admin, err := op.GetAdmin()
+	if err != nil {
+		return err
+	}
+	if r.token, err = common.GenerateToken(admin); err != nil {
+		return errors.WithStack(err)
+	}
+	for i := 0; i < r.cfg.Shares && i < len(r.files); i++ {
+		sid, err := op.CreateSharing(&model.Sharing{
+			SharingDB: &model.SharingDB{},
+			Files:     []string{r.files[i]},
+			Creator:   admin,
+		})
+		if err != nil {
+			return errors.WithMessage(err, "failed create sharing")
+		}
+		r.shares = append(r.shares, sid)
+	}
+	return nil
+}
+
+func (r *runner) loop(ctx context.Context, rnd *rand.Rand, rec *recorder, fn func(ctx context.Context, rnd *rand.Rand) (int64, error)) {
+	for ctx.Err() == nil {
+		start := time.Now()
+		n, err := fn(ctx, rnd)
+		if ctx.Err() != nil {
+			// the request was cut off by the end of the run
+			return
+		}
+		rec.record(time.Since(start), n, err)
+	}
+}
+
+func (r *runner) list(ctx context.Context, rnd *rand.Rand) (int64, error) {
+	dir := r.dirs[rnd.Intn(len(r.dirs))]
+	body, err := utils.Json.MarshalToString(map[string]any{
+		"path":    dir,
+		"refresh": r.cfg.Refresh,
+	})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url+"/api/fs/list", strings.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", r.token)
+	req.Header.Set("Content-Type", "application/json")
+	res, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return int64(len(data)), err
+	}
+	if code := utils.Json.Get(data, "code").ToInt(); code != 200 {
+		return int64(len(data)), errors.Errorf("list %s: %s", dir, utils.Json.Get(data, "message").ToString())
+	}
+	return int64(len(data)), nil
+}
+
+func (r *runner) rangeStream(ctx context.Context, rnd *rand.Rand) (int64, error) {
+	file := r.files[rnd.Intn(len(r.files))]
+	return r.download(ctx, rnd, "/d"+utils.EncodePath(file, true)+"?sign="+sign.Sign(file), file)
+}
+
+func (r *runner) shareHit(ctx context.Context, rnd *rand.Rand) (int64, error) {
+	i := rnd.Intn(len(r.shares))
+	return r.download(ctx, rnd, "/sd/"+r.shares[i], r.files[i])
+}
+
+func (r *runner) download(ctx context.Context, rnd *rand.Rand, u, file string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url+u, nil)
+	if err != nil {
+		return 0, err
+	}
+	if r.cfg.RangeSize > 0 {
+		var off int64
+		// generated files are at least half of the configured size
+		if last := r.cfg.Storage.FileSize/2 - r.cfg.RangeSize; last > 0 {
+			off = rnd.Int63n(last + 1)
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+r.cfg.RangeSize-1))
+	}
+	res, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	n, err := io.Copy(io.Discard, res.Body)
+	if err != nil {
+		return n, err
+	}
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+		return n, errors.Errorf("get %s: status %d", file, res.StatusCode)
+	}
+	if common.IsMediaFile(file) {
+		r.media.Add(1)
+	}
+	return n, nil
+}
@@ -0,0 +1,254 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultInterval is the scan interval in minutes of the rules without one
+const DefaultInterval = 10
+
+// Release is a subfolder shared or refreshed by a scan
+type Release struct {
+	Rule      string     `json:"rule"`
+	Path      string     `json:"path"`
+	SharingID string     `json:"sharing_id"`
+	URL       string     `json:"url"`
+	Pwd       string     `json:"pwd,omitempty"`
+	Expires   *time.Time `json:"expires,omitempty"`
+	Refreshed bool       `json:"refreshed"`
+}
+
+// scanLock makes sure a rule isn't scanned twice at the same time, which would share a subfolder twice
+var scanLock sync.Mutex
+
+// Validate checks a rule before it's saved
+func Validate(r *model.WatchRule) error {
+	r.Path = utils.FixAndCleanPath(r.Path)
+	if r.Interval <= 0 {
+		r.Interval = DefaultInterval
+	}
+	creator, err := op.GetUserById(r.CreatorID)
+	if err != nil {
+		return errors.WithMessage(err, "failed get creator")
+	}
+	return checkCreator(creator, r.Path)
+}
+
+// checkCreator makes sure the creator of a rule may still share the watched path,
+// it's checked on every scan since the user may be changed after the rule is saved
+func checkCreator(creator *model.User, path string) error {
+	if creator.Disabled || !creator.CanShare() {
+		return errors.Errorf("user [%s] can't share", creator.Username)
+	}
+	if !utils.IsSubPath(creator.BasePath, path) {
+		return errors.Errorf("path [%s] isn't in the base path of user [%s]", path, creator.Username)
+	}
+	return nil
+}
+
+// ScanDue scans the enabled rules whose interval has passed since their last scan
+func ScanDue(ctx context.Context) {
+	rules, err := db.GetEnabledWatchRules()
+	if err != nil {
+		log.Errorf("failed get watch rules: %+v", err)
+		return
+	}
+	now := time.Now()
+	for i := range rules {
+		r := &rules[i]
+		if r.LastScanAt != nil && now.Sub(*r.LastScanAt) < time.Duration(r.Interval)*time.Minute {
+			continue
+		}
+		if _, err := Scan(ctx, r); err != nil {
+			log.Errorf("failed scan watch rule %s: %+v", r.Name, err)
+		}
+	}
+}
+
+// Scan shares the new subfolders of the rule, refreshes the modified ones if the rule asks for it,
+// and posts the releases to the notification channels of the rule
+func Scan(ctx context.Context, r *model.WatchRule) ([]Release, error) {
+	scanLock.Lock()
+	defer scanLock.Unlock()
+	releases, err := scan(ctx, r)
+	now := time.Now()
+	r.LastScanAt = &now
+	r.LastError = ""
+	if err != nil {
+		r.LastError = err.Error()
+	}
+	if len(releases) > 0 {
+		if notifyErr := notifyReleases(r, releases); notifyErr != nil {
+			log.Warnf("failed notify releases of watch rule %s: %+v", r.Name, notifyErr)
+			if r.LastError == "" {
+				r.LastError = notifyErr.Error()
+			}
+		}
+	}
+	if updateErr := db.UpdateWatchRule(r); updateErr != nil {
+		log.Errorf("failed update watch rule %s: %+v", r.Name, updateErr)
+	}
+	return releases, err
+}
+
+func scan(ctx context.Context, r *model.WatchRule) ([]Release, error) {
+	creator, err := op.GetUserById(r.CreatorID)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get creator")
+	}
+	if err = checkCreator(creator, r.Path); err != nil {
+		return nil, err
+	}
+	objs, err := fs.List(ctx, r.Path, &fs.ListArgs{Refresh: true, NoLog: true})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed list watched path")
+	}
+	shares, err := db.GetWatchSharesByRuleId(r.ID)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]*model.WatchShare, len(shares))
+	for i := range shares {
+		seen[shares[i].Path] = &shares[i]
+	}
+	first := r.LastScanAt == nil
+	var releases []Release
+	for _, obj := range objs {
+		if !obj.IsDir() {
+			continue
+		}
+		path := stdpath.Join(r.Path, obj.GetName())
+		ws, ok := seen[path]
+		if !ok {
+			ws = &model.WatchShare{RuleID: r.ID, Path: path, Modified: obj.ModTime()}
+			if first && !r.IncludeExisting {
+				// the subfolders from before the rule are only remembered
+				if err = db.SaveWatchShare(ws); err != nil {
+					return releases, err
+				}
+				continue
+			}
+		} else if ws.SharingID == "" || !r.Refresh || !obj.ModTime().After(ws.Modified) {
+			continue
+		}
+		release, err := share(r, creator, ws)
+		if err != nil {
+			return releases, errors.WithMessagef(err, "failed share %s", path)
+		}
+		ws.Modified = obj.ModTime()
+		if err = db.SaveWatchShare(ws); err != nil {
+			return releases, err
+		}
+		releases = append(releases, *release)
+	}
+	return releases, nil
+}
+
+// share creates the sharing of a subfolder, or renews it if the subfolder was shared before
+func share(r *model.WatchRule, creator *model.User, ws *model.WatchShare) (*Release, error) {
+	t := r.Template
+	var expires *time.Time
+	if t.ExpireHours > 0 {
+		e := time.Now().Add(time.Duration(t.ExpireHours) * time.Hour)
+		expires = &e
+	}
+	pwd := t.Pwd
+	if t.RandomPwd {
+		pwd = random.String(8)
+	}
+	release := &Release{Rule: r.Name, Path: ws.Path, Pwd: pwd, Expires: expires}
+	if ws.SharingID != "" {
+		if s, err := op.GetSharingById(ws.SharingID, true); err == nil {
+			s.Expires = expires
+			s.Pwd = pwd
+			s.Accessed = 0
+			s.Disabled = false
+			if err = op.UpdateSharing(s, true); err != nil {
+				return nil, err
+			}
+			release.SharingID, release.URL, release.Refreshed = s.ID, sharingURL(s.ID), true
+			return release, nil
+		}
+		// the sharing was deleted meanwhile, share the subfolder again
+	}
+	s := &model.Sharing{
+		SharingDB: &model.SharingDB{
+			Expires:     expires,
+			Pwd:         pwd,
+			MaxAccessed: t.MaxAccessed,
+			Remark:      t.Remark,
+			Readme:      t.Readme,
+			Header:      t.Header,
+		},
+		Files:   []string{ws.Path},
+		Creator: creator,
+	}
+	// the sharing counts towards the share quotas of the creator and its group
+	id, err := op.CreateSharing(s)
+	if err != nil {
+		return nil, err
+	}
+	ws.SharingID = id
+	release.SharingID, release.URL = id, sharingURL(id)
+	return release, nil
+}
+
+func sharingURL(id string) string {
+	return fmt.Sprintf("%s/@s/%s", strings.TrimSuffix(conf.Conf.SiteURL, "/"), id)
+}
+
+func notifyReleases(r *model.WatchRule, releases []Release) error {
+	var errs []string
+	if r.WebhookURL != "" {
+		res, err := base.RestyClient.R().
+			SetBody(map[string]any{
+				"rule":     r.Name,
+				"path":     r.Path,
+				"releases": releases,
+			}).
+			Post(r.WebhookURL)
+		if err != nil {
+			errs = append(errs, err.Error())
+		} else if res.IsError() {
+			errs = append(errs, "webhook responded "+res.Status())
+		}
+	}
+	if r.NotifyEmail != "" && notify.MailEnabled() {
+		var body strings.Builder
+		for _, release := range releases {
+			fmt.Fprintf(&body, "%s\n%s\n", release.Path, release.URL)
+			if release.Pwd != "" {
+				fmt.Fprintf(&body, "password: %s\n", release.Pwd)
+			}
+			if release.Expires != nil {
+				fmt.Fprintf(&body, "expires: %s\n", release.Expires.Format(time.RFC3339))
+			}
+			body.WriteString("\n")
+		}
+		subject := fmt.Sprintf("[%s] %d new release(s)", r.Name, len(releases))
+		if err := notify.SendMail(r.NotifyEmail, subject, body.String()); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package watch_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/watch"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file:watch?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	base.InitClient()
+	db.Init(dB)
+}
+
+func TestScanChecksCreator(t *testing.T) {
+	ctx := context.Background()
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Mock",
+		MountPath: "/watch",
+		Addition:  `{"seed":1,"depth":2,"num_folder":2,"num_file":0}`,
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteStorageById(ctx, id)
+	})
+	creator := &model.User{Username: "watch_creator", BasePath: "/", Permission: 1 << 14, MaxShares: 1, Authn: "[]"}
+	if err = op.CreateUser(creator); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteUserById(creator.ID)
+	})
+	rule := &model.WatchRule{Name: "releases", Path: "/watch", CreatorID: creator.ID, IncludeExisting: true}
+	if err = watch.Validate(rule); err != nil {
+		t.Fatalf("failed validate rule: %+v", err)
+	}
+	if err = db.CreateWatchRule(rule); err != nil {
+		t.Fatalf("failed create rule: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.DeleteWatchRuleById(rule.ID)
+	})
+
+	// the second subfolder exceeds the share quota of the creator
+	releases, err := watch.Scan(ctx, rule)
+	if !errors.Is(err, errs.ShareQuotaExceeded) || len(releases) != 1 {
+		t.Fatalf("expected one release within the share quota, got %+v %v", releases, err)
+	}
+
+	creator.MaxShares = 0
+	creator.Disabled = true
+	if err = op.UpdateUser(creator); err != nil {
+		t.Fatalf("failed update user: %+v", err)
+	}
+	if releases, err = watch.Scan(ctx, rule); err == nil || len(releases) != 0 {
+		t.Errorf("expected a disabled creator to share nothing, got %+v %v", releases, err)
+	}
+
+	creator.Disabled = false
+	creator.Permission = 0
+	if err = op.UpdateUser(creator); err != nil {
+		t.Fatalf("failed update user: %+v", err)
+	}
+	if releases, err = watch.Scan(ctx, rule); err == nil || len(releases) != 0 {
+		t.Errorf("expected a creator without the share permission to share nothing, got %+v %v", releases, err)
+	}
+}
+
+func TestScanFollowsTemplate(t *testing.T) {
+	ctx := context.Background()
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Mock",
+		MountPath: "/releases",
+		Addition:  `{"seed":2,"depth":2,"num_folder":2,"num_file":0}`,
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteStorageById(ctx, id)
+	})
+	storage, err := op.GetStorageByMountPath("/releases")
+	if err != nil {
+		t.Fatalf("failed get storage: %+v", err)
+	}
+	creator := &model.User{Username: "release_creator", BasePath: "/", Permission: 1 << 14, Authn: "[]"}
+	if err = op.CreateUser(creator); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteUserById(creator.ID)
+	})
+
+	var mu sync.Mutex
+	var posted []watch.Release
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Releases []watch.Release `json:"releases"`
+		}
+		_ = utils.Json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		posted = append(posted, body.Releases...)
+		mu.Unlock()
+	}))
+	defer hook.Close()
+	rule := &model.WatchRule{
+		Name:       "releases",
+		Path:       "/releases",
+		CreatorID:  creator.ID,
+		Template:   model.SharingTemplate{RandomPwd: true, ExpireHours: 24, MaxAccessed: 10},
+		WebhookURL: hook.URL,
+	}
+	if err = watch.Validate(rule); err != nil {
+		t.Fatalf("failed validate rule: %+v", err)
+	}
+	if err = db.CreateWatchRule(rule); err != nil {
+		t.Fatalf("failed create rule: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.DeleteWatchRuleById(rule.ID)
+	})
+
+	if releases, err := watch.Scan(ctx, rule); err != nil || len(releases) != 0 {
+		t.Fatalf("expected the existing folders to be skipped, got %+v %v", releases, err)
+	}
+	if err = op.MakeDir(ctx, storage, "/v1.0"); err != nil {
+		t.Fatalf("failed make dir: %+v", err)
+	}
+	releases, err := watch.Scan(ctx, rule)
+	if err != nil || len(releases) != 1 {
+		t.Fatalf("expected the new folder to be shared, got %+v %v", releases, err)
+	}
+	release := releases[0]
+	t.Cleanup(func() {
+		_ = op.DeleteSharing(release.SharingID)
+	})
+	if release.Path != "/releases/v1.0" || release.Pwd == "" || release.Expires == nil {
+		t.Errorf("expected the release to follow the template, got %+v", release)
+	}
+	sharing, err := op.GetSharingById(release.SharingID)
+	if err != nil || sharing.MaxAccessed != 10 || sharing.Pwd != release.Pwd || len(sharing.Files) != 1 || sharing.Files[0] != "/releases/v1.0" {
+		t.Errorf("expected the sharing to follow the template, got %+v %v", sharing, err)
+	}
+	mu.Lock()
+	if len(posted) != 1 || posted[0].URL != release.URL {
+		t.Errorf("expected the release to be posted to the webhook, got %+v", posted)
+	}
+	mu.Unlock()
+	if releases, err = watch.Scan(ctx, rule); err != nil || len(releases) != 0 {
+		t.Errorf("expected a folder to be shared once, got %+v %v", releases, err)
+	}
+}
@@ -0,0 +1,175 @@
+package expr
+
+import (
+	"fmt"
+	"net"
+	stdpath "path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+type builtin func(args []any) (any, error)
+
+var builtins map[string]builtin
+
+func init() {
+	builtins = map[string]builtin{
+		"startsWith": stringsFunc(func(s []string) any { return strings.HasPrefix(s[0], s[1]) }, 2),
+		"endsWith":   stringsFunc(func(s []string) any { return strings.HasSuffix(s[0], s[1]) }, 2),
+		"lower":      stringsFunc(func(s []string) any { return strings.ToLower(s[0]) }, 1),
+		"upper":      stringsFunc(func(s []string) any { return strings.ToUpper(s[0]) }, 1),
+		"trim":       stringsFunc(func(s []string) any { return strings.TrimSpace(s[0]) }, 1),
+		"base":       stringsFunc(func(s []string) any { return stdpath.Base(s[0]) }, 1),
+		"dir":        stringsFunc(func(s []string) any { return stdpath.Dir(s[0]) }, 1),
+		"ext": stringsFunc(func(s []string) any {
+			return strings.ToLower(strings.TrimPrefix(stdpath.Ext(s[0]), "."))
+		}, 1),
+		"glob":    stringsErrFunc(glob, 2),
+		"matches": stringsErrFunc(matches, 2),
+		"cidr":    stringsErrFunc(inCIDR, 2),
+		"len":     length,
+	}
+}
+
+func stringsFunc(fn func(s []string) any, n int) builtin {
+	return stringsErrFunc(func(s []string) (any, error) {
+		return fn(s), nil
+	}, n)
+}
+
+func stringsErrFunc(fn func(s []string) (any, error), n int) builtin {
+	return func(args []any) (any, error) {
+		if len(args) != n {
+			return nil, fmt.Errorf("expected %d arguments, got %d", n, len(args))
+		}
+		s := make([]string, n)
+		for i, arg := range args {
+			str, ok := arg.(string)
+			if !ok {
+				return nil, fmt.Errorf("argument %d is %s instead of string", i+1, typeName(arg))
+			}
+			s[i] = str
+		}
+		return fn(s)
+	}
+}
+
+func length(args []any) (any, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+	}
+	switch v := args[0].(type) {
+	case string:
+		return float64(len([]rune(v))), nil
+	case []any:
+		return float64(len(v)), nil
+	case map[string]any:
+		return float64(len(v)), nil
+	}
+	return nil, fmt.Errorf("can't get the length of %s", typeName(args[0]))
+}
+
+// glob matches a path against a pattern of path.Match, `**` matches any number of path elements
+func glob(s []string) (any, error) {
+	name, pattern := s[0], s[1]
+	if !strings.Contains(pattern, "**") {
+		ok, err := stdpath.Match(pattern, name)
+		return ok, err
+	}
+	re, err := cachedRegexp("glob:"+pattern, func() (string, error) {
+		return globToRegexp(pattern)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString(name), nil
+}
+
+func globToRegexp(pattern string) (string, error) {
+	if _, err := stdpath.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		case '[':
+			// character classes of path.Match are valid in regexp as well
+			j := strings.IndexByte(pattern[i:], ']')
+			if j < 0 {
+				return "", stdpath.ErrBadPattern
+			}
+			sb.WriteString(pattern[i : i+j+1])
+			i += j
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return sb.String(), nil
+}
+
+// matches uses the RE2 syntax, so it runs in linear time whatever the pattern is
+func matches(s []string) (any, error) {
+	re, err := cachedRegexp("re:"+s[1], func() (string, error) {
+		return s[1], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString(s[0]), nil
+}
+
+func inCIDR(s []string) (any, error) {
+	_, network, err := net.ParseCIDR(s[1])
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(s[0])
+	return ip != nil && network.Contains(ip), nil
+}
+
+const maxCachedRegexps = 256
+
+var (
+	regexpCache     = map[string]*regexp.Regexp{}
+	regexpCacheLock sync.Mutex
+)
+
+func cachedRegexp(key string, source func() (string, error)) (*regexp.Regexp, error) {
+	regexpCacheLock.Lock()
+	defer regexpCacheLock.Unlock()
+	if re, ok := regexpCache[key]; ok {
+		return re, nil
+	}
+	src, err := source()
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(regexpCache) >= maxCachedRegexps {
+		// patterns usually come from a few policies, so simply start over
+		regexpCache = map[string]*regexp.Regexp{}
+	}
+	regexpCache[key] = re
+	return re, nil
+}
@@ -0,0 +1,342 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Env holds the variables of an evaluation. Values may be nil, bool, string,
+// any number type, []any, []string and map[string]any.
+type Env map[string]any
+
+// Eval evaluates the program with the variables of env
+func (p *Program) Eval(env Env) (any, error) {
+	return eval(p.root, env)
+}
+
+// EvalBool evaluates the program and requires the result to be a bool
+func (p *Program) EvalBool(env Env) (bool, error) {
+	v, err := p.Eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression returns %s instead of bool", typeName(v))
+	}
+	return b, nil
+}
+
+func eval(n node, env Env) (any, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		return n.value, nil
+	case *variableNode:
+		v, ok := env[n.name]
+		if !ok {
+			return nil, fmt.Errorf("undefined variable %s", n.name)
+		}
+		return normalize(v), nil
+	case *memberNode:
+		x, err := eval(n.x, env)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := x.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("can't get field %s of %s", n.name, typeName(x))
+		}
+		return normalize(m[n.name]), nil
+	case *indexNode:
+		return evalIndex(n, env)
+	case *listNode:
+		items := make([]any, 0, len(n.items))
+		for _, item := range n.items {
+			v, err := eval(item, env)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case *unaryNode:
+		x, err := eval(n.x, env)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "!":
+			b, ok := x.(bool)
+			if !ok {
+				return nil, fmt.Errorf("can't negate %s", typeName(x))
+			}
+			return !b, nil
+		default:
+			f, ok := x.(float64)
+			if !ok {
+				return nil, fmt.Errorf("can't negate %s", typeName(x))
+			}
+			return -f, nil
+		}
+	case *binaryNode:
+		return evalBinary(n, env)
+	case *callNode:
+		args := make([]any, 0, len(n.args))
+		for _, arg := range n.args {
+			v, err := eval(arg, env)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, v)
+		}
+		v, err := n.fn(args)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", n.name, err)
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("unknown node %T", n)
+}
+
+func evalIndex(n *indexNode, env Env) (any, error) {
+	x, err := eval(n.x, env)
+	if err != nil {
+		return nil, err
+	}
+	index, err := eval(n.index, env)
+	if err != nil {
+		return nil, err
+	}
+	switch x := x.(type) {
+	case map[string]any:
+		key, ok := index.(string)
+		if !ok {
+			return nil, fmt.Errorf("can't index map with %s", typeName(index))
+		}
+		return normalize(x[key]), nil
+	case []any:
+		f, ok := index.(float64)
+		if !ok || f != math.Trunc(f) {
+			return nil, fmt.Errorf("can't index list with %v", index)
+		}
+		i := int(f)
+		if i < 0 {
+			i += len(x)
+		}
+		if i < 0 || i >= len(x) {
+			return nil, nil
+		}
+		return normalize(x[i]), nil
+	}
+	return nil, fmt.Errorf("can't index %s", typeName(x))
+}
+
+func evalBinary(n *binaryNode, env Env) (any, error) {
+	l, err := eval(n.l, env)
+	if err != nil {
+		return nil, err
+	}
+	// && and || short-circuit
+	if n.op == "&&" || n.op == "||" {
+		lb, ok := l.(bool)
+		if !ok {
+			return nil, fmt.Errorf("left side of %s is %s instead of bool", n.op, typeName(l))
+		}
+		if lb == (n.op == "||") {
+			return lb, nil
+		}
+		r, err := eval(n.r, env)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := r.(bool)
+		if !ok {
+			return nil, fmt.Errorf("right side of %s is %s instead of bool", n.op, typeName(r))
+		}
+		return rb, nil
+	}
+	r, err := eval(n.r, env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case "in":
+		return contains(r, l)
+	case "+":
+		if ls, ok := l.(string); ok {
+			if rs, ok := r.(string); ok {
+				return ls + rs, nil
+			}
+		}
+	case "<", "<=", ">", ">=":
+		c, err := compare(l, r)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	}
+	lf, lok := l.(float64)
+	rf, rok := r.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("can't apply %s to %s and %s", n.op, typeName(l), typeName(r))
+	}
+	switch n.op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return lf / rf, nil
+	default:
+		if rf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(lf, rf), nil
+	}
+}
+
+func equal(l, r any) bool {
+	switch l := l.(type) {
+	case []any:
+		rl, ok := r.([]any)
+		if !ok || len(l) != len(rl) {
+			return false
+		}
+		for i := range l {
+			if !equal(l[i], rl[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		return false
+	}
+	if _, ok := r.(map[string]any); ok {
+		return false
+	}
+	if _, ok := r.([]any); ok {
+		return false
+	}
+	return l == r
+}
+
+func compare(l, r any) (int, error) {
+	switch l := l.(type) {
+	case float64:
+		if r, ok := r.(float64); ok {
+			switch {
+			case l < r:
+				return -1, nil
+			case l > r:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if r, ok := r.(string); ok {
+			return strings.Compare(l, r), nil
+		}
+	}
+	return 0, fmt.Errorf("can't compare %s and %s", typeName(l), typeName(r))
+}
+
+func contains(container, x any) (bool, error) {
+	switch c := container.(type) {
+	case []any:
+		for _, item := range c {
+			if equal(item, x) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case string:
+		s, ok := x.(string)
+		if !ok {
+			return false, fmt.Errorf("can't look for %s in a string", typeName(x))
+		}
+		return strings.Contains(c, s), nil
+	case map[string]any:
+		s, ok := x.(string)
+		if !ok {
+			return false, fmt.Errorf("can't look for %s in a map", typeName(x))
+		}
+		_, ok = c[s]
+		return ok, nil
+	}
+	return false, fmt.Errorf("can't look for a value in %s", typeName(container))
+}
+
+// normalize converts the values of the environment into the types the
+// evaluator works with, numbers become float64 and string slices []any
+func normalize(v any) any {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int8:
+		return float64(v)
+	case int16:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case []string:
+		items := make([]any, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return items
+	case Env:
+		return map[string]any(v)
+	}
+	return v
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,109 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	env := Env{
+		"path": "/media/movies/a.MP4",
+		"ip":   "10.1.2.3",
+		"user": map[string]any{
+			"name":     "alice",
+			"is_guest": false,
+			"role":     2,
+			"groups":   []string{"staff", "family"},
+		},
+		"time": Env{"hour": 23},
+	}
+	tests := []struct {
+		src    string
+		expect any
+	}{
+		{`1 + 2 * 3`, 7.0},
+		{`(1 + 2) * 3`, 9.0},
+		{`-7 % 4`, -3.0},
+		{`"a" + 'b'`, "ab"},
+		{`user.name == "alice" && !user.is_guest`, true},
+		{`user.role >= 2`, true},
+		{`"staff" in user.groups`, true},
+		{`"admin" in user.groups`, false},
+		{`"name" in user`, true},
+		{`"movies" in path`, true},
+		{`user.groups[-1]`, "family"},
+		{`user["name"]`, "alice"},
+		{`user.missing == null`, true},
+		{`[1, 2] == [1, 2]`, true},
+		{`ext(path) == "mp4" && startsWith(path, "/media/")`, true},
+		{`glob(path, "/media/**.MP4")`, true},
+		{`glob(path, "/media/*.MP4")`, false},
+		{`glob(path, "/media/movies/*.MP4")`, true},
+		{`matches(lower(base(path)), "^a\\.(mp4|mkv)$")`, true},
+		{`cidr(ip, "10.0.0.0/8") && !cidr(ip, "10.2.0.0/16")`, true},
+		{`time.hour >= 22 || time.hour < 6`, true},
+		{`len(user.groups) == 2 && len("héllo") == 5`, true},
+		// the right side is skipped, so the undefined variable doesn't fail it
+		{`false && undefined`, false},
+	}
+	for _, tt := range tests {
+		p, err := Compile(tt.src)
+		if err != nil {
+			t.Errorf("failed compile %s: %+v", tt.src, err)
+			continue
+		}
+		v, err := p.Eval(env)
+		if err != nil {
+			t.Errorf("failed eval %s: %+v", tt.src, err)
+			continue
+		}
+		if !equal(v, tt.expect) {
+			t.Errorf("%s: expected %v, got %v", tt.src, tt.expect, v)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	compileErrors := []string{
+		``,
+		`1 +`,
+		`(1`,
+		`"unterminated`,
+		`a ? b`,
+		`system("rm -rf /")`,
+		`a.`,
+		`[1, 2`,
+		strings.Repeat("(", maxDepth+1) + "1" + strings.Repeat(")", maxDepth+1),
+		strings.Repeat("a", MaxLength+1),
+	}
+	for _, src := range compileErrors {
+		if _, err := Compile(src); err == nil {
+			t.Errorf("expected compile error of %.20s", src)
+		}
+	}
+
+	evalErrors := []string{
+		`undefined`,
+		`1 && true`,
+		`"a" < 1`,
+		`1 / 0`,
+		`path.name`,
+		`startsWith(path)`,
+		`cidr(path, "invalid")`,
+	}
+	for _, src := range evalErrors {
+		p, err := Compile(src)
+		if err != nil {
+			t.Errorf("failed compile %s: %+v", src, err)
+			continue
+		}
+		if _, err = p.Eval(Env{"path": "/a"}); err == nil {
+			t.Errorf("expected eval error of %s", src)
+		}
+	}
+
+	p, _ := Compile(`path`)
+	if _, err := p.EvalBool(Env{"path": "/a"}); err == nil {
+		t.Errorf("expected error of a non bool result")
+	}
+}
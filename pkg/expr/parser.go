@@ -0,0 +1,264 @@
+package expr
+
+import (
+	"fmt"
+)
+
+const (
+	// MaxLength is the max length of the source of an expression
+	MaxLength = 4096
+	// maxDepth limits the nesting of an expression, so parsing and evaluating can't overflow the stack
+	maxDepth = 64
+)
+
+type node interface{}
+
+type (
+	literalNode  struct{ value any }
+	variableNode struct{ name string }
+	memberNode   struct {
+		x    node
+		name string
+	}
+	indexNode struct{ x, index node }
+	listNode  struct{ items []node }
+	unaryNode struct {
+		op string
+		x  node
+	}
+	binaryNode struct {
+		op   string
+		l, r node
+	}
+	callNode struct {
+		name string
+		fn   builtin
+		args []node
+	}
+)
+
+// binary operators from the lowest to the highest precedence
+var precedences = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+// Program is a compiled expression, it is safe for concurrent use
+type Program struct {
+	src  string
+	root node
+}
+
+func (p *Program) String() string {
+	return p.src
+}
+
+// Compile parses src, unknown functions and syntax errors are reported here
+// instead of when the expression is evaluated
+func Compile(src string) (*Program, error) {
+	if len(src) > MaxLength {
+		return nil, fmt.Errorf("expression is longer than %d", MaxLength)
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+	return &Program{src: src, root: root}, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isOp(t token, ops ...string) bool {
+	if t.kind != tokenOp && !(t.kind == tokenIdent && t.text == "in") {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if t := p.next(); !p.isOp(t, op) {
+		if t.kind == tokenEOF {
+			return fmt.Errorf("expected %q at the end", op)
+		}
+		return fmt.Errorf("expected %q at %d, got %q", op, t.pos, t.text)
+	}
+	return nil
+}
+
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > maxDepth {
+		return fmt.Errorf("expression is nested deeper than %d", maxDepth)
+	}
+	return nil
+}
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level == len(precedences) {
+		return p.parseUnary()
+	}
+	l, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp(p.peek(), precedences[level]...) {
+		op := p.next().text
+		r, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryNode{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+	if t := p.peek(); p.isOp(t, "!", "-") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: t.text, x: x}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case p.isOp(t, "."):
+			p.next()
+			name := p.next()
+			if name.kind != tokenIdent {
+				return nil, fmt.Errorf("expected a field name at %d", name.pos)
+			}
+			x = &memberNode{x: x, name: name.text}
+		case p.isOp(t, "["):
+			p.next()
+			index, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = &indexNode{x: x, index: index}
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		return &literalNode{value: t.num}, nil
+	case tokenString:
+		return &literalNode{value: t.text}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		if !p.isOp(p.peek(), "(") {
+			return &variableNode{name: t.text}, nil
+		}
+		fn, ok := builtins[t.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %s at %d", t.text, t.pos)
+		}
+		p.next()
+		args, err := p.parseList(")")
+		if err != nil {
+			return nil, err
+		}
+		return &callNode{name: t.text, fn: fn, args: args}, nil
+	case tokenOp:
+		switch t.text {
+		case "(":
+			x, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &listNode{items: items}, nil
+		}
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+}
+
+// parseList parses comma separated expressions up to the closing op
+func (p *parser) parseList(closing string) ([]node, error) {
+	var items []node
+	if p.isOp(p.peek(), closing) {
+		p.next()
+		return items, nil
+	}
+	for {
+		x, err := p.parseBinary(0)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, x)
+		if p.isOp(p.peek(), ",") {
+			p.next()
+			continue
+		}
+		return items, p.expect(closing)
+	}
+}
@@ -141,6 +141,20 @@ func CreateTempFile(r io.Reader, size int64) (*os.File, error) {
 // GetFileType get file type
 func GetFileType(filename string) int {
 	ext := strings.ToLower(Ext(filename))
+	// the mapping of file_types takes precedence over the type lists
+	if ft, ok := conf.FileTypeMap[ext]; ok && ft.Preview != "" {
+		switch ft.Preview {
+		case conf.PreviewVideo:
+			return conf.VIDEO
+		case conf.PreviewAudio:
+			return conf.AUDIO
+		case conf.PreviewImage:
+			return conf.IMAGE
+		case conf.PreviewText:
+			return conf.TEXT
+		}
+		return conf.UNKNOWN
+	}
 	if SliceContains(conf.SlicesMap[conf.AudioTypes], ext) {
 		return conf.AUDIO
 	}
@@ -163,16 +177,20 @@ func GetObjType(filename string, isDir bool) int {
 	return GetFileType(filename)
 }
 
-var extraMimeTypes = map[string]string{
-	".apk": "application/vnd.android.package-archive",
+// MappedMimeType returns the mime type the file_types setting maps the extension of name to
+func MappedMimeType(name string) (string, bool) {
+	ft, ok := conf.FileTypeMap[Ext(name)]
+	if !ok || ft.Mime == "" {
+		return "", false
+	}
+	return ft.Mime, true
 }
 
 func GetMimeType(name string) string {
-	ext := path.Ext(name)
-	if m, ok := extraMimeTypes[ext]; ok {
+	if m, ok := MappedMimeType(name); ok {
 		return m
 	}
-	m := mime.TypeByExtension(ext)
+	m := mime.TypeByExtension(path.Ext(name))
 	if m != "" {
 		return m
 	}
@@ -2,12 +2,11 @@ package common
 
 import (
 	"fmt"
+	stdpath "path"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/OpenListTeam/OpenList/v4/internal/conf"
-	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
@@ -121,21 +120,33 @@ func detectAccessType(c *gin.Context) string {
 	return AccessTypeDownload
 }
 
-// LogMediaAccess 记录媒体文件访问日志（用于前端预览）
-func LogMediaAccess(c *gin.Context, rawPath string) {
-	LogMediaAccessWithType(c, rawPath, AccessTypePreview)
+func init() {
+	// 媒体访问日志作为访问事件的 hook 注册，也是其他 hook 的参考实现
+	RegisterAccessHook("media_logger", 0, logMediaAccess)
+	RegisterEntryHook("media_logger", 0, logMediaEntry)
 }
 
-// LogMediaAccessWithType 记录媒体文件访问日志（指定类型）
-func LogMediaAccessWithType(c *gin.Context, rawPath string, accessType string) {
-	if !IsMediaFile(rawPath) {
+// logMediaEntry 在调试日志中记录流式列表返回的媒体文件，列出不算访问，所以不写访问日志
+func logMediaEntry(e *EntryEvent) {
+	if e.Obj.IsDir() || !IsMediaFile(e.Obj.GetName()) {
 		return
 	}
+	username := "Guest"
+	if e.User != nil {
+		username = e.User.Username
+	}
+	log.WithFields(log.Fields{
+		"type": "media_list",
+		"user": username,
+		"path": stdpath.Join(e.Parent, e.Obj.GetName()),
+	}).Debug("[媒体列表] 列出媒体文件")
+}
 
-	// 获取客户端IP
-	clientIP := "unknown"
-	if c != nil {
-		clientIP = c.ClientIP()
+// logMediaAccess 记录媒体文件访问日志
+func logMediaAccess(e *AccessEvent) {
+	rawPath, clientIP, accessType := e.Path, e.IP, e.Type
+	if !IsMediaFile(rawPath) {
+		return
 	}
 
 	// 去重检查
@@ -145,10 +156,8 @@ func LogMediaAccessWithType(c *gin.Context, rawPath string, accessType string) {
 
 	// 获取用户信息
 	username := "Guest"
-	if c != nil && c.Request != nil && c.Request.Context() != nil {
-		if user, ok := c.Request.Context().Value(conf.UserKey).(*model.User); ok && user != nil {
-			username = user.Username
-		}
+	if e.User != nil {
+		username = e.User.Username
 	}
 
 	// 格式化时间
@@ -173,9 +182,3 @@ func LogMediaAccessWithType(c *gin.Context, rawPath string, accessType string) {
 	// 输出到标准输出（运行日志）
 	fmt.Println("[媒体访问] " + logMsg)
 }
-
-// LogMediaAccessAuto 自动检测访问类型并记录日志
-func LogMediaAccessAuto(c *gin.Context, rawPath string) {
-	accessType := detectAccessType(c)
-	LogMediaAccessWithType(c, rawPath, accessType)
-}
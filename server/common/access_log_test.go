@@ -0,0 +1,67 @@
+package common
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestLogMediaAccess(t *testing.T) {
+	hook := new(test.Hook)
+	old := log.StandardLogger().ReplaceHooks(log.LevelHooks{})
+	log.AddHook(hook)
+	resetAccessCache := func() {
+		accessCacheLock.Lock()
+		accessCache = make(map[string]time.Time)
+		accessCacheLock.Unlock()
+	}
+	resetAccessCache()
+	t.Cleanup(func() {
+		log.StandardLogger().ReplaceHooks(old)
+		resetAccessCache()
+	})
+	access := func(path, ip, ua string, user *model.User) *log.Entry {
+		hook.Reset()
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/d"+path, nil)
+		c.Request.Header.Set("User-Agent", ua)
+		c.Request.RemoteAddr = ip + ":1234"
+		if user != nil {
+			GinWithValue(c, conf.UserKey, user)
+		}
+		EmitAccessEventAuto(c, path)
+		for _, e := range hook.AllEntries() {
+			if e.Data["type"] == "media_access" && e.Data["path"] == path {
+				return e
+			}
+		}
+		return nil
+	}
+
+	e := access("/media/a.mp4", "10.0.0.1", "", nil)
+	if e == nil || e.Data["user"] != "Guest" || e.Data["access_type"] != AccessTypeDownload || e.Data["ip"] != "10.0.0.1" {
+		t.Errorf("unexpected access log of a media file: %+v", e)
+	}
+	if e = access("/media/a.txt", "10.0.0.1", "", nil); e != nil {
+		t.Errorf("expected no access log of a non-media file, got %+v", e.Data)
+	}
+	e = access("/media/b.mkv", "10.0.0.1", "VLC/3.0.20 LibVLC/3.0.20", &model.User{Username: "media_user"})
+	if e == nil || e.Data["user"] != "media_user" || e.Data["access_type"] != AccessTypePlayer {
+		t.Errorf("unexpected access log of a player: %+v", e)
+	}
+
+	// repeated access from the same ip is deduplicated
+	if e = access("/media/a.mp4", "10.0.0.1", "", nil); e != nil {
+		t.Errorf("expected the repeated access to be deduplicated, got %+v", e.Data)
+	}
+	if e = access("/media/a.mp4", "10.0.0.2", "", nil); e == nil {
+		t.Error("expected the access from another ip to be logged")
+	}
+}
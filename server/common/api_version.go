@@ -0,0 +1,85 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/pkg/errors"
+)
+
+const (
+	APIVersionV1 = 1
+	// APIVersionV2 returns the user of a user-bound sign separately instead of
+	// appending it to the sign as `sign:user:username`
+	APIVersionV2 = 2
+
+	LatestAPIVersion = APIVersionV2
+	APIVersionHeader = "X-API-Version"
+)
+
+var acceptVersionRegexp = regexp.MustCompile(`application/vnd\.openlist\.v(\d+)\+json`)
+
+// NegotiateAPIVersion returns the version asked for by the X-API-Version header
+// or a `application/vnd.openlist.v2+json` Accept header, 0 if none was asked for
+func NegotiateAPIVersion(header, accept string) (int, error) {
+	if header == "" {
+		if m := acceptVersionRegexp.FindStringSubmatch(accept); m != nil {
+			header = m[1]
+		}
+	}
+	if header == "" {
+		return 0, nil
+	}
+	v, err := strconv.Atoi(header)
+	if err != nil || v < APIVersionV1 || v > LatestAPIVersion {
+		return 0, errors.Errorf("unsupported api version: %s, the latest is %d", header, LatestAPIVersion)
+	}
+	return v, nil
+}
+
+// GetAPIVersion returns the api version of the request, requests outside of the api are v1
+func GetAPIVersion(ctx context.Context) int {
+	if v, ok := ctx.Value(conf.APIVersionKey).(int); ok {
+		return v
+	}
+	return APIVersionV1
+}
+
+// DeprecationHeaders returns the Deprecation and Sunset headers of the v1 api, an
+// empty value means the header is not sent
+func DeprecationHeaders() (deprecation, sunset string) {
+	deprecation = "true"
+	if at := setting.GetInt(conf.ApiV1DeprecatedAt, 0); at > 0 {
+		deprecation = "@" + strconv.Itoa(at)
+	}
+	if at := setting.GetInt(conf.ApiV1SunsetAt, 0); at > 0 {
+		sunset = time.Unix(int64(at), 0).UTC().Format(http.TimeFormat)
+	}
+	return
+}
+
+// UserSign returns the sign field and the user field of a user-bound sign for
+// the api version of the request, v1 clients expect the user in the sign
+func UserSign(ctx context.Context, sign, username string) (string, string) {
+	if sign == "" {
+		return "", ""
+	}
+	if GetAPIVersion(ctx) >= APIVersionV2 {
+		return sign, username
+	}
+	return sign + ":user:" + username, ""
+}
+
+// UserSignQuery returns the query of a user-bound sign in the format of the api version
+func UserSignQuery(ctx context.Context, sign, username string) string {
+	if GetAPIVersion(ctx) >= APIVersionV2 {
+		return "?sign=" + url.QueryEscape(sign) + "&user=" + url.QueryEscape(username)
+	}
+	return "?sign=" + sign + ":user:" + username
+}
@@ -15,6 +15,9 @@ var SecretKey []byte
 type UserClaims struct {
 	Username string `json:"username"`
 	PwdTS    int64  `json:"pwd_ts"`
+	// ClientID and Scopes are set on the tokens exchanged by client apps
+	ClientID string   `json:"client_id,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -38,6 +41,28 @@ func GenerateToken(user *model.User) (tokenString string, err error) {
 	return tokenString, err
 }
 
+// GenerateScopedToken generates a token of the user for a client app, which
+// only works on the routes of its scopes and expires after ttl
+func GenerateScopedToken(user *model.User, clientID string, scopes []string, ttl time.Duration) (tokenString string, err error) {
+	claim := UserClaims{
+		Username: user.Username,
+		PwdTS:    user.PwdTS,
+		ClientID: clientID,
+		Scopes:   scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+		}}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claim)
+	tokenString, err = token.SignedString(SecretKey)
+	if err != nil {
+		return "", err
+	}
+	validTokenCache.Set(tokenString, true, cache.WithEx[bool](ttl))
+	return tokenString, err
+}
+
 func ParseToken(tokenString string) (*UserClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return SecretKey, nil
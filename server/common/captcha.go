@@ -0,0 +1,35 @@
+package common
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/pkg/errors"
+)
+
+// VerifyCaptcha checks a captcha response against a siteverify style endpoint,
+// which is shared by reCAPTCHA, hCaptcha and Cloudflare Turnstile.
+func VerifyCaptcha(verifyUrl, secret, response, ip string) error {
+	if response == "" {
+		return errors.New("captcha is required")
+	}
+	var resp struct {
+		Success bool `json:"success"`
+	}
+	res, err := base.RestyClient.R().
+		SetFormData(map[string]string{
+			"secret":   secret,
+			"response": response,
+			"remoteip": ip,
+		}).
+		SetResult(&resp).
+		Post(verifyUrl)
+	if err != nil {
+		return errors.WithMessage(err, "failed verify captcha")
+	}
+	if res.IsError() {
+		return errors.Errorf("failed verify captcha: %s", res.Status())
+	}
+	if !resp.Success {
+		return errors.New("captcha verification failed")
+	}
+	return nil
+}
@@ -32,6 +32,10 @@ func IsApply(metaPath, reqPath string, applySub bool) bool {
 }
 
 func CanAccess(user *model.User, meta *model.Meta, reqPath string, password string) bool {
+	// restricted paths need an approved grant, whatever the nearest meta is
+	if !op.CanAccessRestricted(user, reqPath) {
+		return false
+	}
 	// if the reqPath is in hide (only can check the nearest meta) and user can't see hides, can't access
 	if meta != nil && !user.CanSeeHides() && meta.Hide != "" &&
 		IsApply(meta.Path, path.Dir(reqPath), meta.HSub) { // the meta should apply to the parent of current path
@@ -58,6 +62,17 @@ func CanAccess(user *model.User, meta *model.Meta, reqPath string, password stri
 	return meta.Password == password
 }
 
+// CanAccessRestricted checks the grants of user on every path, for the handlers that
+// change files without checking them with CanAccess
+func CanAccessRestricted(user *model.User, paths ...string) bool {
+	for _, p := range paths {
+		if !op.CanAccessRestricted(user, p) {
+			return false
+		}
+	}
+	return true
+}
+
 // ShouldProxy TODO need optimize
 // when should be proxy?
 // 1. config.MustProxy()
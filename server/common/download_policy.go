@@ -0,0 +1,56 @@
+package common
+
+import (
+	"net/http"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/policy"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	// the policy runs before the other pre-download hooks, a denied download is not worth annotating
+	RegisterHook(HookPreDownload, "download_policy", -100, checkDownloadPolicy)
+}
+
+// checkDownloadPolicy checks the downloads of /d, /p and the archives. The path of a
+// share download is the one inside the share, so the share handlers check it once unwrapped.
+func checkDownloadPolicy(c *gin.Context) {
+	ctx := c.Request.Context()
+	if _, ok := ctx.Value(conf.SharingIDKey).(string); ok {
+		return
+	}
+	path, _ := ctx.Value(conf.PathKey).(string)
+	CheckDownloadPolicy(c, path)
+}
+
+// CheckDownloadPolicy evaluates the download policy for the file at path,
+// the request is aborted if it is denied
+func CheckDownloadPolicy(c *gin.Context, path string) bool {
+	if !policy.Enabled() {
+		return true
+	}
+	ctx := c.Request.Context()
+	in := policy.Input{
+		Path:      path,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	in.User, _ = ctx.Value(conf.UserKey).(*model.User)
+	in.Sharing, _ = ctx.Value(conf.SharingIDKey).(string)
+	d := policy.Check(in)
+	for k, v := range d.Annotations {
+		c.Header("X-Policy-"+k, v)
+	}
+	if d.Action == policy.ActionDeny {
+		msg := d.Message
+		if msg == "" {
+			msg = "the download is denied by the policy"
+		}
+		ErrorPage(c, errors.New(msg), http.StatusForbidden)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,209 @@
+package common
+
+import (
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// HookPoint is a place in the request pipeline where registered hooks run,
+// so forks and plugins can add behavior without patching the router
+type HookPoint int
+
+const (
+	// HookPreAuth runs for every request before the user is resolved
+	HookPreAuth HookPoint = iota
+	// HookPostAuth runs once the user of an api request is in the context
+	HookPostAuth
+	// HookPreDownload runs before a download, proxy or share download is served
+	HookPreDownload
+	// HookPostDownload runs after the download handler returns, even if the hooks before aborted it
+	HookPostDownload
+)
+
+var hookPointNames = map[HookPoint]string{
+	HookPreAuth:      "pre-auth",
+	HookPostAuth:     "post-auth",
+	HookPreDownload:  "pre-download",
+	HookPostDownload: "post-download",
+}
+
+func (p HookPoint) String() string {
+	return hookPointNames[p]
+}
+
+// AccessEvent describes a file being accessed, it is emitted by the handlers
+// instead of writing logs directly
+type AccessEvent struct {
+	Context *gin.Context
+	// Path is the full path of the file in OpenList
+	Path string
+	// Type is one of AccessTypePreview, AccessTypeDownload and AccessTypePlayer
+	Type string
+	User *model.User
+	IP   string
+}
+
+// EntryEvent describes an object sent to the client by a streaming listing,
+// hooks see the entries one by one while they are written
+type EntryEvent struct {
+	Context *gin.Context
+	// Parent is the full path of the listed directory
+	Parent string
+	Obj    model.Obj
+	User   *model.User
+}
+
+type hook[T any] struct {
+	name     string
+	priority int
+	fn       T
+}
+
+var (
+	hooksLock    sync.RWMutex
+	hooks        = map[HookPoint][]hook[gin.HandlerFunc]{}
+	accessHooks  []hook[func(e *AccessEvent)]
+	entryHooks   []hook[func(e *EntryEvent)]
+	hookSequence int
+)
+
+// insertHook adds h to list, a hook with the same name is replaced
+func insertHook[T any](list []hook[T], h hook[T]) []hook[T] {
+	// running hooks may iterate the old list, the new one never shares its array
+	list = append(slices.Clip(removeHook(list, h.name)), h)
+	// hooks with the same priority run in the order they are registered
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].priority < list[j].priority
+	})
+	return list
+}
+
+// removeHook returns list without the hook of the name, the array of list is left untouched
+func removeHook[T any](list []hook[T], name string) []hook[T] {
+	for i, old := range list {
+		if old.name == name {
+			return append(append([]hook[T]{}, list[:i]...), list[i+1:]...)
+		}
+	}
+	return list
+}
+
+// RegisterHook adds fn to the hooks of point, hooks with a lower priority run first.
+// A hook may abort the request like any gin middleware, the remaining hooks and
+// the handler are skipped then. It is safe to register hooks after the server started,
+// registering a name again replaces the hook of that name.
+func RegisterHook(point HookPoint, name string, priority int, fn gin.HandlerFunc) {
+	hooksLock.Lock()
+	defer hooksLock.Unlock()
+	hooks[point] = insertHook(hooks[point], hook[gin.HandlerFunc]{name: name, priority: priority, fn: fn})
+}
+
+// RegisterAccessHook adds fn to the hooks called for every access event
+func RegisterAccessHook(name string, priority int, fn func(e *AccessEvent)) {
+	hooksLock.Lock()
+	defer hooksLock.Unlock()
+	accessHooks = insertHook(accessHooks, hook[func(e *AccessEvent)]{name: name, priority: priority, fn: fn})
+}
+
+// RegisterEntryHook adds fn to the hooks called for every entry of a streaming listing
+func RegisterEntryHook(name string, priority int, fn func(e *EntryEvent)) {
+	hooksLock.Lock()
+	defer hooksLock.Unlock()
+	entryHooks = insertHook(entryHooks, hook[func(e *EntryEvent)]{name: name, priority: priority, fn: fn})
+}
+
+// UnregisterHook removes the hook of point with the name, if any
+func UnregisterHook(point HookPoint, name string) {
+	hooksLock.Lock()
+	defer hooksLock.Unlock()
+	hooks[point] = removeHook(hooks[point], name)
+}
+
+func UnregisterAccessHook(name string) {
+	hooksLock.Lock()
+	defer hooksLock.Unlock()
+	accessHooks = removeHook(accessHooks, name)
+}
+
+func UnregisterEntryHook(name string) {
+	hooksLock.Lock()
+	defer hooksLock.Unlock()
+	entryHooks = removeHook(entryHooks, name)
+}
+
+// HookNames returns the names of the hooks of point in the order they run
+func HookNames(point HookPoint) []string {
+	hooksLock.RLock()
+	defer hooksLock.RUnlock()
+	names := make([]string, 0, len(hooks[point]))
+	for _, h := range hooks[point] {
+		names = append(names, h.name)
+	}
+	return names
+}
+
+// RunHooks calls the hooks of point and reports whether the request may go on
+func RunHooks(point HookPoint, c *gin.Context) bool {
+	hooksLock.RLock()
+	list := hooks[point]
+	hooksLock.RUnlock()
+	for _, h := range list {
+		h.fn(c)
+		if c.IsAborted() {
+			log.Debugf("request %s aborted by %s hook [%s]", c.Request.URL.Path, point, h.name)
+			return false
+		}
+	}
+	return true
+}
+
+// EmitAccessEvent passes the access of rawPath to the access hooks
+func EmitAccessEvent(c *gin.Context, rawPath string, accessType string) {
+	e := &AccessEvent{
+		Context: c,
+		Path:    rawPath,
+		Type:    accessType,
+		IP:      "unknown",
+	}
+	if c != nil {
+		e.IP = c.ClientIP()
+		if c.Request != nil {
+			e.User, _ = c.Request.Context().Value(conf.UserKey).(*model.User)
+		}
+	}
+	hooksLock.RLock()
+	list := accessHooks
+	hooksLock.RUnlock()
+	for _, h := range list {
+		h.fn(e)
+	}
+}
+
+// EmitAccessEventAuto detects the type of the access from the request and emits it
+func EmitAccessEventAuto(c *gin.Context, rawPath string) {
+	EmitAccessEvent(c, rawPath, detectAccessType(c))
+}
+
+// EmitEntryEvent passes an entry of a streaming listing to the entry hooks
+func EmitEntryEvent(c *gin.Context, parent string, obj model.Obj) {
+	e := &EntryEvent{
+		Context: c,
+		Parent:  parent,
+		Obj:     obj,
+	}
+	if c != nil && c.Request != nil {
+		e.User, _ = c.Request.Context().Value(conf.UserKey).(*model.User)
+	}
+	hooksLock.RLock()
+	list := entryHooks
+	hooksLock.RUnlock()
+	for _, h := range list {
+		h.fn(e)
+	}
+}
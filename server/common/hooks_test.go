@@ -0,0 +1,60 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegisterHook(t *testing.T) {
+	point := HookPostDownload
+	t.Cleanup(func() {
+		for _, name := range []string{"test_a", "test_b"} {
+			UnregisterHook(point, name)
+		}
+	})
+	names := func() string {
+		var res []string
+		for _, name := range HookNames(point) {
+			if strings.HasPrefix(name, "test_") {
+				res = append(res, name)
+			}
+		}
+		return strings.Join(res, ",")
+	}
+	nop := func(c *gin.Context) {}
+
+	RegisterHook(point, "test_a", 10, nop)
+	RegisterHook(point, "test_b", 20, nop)
+	if got := names(); got != "test_a,test_b" {
+		t.Errorf("expected the hooks in the order of their priority, got %s", got)
+	}
+	// registering a name again replaces its hook
+	RegisterHook(point, "test_a", 30, nop)
+	if got := names(); got != "test_b,test_a" {
+		t.Errorf("expected the hook to be replaced, got %s", got)
+	}
+	UnregisterHook(point, "test_a")
+	if got := names(); got != "test_b" {
+		t.Errorf("expected the hook to be removed, got %s", got)
+	}
+	UnregisterHook(point, "test_missing")
+	if got := names(); got != "test_b" {
+		t.Errorf("expected removing a missing hook to change nothing, got %s", got)
+	}
+
+	var calls []string
+	RegisterAccessHook("test_access", 0, func(e *AccessEvent) {
+		calls = append(calls, "first")
+	})
+	RegisterAccessHook("test_access", 0, func(e *AccessEvent) {
+		calls = append(calls, "second")
+	})
+	EmitAccessEvent(nil, "/file", AccessTypeDownload)
+	UnregisterAccessHook("test_access")
+	EmitAccessEvent(nil, "/file", AccessTypeDownload)
+	if strings.Join(calls, ",") != "second" {
+		t.Errorf("expected only the replacing access hook to be called once, got %v", calls)
+	}
+}
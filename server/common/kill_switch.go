@@ -0,0 +1,97 @@
+package common
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+type KillSwitchStatus struct {
+	Enabled  bool  `json:"enabled"`
+	ExpireAt int64 `json:"expire_at"`
+}
+
+var (
+	killSwitchTimer *time.Timer
+	// killSwitchGen changes on every SetKillSwitch, a timer that fired while the
+	// kill switch was set again must not turn off the new one
+	killSwitchGen uint64
+	killSwitchMu  sync.Mutex
+)
+
+// IsKillSwitchOn reports whether guest access, public shares and unsigned downloads are cut off.
+// An expired kill switch is treated as off even if it has not been reset in the database yet,
+// e.g. when the server restarted before the timer fired.
+func IsKillSwitchOn() bool {
+	if !setting.GetBool(conf.KillSwitch) {
+		return false
+	}
+	expireAt := int64(setting.GetInt(conf.KillSwitchExpireAt, 0))
+	return expireAt <= 0 || time.Now().Unix() < expireAt
+}
+
+func GetKillSwitchStatus() KillSwitchStatus {
+	if !IsKillSwitchOn() {
+		return KillSwitchStatus{}
+	}
+	return KillSwitchStatus{
+		Enabled:  true,
+		ExpireAt: int64(setting.GetInt(conf.KillSwitchExpireAt, 0)),
+	}
+}
+
+// SetKillSwitch turns the kill switch on or off.
+// If duration is positive, the kill switch turns itself off after it elapses.
+func SetKillSwitch(enable bool, duration time.Duration) error {
+	killSwitchMu.Lock()
+	defer killSwitchMu.Unlock()
+	if killSwitchTimer != nil {
+		killSwitchTimer.Stop()
+		killSwitchTimer = nil
+	}
+	killSwitchGen++
+	gen := killSwitchGen
+	var expireAt int64
+	if enable && duration > 0 {
+		expireAt = time.Now().Add(duration).Unix()
+	}
+	if err := saveKillSwitch(enable, expireAt); err != nil {
+		return err
+	}
+	if enable {
+		utils.Log.Warnf("kill switch enabled, expire at: %d", expireAt)
+	} else {
+		utils.Log.Infof("kill switch disabled")
+	}
+	if expireAt > 0 {
+		killSwitchTimer = time.AfterFunc(duration, func() {
+			killSwitchMu.Lock()
+			defer killSwitchMu.Unlock()
+			if gen != killSwitchGen {
+				return
+			}
+			killSwitchTimer = nil
+			if err := saveKillSwitch(false, 0); err != nil {
+				utils.Log.Errorf("failed to turn off expired kill switch: %+v", err)
+				return
+			}
+			utils.Log.Infof("kill switch expired and has been turned off")
+		})
+	}
+	return nil
+}
+
+func saveKillSwitch(enable bool, expireAt int64) error {
+	items, err := op.GetSettingItemInKeys([]string{conf.KillSwitch, conf.KillSwitchExpireAt})
+	if err != nil {
+		return err
+	}
+	items[0].Value = strconv.FormatBool(enable)
+	items[1].Value = strconv.FormatInt(expireAt, 10)
+	return op.SaveSettingItems(items)
+}
@@ -0,0 +1,50 @@
+package common
+
+import (
+	"fmt"
+	"html"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// LinkPasswordCookie is the cookie keeping the unlock token of a protected link
+func LinkPasswordCookie(l *model.LinkPassword) string {
+	return fmt.Sprintf("openlist_link_%d", l.ID)
+}
+
+// LinkPasswordPage is the interstitial asking a browser for the password of a protected link,
+// the form posts to the unlock api which redirects back to redirect
+func LinkPasswordPage(c *gin.Context, path, redirect string, wrong bool) {
+	message := ""
+	if wrong {
+		message = `<p style="color: #d33">The password is incorrect</p>`
+	}
+	page := fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+	<head>
+		<meta charset="utf-8" />
+		<meta name="viewport" content="width=device-width, initial-scale=1" />
+		<meta name="color-scheme" content="dark light" />
+		<meta name="robots" content="noindex" />
+		<title>Password required</title>
+	</head>
+	<body>
+		<h1>Password required</h1>
+		<hr>
+		<p>%s is protected by a password</p>
+		%s
+		<form method="post" action="%s/api/public/link_password/unlock">
+			<input type="hidden" name="path" value="%s" />
+			<input type="hidden" name="redirect" value="%s" />
+			<input type="password" name="password" autofocus required />
+			<button type="submit">Unlock</button>
+		</form>
+	</body>
+</html>`,
+		html.EscapeString(path), message, html.EscapeString(GetApiUrl(c.Request.Context())),
+		html.EscapeString(path), html.EscapeString(redirect))
+	c.Header("Cache-Control", "no-store")
+	c.Data(401, "text/html; charset=utf-8", []byte(page))
+	c.Abort()
+}
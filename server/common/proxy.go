@@ -80,7 +80,10 @@ func attachHeader(w http.ResponseWriter, file model.Obj, link *model.Link) {
 	}
 	w.Header().Set("Etag", GetEtag(file, size))
 	contentType := link.Header.Get("Content-Type")
-	if len(contentType) > 0 {
+	if m, ok := utils.MappedMimeType(fileName); ok {
+		// the mapping of the admin wins over the type reported by the storage
+		w.Header().Set("Content-Type", m)
+	} else if len(contentType) > 0 {
 		w.Header().Set("Content-Type", contentType)
 	} else {
 		w.Header().Set("Content-Type", utils.GetMimeType(fileName))
@@ -0,0 +1,89 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// scopeRoutes are the api routes each scope allows, routes ending with / are prefixes
+var scopeRoutes = map[string][]string{
+	model.ScopeFsRead: {
+		"/fs/list", "/fs/list/stream", "/fs/get", "/fs/dirs", "/fs/search", "/fs/other", "/fs/prefetch",
+		"/fs/archive/meta", "/fs/archive/list",
+		// downloads with the token in the query
+		"/d/", "/p/",
+	},
+	model.ScopeFsWrite: {
+		"/fs/mkdir", "/fs/rename", "/fs/batch_rename", "/fs/regex_rename",
+		"/fs/move", "/fs/recursive_move", "/fs/copy", "/fs/remove", "/fs/remove_empty_directory",
+		"/fs/schedule_remove", "/fs/cancel_scheduled_remove",
+		"/fs/put", "/fs/form", "/fs/get_direct_upload_info", "/fs/archive/decompress",
+	},
+	model.ScopeShare: {"/share/", "/fs/link_password/set", "/fs/link_password/delete"},
+}
+
+// routes every scoped token may use
+var scopelessRoutes = []string{"/me", "/auth/logout"}
+
+// apiRoute returns the route relative to the api without the version, e.g. /fs/list
+func apiRoute(fullPath string) string {
+	route := strings.TrimPrefix(fullPath, strings.TrimSuffix(conf.URL.Path, "/"))
+	route = strings.TrimPrefix(route, "/api")
+	return strings.TrimPrefix(route, "/v2")
+}
+
+func routeMatches(routes []string, route string) bool {
+	for _, r := range routes {
+		if r == route || strings.HasSuffix(r, "/") && strings.HasPrefix(route, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeAllows reports whether a token with the scopes may use the route of fullPath
+func ScopeAllows(scopes []string, fullPath string) bool {
+	route := apiRoute(fullPath)
+	if routeMatches(scopelessRoutes, route) {
+		return true
+	}
+	for _, scope := range scopes {
+		if routeMatches(scopeRoutes[scope], route) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckClientToken checks a token exchanged by a client app against the app
+// and the scopes, tokens of a login always pass
+func CheckClientToken(c *gin.Context, claims *UserClaims, user *model.User) error {
+	if claims.ClientID == "" {
+		return nil
+	}
+	app, err := op.GetClientAppByClientId(claims.ClientID)
+	if err != nil || app.Disabled {
+		return errors.New("the client app of the token is disabled or removed")
+	}
+	if !app.CanActAs(user) {
+		return errors.New("the client app may not act as this user")
+	}
+	// the scopes of the app may have been reduced since the token was issued
+	appScopes := app.GetScopes()
+	scopes := make([]string, 0, len(claims.Scopes))
+	for _, scope := range claims.Scopes {
+		if utils.SliceContains(appScopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if c.FullPath() != "" && !ScopeAllows(scopes, c.FullPath()) {
+		return errors.New("the token is not allowed to access this api")
+	}
+	return nil
+}
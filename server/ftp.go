@@ -126,6 +126,9 @@ func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string)
 		if err != nil {
 			return nil, err
 		}
+		if common.IsKillSwitchOn() {
+			return nil, errors.New("guest access is temporarily disabled")
+		}
 	} else {
 		userObj, err = op.GetUserByName(user)
 		if err == nil {
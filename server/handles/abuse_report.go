@@ -0,0 +1,189 @@
+package handles
+
+import (
+	"fmt"
+	"net/mail"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/OpenListTeam/go-cache"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	AbuseReportCache  = cache.NewMemCache[int]()
+	AbuseReportWindow = time.Hour
+	abuseReportLock   sync.Mutex
+)
+
+// countAbuseReport counts a report attempt of ip, it returns false if ip has used up its limit
+func countAbuseReport(ip string, limit int) bool {
+	abuseReportLock.Lock()
+	defer abuseReportLock.Unlock()
+	count, _ := AbuseReportCache.Get(ip)
+	if limit > 0 && count >= limit {
+		return false
+	}
+	AbuseReportCache.Set(ip, count+1, cache.WithEx[int](AbuseReportWindow))
+	return true
+}
+
+type ReportSharingReq struct {
+	Reason  string `json:"reason" binding:"required"`
+	Detail  string `json:"detail"`
+	Email   string `json:"email"`
+	Captcha string `json:"captcha"`
+}
+
+type ReportSharingResp struct {
+	ID uint `json:"id"`
+}
+
+func ReportSharing(c *gin.Context) {
+	if !setting.GetBool(conf.AbuseReportEnabled) {
+		common.ErrorStrResp(c, "abuse reporting is not enabled", 403)
+		return
+	}
+	// every attempt counts, so the captcha and the share ids can't be guessed without limit
+	ip := c.ClientIP()
+	if !countAbuseReport(ip, setting.GetInt(conf.AbuseReportRateLimit, 5)) {
+		common.ErrorStrResp(c, "too many reports, please try again later", 429)
+		return
+	}
+	var req ReportSharingReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Email != "" {
+		addr, err := mail.ParseAddress(req.Email)
+		if err != nil {
+			common.ErrorStrResp(c, "invalid email address", 400)
+			return
+		}
+		req.Email = addr.Address
+	}
+	if verifyUrl := setting.GetStr(conf.AbuseReportCaptchaVerifyUrl); verifyUrl != "" {
+		err := common.VerifyCaptcha(verifyUrl, setting.GetStr(conf.AbuseReportCaptchaSecret), req.Captcha, ip)
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+	}
+	sid := c.Param("sid")
+	if _, err := op.GetSharingById(sid); err != nil {
+		common.ErrorStrResp(c, "the share does not exist", 404)
+		return
+	}
+	r := &model.AbuseReport{
+		SharingID: sid,
+		Reason:    req.Reason,
+		Detail:    req.Detail,
+		Email:     req.Email,
+		IP:        ip,
+	}
+	if err := op.CreateAbuseReport(r); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	log.Infof("abuse report [%d] filed against sharing [%s] from %s", r.ID, sid, ip)
+	common.SuccessResp(c, ReportSharingResp{ID: r.ID})
+}
+
+type ListAbuseReportsReq struct {
+	model.PageReq
+	// Status filters reports by status, a negative value lists all of them
+	Status int `json:"status" form:"status"`
+}
+
+func ListAbuseReports(c *gin.Context) {
+	req := ListAbuseReportsReq{Status: -1}
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	reports, total, err := op.GetAbuseReports(req.Status, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: reports,
+		Total:   total,
+	})
+}
+
+type HandleAbuseReportReq struct {
+	ID             uint   `json:"id" binding:"required"`
+	Status         int    `json:"status"`
+	Note           string `json:"note"`
+	DisableSharing bool   `json:"disable_sharing"`
+}
+
+func HandleAbuseReport(c *gin.Context) {
+	var req HandleAbuseReportReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	reports, err := op.HandleAbuseReport(req.ID, req.Status, req.Note, req.DisableSharing)
+	if errors.Is(err, errs.InvalidAbuseReportStatus) || errors.Is(err, errs.AbuseReportHandled) {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	go notifyReporters(reports)
+	common.SuccessResp(c, reports)
+}
+
+func DeleteAbuseReport(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteAbuseReportById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func notifyReporters(reports []model.AbuseReport) {
+	if !notify.MailEnabled() {
+		return
+	}
+	siteTitle := setting.GetStr(conf.SiteTitle)
+	for _, r := range reports {
+		if r.Email == "" {
+			continue
+		}
+		result := "reviewed and no action was taken"
+		if r.Status == model.AbuseReportResolved {
+			result = "reviewed and the reported content has been dealt with"
+		}
+		subject := fmt.Sprintf("[%s] Your abuse report #%d has been reviewed", siteTitle, r.ID)
+		body := fmt.Sprintf("Your report against share %s has been %s.\n", r.SharingID, result)
+		if r.Note != "" {
+			body += "\nNote from the administrator:\n" + r.Note + "\n"
+		}
+		if err := notify.SendMail(r.Email, subject, body); err != nil {
+			log.Warnf("failed notify reporter of abuse report [%d]: %+v", r.ID, err)
+		}
+	}
+}
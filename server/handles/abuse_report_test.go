@@ -0,0 +1,80 @@
+package handles_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/handles"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+)
+
+func TestReportSharing(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/report", mock.Addition{Seed: 18, Depth: 1, NumFile: 1, FileSize: 16, Extensions: "txt"})
+	admin := s.AdminToken()
+	s.SetSetting(conf.AbuseReportEnabled, "true")
+	s.SetSetting(conf.AbuseReportRateLimit, "2")
+	t.Cleanup(func() {
+		handles.AbuseReportCache.Del("127.0.0.1")
+	})
+	share := servertest.PostJSON[handles.SharingResp](s, "/api/share/create", admin, handles.UpdateSharingReq{Files: []string{"/report/file_0.txt"}})
+	if share.Code != 200 {
+		t.Fatalf("failed create sharing: %s", share.Message)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteSharing(share.Data.ID)
+	})
+	report := func(sid, captcha string) int {
+		return servertest.PostJSON[handles.ReportSharingResp](s, "/api/share/"+sid+"/report", "",
+			handles.ReportSharingReq{Reason: "spam", Captcha: captcha}).Code
+	}
+
+	// the failed attempts count against the limit too
+	captcha := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = utils.Json.NewEncoder(w).Encode(map[string]bool{"success": r.FormValue("response") == "valid"})
+	}))
+	defer captcha.Close()
+	s.SetSetting(conf.AbuseReportCaptchaVerifyUrl, captcha.URL)
+	if code := report(share.Data.ID, "invalid"); code != 403 {
+		t.Errorf("expected a wrong captcha to be rejected, got %d", code)
+	}
+	if code := report("missing", "valid"); code != 404 {
+		t.Errorf("expected a missing share to be rejected, got %d", code)
+	}
+	if code := report(share.Data.ID, "valid"); code != 429 {
+		t.Errorf("expected the failed attempts to use up the limit, got %d", code)
+	}
+
+	handles.AbuseReportCache.Del("127.0.0.1")
+	res := servertest.PostJSON[handles.ReportSharingResp](s, "/api/share/"+share.Data.ID+"/report", "",
+		handles.ReportSharingReq{Reason: "spam", Captcha: "valid"})
+	if res.Code != 200 {
+		t.Fatalf("failed report sharing: %s", res.Message)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteAbuseReportById(res.Data.ID)
+	})
+	handle := func(status int) int {
+		return servertest.PostJSON[any](s, "/api/admin/abuse_report/handle", admin,
+			handles.HandleAbuseReportReq{ID: res.Data.ID, Status: status}).Code
+	}
+	if code := handle(model.AbuseReportPending); code != 400 {
+		t.Errorf("expected an invalid status to be rejected, got %d", code)
+	}
+	if code := handle(model.AbuseReportDismissed); code != 200 {
+		t.Errorf("failed handle abuse report, got %d", code)
+	}
+	if code := handle(model.AbuseReportResolved); code != 400 {
+		t.Errorf("expected a handled report to be rejected, got %d", code)
+	}
+	if r, err := op.GetAbuseReportById(res.Data.ID); err != nil || r.Status != model.AbuseReportDismissed {
+		t.Errorf("expected the report to stay dismissed, got %+v %v", r, err)
+	}
+}
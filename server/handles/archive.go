@@ -83,7 +83,7 @@ func FsArchiveMetaSplit(c *gin.Context) {
 		return
 	}
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if user.IsGuest() && user.Disabled {
+	if user.IsGuest() && (user.Disabled || common.IsKillSwitchOn()) {
 		common.ErrorStrResp(c, "Guest user is disabled, login please", 401)
 		return
 	}
@@ -132,7 +132,10 @@ func FsArchiveMeta(c *gin.Context, req *ArchiveMetaReq, user *model.User) {
 		return
 	}
 	s := ""
-	if isEncrypt(meta, reqPath) || setting.GetBool(conf.SignAll) {
+	if _, restricted := op.GetRestrictedPath(reqPath); restricted {
+		// the archive routes only let the users with a grant in, so the sign carries the user
+		s = common.SignPathWithUser(reqPath, user.Username) + ":user:" + user.Username
+	} else if isEncrypt(meta, reqPath) || setting.GetBool(conf.SignAll) {
 		s = sign.SignArchive(reqPath)
 	}
 	api := "/ae"
@@ -168,7 +171,7 @@ func FsArchiveListSplit(c *gin.Context) {
 		return
 	}
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if user.IsGuest() && user.Disabled {
+	if user.IsGuest() && (user.Disabled || common.IsKillSwitchOn()) {
 		common.ErrorStrResp(c, "Guest user is disabled, login please", 401)
 		return
 	}
@@ -264,6 +267,10 @@ func FsArchiveDecompress(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.CanAccessRestricted(user, append(srcPaths, dstDir)...) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	tasks := make([]task.TaskExtensionInfo, 0, len(srcPaths))
 	for _, srcPath := range srcPaths {
 		t, e := fs.ArchiveDecompress(c.Request.Context(), srcPath, dstDir, model.ArchiveDecompressArgs{
@@ -0,0 +1,40 @@
+package handles
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type ChaosResp struct {
+	Allowed bool           `json:"allowed"`
+	Rules   []op.ChaosRule `json:"rules"`
+}
+
+type SetChaosReq struct {
+	Rules []op.ChaosRule `json:"rules"`
+}
+
+func GetChaos(c *gin.Context) {
+	common.SuccessResp(c, ChaosResp{
+		Allowed: op.ChaosAllowed(),
+		Rules:   op.GetChaosRules(),
+	})
+}
+
+func SetChaos(c *gin.Context) {
+	if !op.ChaosAllowed() {
+		common.ErrorStrResp(c, "chaos rules are only available in dev or debug mode", 403)
+		return
+	}
+	var req SetChaosReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.SetChaosRules(req.Rules); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	GetChaos(c)
+}
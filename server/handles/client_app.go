@@ -0,0 +1,189 @@
+package handles
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// GrantTypeTokenExchange is the grant type of RFC 8693, the subject is given by username
+const GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+type ExchangeTokenReq struct {
+	GrantType    string `json:"grant_type" form:"grant_type"`
+	ClientID     string `json:"client_id" form:"client_id"`
+	ClientSecret string `json:"client_secret" form:"client_secret"`
+	Username     string `json:"username" form:"username"`
+	// Scope is a space separated list, empty asks for every scope of the app
+	Scope string `json:"scope" form:"scope"`
+}
+
+type ExchangeTokenResp struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int64  `json:"expires_in"`
+	Scope           string `json:"scope"`
+}
+
+// oauthError writes an error in the format of RFC 6749 instead of common.Resp,
+// so the client libraries of the apps understand it
+func oauthError(c *gin.Context, status int, code, description string) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, gin.H{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+// ExchangeToken swaps the credential of a client app and a username for a
+// scoped, short-lived token of the user
+func ExchangeToken(c *gin.Context) {
+	var req ExchangeTokenReq
+	if err := c.ShouldBind(&req); err != nil {
+		oauthError(c, 400, "invalid_request", err.Error())
+		return
+	}
+	if req.GrantType != GrantTypeTokenExchange {
+		oauthError(c, 400, "unsupported_grant_type", "grant_type must be "+GrantTypeTokenExchange)
+		return
+	}
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		req.ClientID, req.ClientSecret = id, secret
+	}
+	ip := c.ClientIP()
+	count, ok := model.LoginCache.Get(ip)
+	if ok && count >= model.DefaultMaxAuthRetries {
+		model.LoginCache.Expire(ip, model.DefaultLockDuration)
+		oauthError(c, 429, "invalid_client", "too many failed attempts, try again later")
+		return
+	}
+	app, err := op.VerifyClientApp(req.ClientID, req.ClientSecret)
+	if err != nil {
+		model.LoginCache.Set(ip, count+1)
+		oauthError(c, 401, "invalid_client", err.Error())
+		return
+	}
+	model.LoginCache.Del(ip)
+	user, err := op.GetUserByName(req.Username)
+	if err != nil || !app.CanActAs(user) {
+		oauthError(c, 400, "invalid_grant", "the app may not act as this user")
+		return
+	}
+	appScopes := app.GetScopes()
+	scopes := strings.Fields(req.Scope)
+	if len(scopes) == 0 {
+		scopes = appScopes
+	}
+	for _, scope := range scopes {
+		if !utils.SliceContains(appScopes, scope) {
+			oauthError(c, 400, "invalid_scope", "the app may not ask for scope "+scope)
+			return
+		}
+	}
+	ttl := time.Duration(app.TokenTTL) * time.Second
+	token, err := common.GenerateScopedToken(user, app.ClientID, scopes, ttl)
+	if err != nil {
+		oauthError(c, 500, "server_error", err.Error())
+		return
+	}
+	log.Infof("client app %s exchanged a token of %s with scopes %v", app.Name, user.Username, scopes)
+	c.Header("Cache-Control", "no-store")
+	c.JSON(200, ExchangeTokenResp{
+		AccessToken:     token,
+		IssuedTokenType: "urn:ietf:params:oauth:token-type:access_token",
+		TokenType:       "Bearer",
+		ExpiresIn:       app.TokenTTL,
+		Scope:           strings.Join(scopes, " "),
+	})
+}
+
+func ListClientApps(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	apps, total, err := op.GetClientApps(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: apps,
+		Total:   total,
+	})
+}
+
+type ClientAppSecretResp struct {
+	model.ClientApp
+	// ClientSecret is only returned when the app is created or its secret is reset
+	ClientSecret string `json:"client_secret"`
+}
+
+func CreateClientApp(c *gin.Context) {
+	var req model.ClientApp
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	secret, err := op.CreateClientApp(&req)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, ClientAppSecretResp{ClientApp: req, ClientSecret: secret})
+}
+
+func UpdateClientApp(c *gin.Context) {
+	var req model.ClientApp
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.UpdateClientApp(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+func ResetClientAppSecret(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	secret, err := op.ResetClientAppSecret(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	app, err := op.GetClientAppById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, ClientAppSecretResp{ClientApp: *app, ClientSecret: secret})
+}
+
+func DeleteClientApp(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteClientAppById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
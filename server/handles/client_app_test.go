@@ -0,0 +1,92 @@
+package handles_test
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/handles"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+)
+
+func TestExchangeToken(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/embed", mock.Addition{Seed: 7, Depth: 1, NumFile: 2, FileSize: 16, Extensions: "txt"})
+	user := s.CreateUser(model.User{Username: "embed_user", Permission: 0xff}, "password")
+	s.CreateUser(model.User{Username: "other_user"}, "password")
+	admin := s.AdminToken()
+
+	created := servertest.PostJSON[handles.ClientAppSecretResp](s, "/api/admin/client_app/create", admin, model.ClientApp{
+		Name:     "portal",
+		Scopes:   model.ScopeFsRead,
+		Users:    user.Username,
+		TokenTTL: 600,
+	})
+	if created.Code != 200 || created.Data.ClientSecret == "" {
+		t.Fatalf("failed create client app: %s", created.Message)
+	}
+	app := created.Data
+	t.Cleanup(func() {
+		servertest.PostJSON[any](s, "/api/admin/client_app/delete?id="+strconv.Itoa(int(app.ID)), admin, nil)
+	})
+
+	exchange := func(form url.Values) (int, map[string]any) {
+		t.Helper()
+		form.Set("grant_type", handles.GrantTypeTokenExchange)
+		req := s.NewRequest(http.MethodPost, "/api/auth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp := s.Do(req, "")
+		var body map[string]any
+		if err := utils.Json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("failed decode token response: %+v", err)
+		}
+		return resp.StatusCode, body
+	}
+
+	status, body := exchange(url.Values{"client_id": {app.ClientID}, "client_secret": {"wrong"}, "username": {user.Username}})
+	if status != 401 || body["error"] != "invalid_client" {
+		t.Errorf("expected invalid client, got %d %v", status, body)
+	}
+	for _, username := range []string{"admin", "other_user", "missing_user"} {
+		status, body = exchange(url.Values{"client_id": {app.ClientID}, "client_secret": {app.ClientSecret}, "username": {username}})
+		if status != 400 || body["error"] != "invalid_grant" {
+			t.Errorf("expected invalid grant for %s, got %d %v", username, status, body)
+		}
+	}
+	status, body = exchange(url.Values{"client_id": {app.ClientID}, "client_secret": {app.ClientSecret}, "username": {user.Username}, "scope": {"fs:write"}})
+	if status != 400 || body["error"] != "invalid_scope" {
+		t.Errorf("expected invalid scope, got %d %v", status, body)
+	}
+	status, body = exchange(url.Values{"client_id": {app.ClientID}, "client_secret": {app.ClientSecret}, "username": {user.Username}})
+	if status != 200 || body["scope"] != model.ScopeFsRead || body["expires_in"] != 600.0 {
+		t.Fatalf("failed exchange token: %d %v", status, body)
+	}
+	token := "Bearer " + body["access_token"].(string)
+
+	if res := servertest.PostJSON[handles.FsListResp](s, "/api/fs/list", token, handles.ListReq{Path: "/embed"}); res.Code != 200 {
+		t.Errorf("expected list to be allowed: %s", res.Message)
+	}
+	if res := servertest.GetJSON[handles.UserResp](s, "/api/me", token); res.Code != 200 || res.Data.Username != user.Username {
+		t.Errorf("expected me to be allowed: %s", res.Message)
+	}
+	if res := servertest.PostJSON[any](s, "/api/fs/mkdir", token, handles.MkdirOrLinkReq{Path: "/embed/new"}); res.Code != 403 {
+		t.Errorf("expected mkdir out of scope to be denied, got %d", res.Code)
+	}
+	if res := servertest.PostJSON[any](s, "/api/me/update", token, model.User{Username: "renamed"}); res.Code != 403 {
+		t.Errorf("expected me update out of scope to be denied, got %d", res.Code)
+	}
+
+	// disabling the app revokes its tokens at once
+	app.Disabled = true
+	if res := servertest.PostJSON[any](s, "/api/admin/client_app/update", admin, app.ClientApp); res.Code != 200 {
+		t.Fatalf("failed disable client app: %s", res.Message)
+	}
+	if res := servertest.PostJSON[handles.FsListResp](s, "/api/fs/list", token, handles.ListReq{Path: "/embed"}); res.Code == 200 {
+		t.Errorf("expected token of a disabled app to be rejected")
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"net/url"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 )
 
 type FsGetDirectUploadInfoReq struct {
@@ -46,6 +48,10 @@ func FsGetDirectUploadInfo(c *gin.Context) {
 		}
 	}
 	directUploadInfo, err := fs.GetDirectUploadInfo(c, req.Tool, path, req.FileName, req.FileSize)
+	if errors.Is(err, errs.UploadRejected) {
+		common.ErrorResp(c, err, 403)
+		return
+	}
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
@@ -26,8 +26,8 @@ func Down(c *gin.Context) {
 	rawPath := c.Request.Context().Value(conf.PathKey).(string)
 	filename := stdpath.Base(rawPath)
 	
-	// 记录媒体文件访问日志（自动检测类型：下载或播放器）
-	common.LogMediaAccessAuto(c, rawPath)
+	// 触发访问事件，由 hook 记录媒体文件访问日志（自动检测类型：下载或播放器）
+	common.EmitAccessEventAuto(c, rawPath)
 	
 	storage, err := fs.GetStorage(rawPath, &fs.GetStoragesArgs{})
 	if err != nil {
@@ -38,6 +38,7 @@ func Down(c *gin.Context) {
 		Proxy(c)
 		return
 	} else {
+		setPrefetchLinks(c, rawPath)
 		link, _, err := fs.Link(c.Request.Context(), rawPath, model.LinkArgs{
 			IP:       c.ClientIP(),
 			Header:   c.Request.Header,
@@ -56,8 +57,9 @@ func Proxy(c *gin.Context) {
 	rawPath := c.Request.Context().Value(conf.PathKey).(string)
 	filename := stdpath.Base(rawPath)
 	
-	// 记录媒体文件访问日志（自动检测类型：预览或播放器）
-	common.LogMediaAccessAuto(c, rawPath)
+	// 触发访问事件，由 hook 记录媒体文件访问日志（自动检测类型：预览或播放器）
+	common.EmitAccessEventAuto(c, rawPath)
+	setPrefetchLinks(c, rawPath)
 	
 	storage, err := fs.GetStorage(rawPath, &fs.GetStoragesArgs{})
 	if err != nil {
@@ -178,7 +180,7 @@ func canProxy(storage driver.Driver, filename string) bool {
 	if utils.SliceContains(conf.SlicesMap[conf.ProxyTypes], utils.Ext(filename)) {
 		return true
 	}
-	if utils.SliceContains(conf.SlicesMap[conf.TextTypes], utils.Ext(filename)) {
+	if utils.GetFileType(filename) == conf.TEXT {
 		return true
 	}
 	return false
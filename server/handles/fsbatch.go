@@ -20,6 +20,7 @@ type RecursiveMoveReq struct {
 	SrcDir         string `json:"src_dir"`
 	DstDir         string `json:"dst_dir"`
 	ConflictPolicy string `json:"conflict_policy"`
+	DryRun         bool   `json:"dry_run"`
 }
 
 func FsRecursiveMove(c *gin.Context) {
@@ -44,6 +45,10 @@ func FsRecursiveMove(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.CanAccessRestricted(user, srcDir, dstDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	meta, err := op.GetNearestMeta(srcDir)
 	if err != nil {
@@ -77,6 +82,7 @@ func FsRecursiveMove(c *gin.Context) {
 	filePathMap := make(map[model.Obj]string)
 	movingFiles := generic.NewQueue[model.Obj]()
 	movingFileNames := make([]string, 0, len(rootFiles))
+	var skippedFileNames []string
 	for _, file := range rootFiles {
 		movingFiles.Push(file)
 		filePathMap[file] = srcDir
@@ -110,6 +116,7 @@ func FsRecursiveMove(c *gin.Context) {
 					common.ErrorStrResp(c, fmt.Sprintf("file [%s] exists", movingFile.GetName()), 403)
 					return
 				} else if req.ConflictPolicy == SKIP {
+					skippedFileNames = append(skippedFileNames, movingFileName)
 					continue
 				}
 			} else if req.ConflictPolicy != OVERWRITE {
@@ -121,6 +128,20 @@ func FsRecursiveMove(c *gin.Context) {
 
 	}
 
+	if isDryRun(c, req.DryRun) {
+		resp := newDryRunResp(skippedFileNames)
+		for _, fileName := range movingFileNames {
+			change, err := fs.DryRunMove(c.Request.Context(), fileName, dstDir)
+			if err != nil {
+				common.ErrorResp(c, err, 500)
+				return
+			}
+			resp.add(change)
+		}
+		common.SuccessResp(c, resp)
+		return
+	}
+
 	var count = 0
 	for i, fileName := range movingFileNames {
 		// move
@@ -141,6 +162,7 @@ type BatchRenameReq struct {
 		SrcName string `json:"src_name"`
 		NewName string `json:"new_name"`
 	} `json:"rename_objects"`
+	DryRun bool `json:"dry_run"`
 }
 
 func FsBatchRename(c *gin.Context) {
@@ -160,6 +182,10 @@ func FsBatchRename(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.CanAccessRestricted(user, reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	meta, err := op.GetNearestMeta(reqPath)
 	if err != nil {
@@ -169,6 +195,8 @@ func FsBatchRename(c *gin.Context) {
 		}
 	}
 	common.GinWithValue(c, conf.MetaKey, meta)
+	dryRun := isDryRun(c, req.DryRun)
+	resp := newDryRunResp(nil)
 	for _, renameObject := range req.RenameObjects {
 		if renameObject.SrcName == "" || renameObject.NewName == "" {
 			continue
@@ -179,11 +207,24 @@ func FsBatchRename(c *gin.Context) {
 			return
 		}
 		filePath := fmt.Sprintf("%s/%s", reqPath, renameObject.SrcName)
+		if dryRun {
+			change, err := fs.DryRunRename(c.Request.Context(), filePath, renameObject.NewName)
+			if err != nil {
+				common.ErrorResp(c, err, 500)
+				return
+			}
+			resp.add(change)
+			continue
+		}
 		if err := fs.Rename(c.Request.Context(), filePath, renameObject.NewName); err != nil {
 			common.ErrorResp(c, err, 500)
 			return
 		}
 	}
+	if dryRun {
+		common.SuccessResp(c, resp)
+		return
+	}
 	common.SuccessResp(c)
 }
 
@@ -191,6 +232,7 @@ type RegexRenameReq struct {
 	SrcDir       string `json:"src_dir"`
 	SrcNameRegex string `json:"src_name_regex"`
 	NewNameRegex string `json:"new_name_regex"`
+	DryRun       bool   `json:"dry_run"`
 }
 
 func FsRegexRename(c *gin.Context) {
@@ -210,6 +252,10 @@ func FsRegexRename(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.CanAccessRestricted(user, reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	meta, err := op.GetNearestMeta(reqPath)
 	if err != nil {
@@ -232,6 +278,8 @@ func FsRegexRename(c *gin.Context) {
 		return
 	}
 
+	dryRun := isDryRun(c, req.DryRun)
+	resp := newDryRunResp(nil)
 	for _, file := range files {
 		if srcRegexp.MatchString(file.GetName()) {
 			newFileName := srcRegexp.ReplaceAllString(file.GetName(), req.NewNameRegex)
@@ -241,6 +289,15 @@ func FsRegexRename(c *gin.Context) {
 				return
 			}
 			filePath := fmt.Sprintf("%s/%s", reqPath, file.GetName())
+			if dryRun {
+				change, err := fs.DryRunRename(c.Request.Context(), filePath, newFileName)
+				if err != nil {
+					common.ErrorResp(c, err, 500)
+					return
+				}
+				resp.add(change)
+				continue
+			}
 			if err := fs.Rename(c.Request.Context(), filePath, newFileName); err != nil {
 				common.ErrorResp(c, err, 500)
 				return
@@ -249,5 +306,9 @@ func FsRegexRename(c *gin.Context) {
 
 	}
 
+	if dryRun {
+		common.SuccessResp(c, resp)
+		return
+	}
 	common.SuccessResp(c)
 }
@@ -0,0 +1,56 @@
+package handles
+
+import (
+	"slices"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/gin-gonic/gin"
+)
+
+// DryRunResp is the response of a move, copy, remove, remove of the empty folders or batch
+// operation with dry_run set, nothing is changed then
+type DryRunResp struct {
+	Changes []fs.DryRunChange `json:"changes"`
+	// Skipped are the names left out because they exist at the destination
+	Skipped []string `json:"skipped,omitempty"`
+	Files   int      `json:"files"`
+	Size    int64    `json:"size"`
+	// TransferFiles and TransferSize estimate the data streamed through OpenList
+	TransferFiles int   `json:"transfer_files"`
+	TransferSize  int64 `json:"transfer_size"`
+	// Partial is set if some folders couldn't be listed, the counts are too low then
+	Partial bool `json:"partial,omitempty"`
+}
+
+func newDryRunResp(skipped []string) *DryRunResp {
+	return &DryRunResp{Changes: []fs.DryRunChange{}, Skipped: skipped}
+}
+
+func (r *DryRunResp) add(change *fs.DryRunChange) {
+	if change == nil {
+		return
+	}
+	r.Changes = append(r.Changes, *change)
+	r.Files += change.Files
+	r.Size += change.Size
+	r.Partial = r.Partial || change.Partial
+	if change.Transfer {
+		r.TransferFiles += change.Files
+		r.TransferSize += change.Size
+	}
+}
+
+// isDryRun reports whether the dry_run flag is set in the body or in the query
+func isDryRun(c *gin.Context, dryRun bool) bool {
+	return dryRun || c.Query("dry_run") == "true"
+}
+
+func skippedNames(names, validNames []string) []string {
+	var skipped []string
+	for _, name := range names {
+		if !slices.Contains(validNames, name) {
+			skipped = append(skipped, name)
+		}
+	}
+	return skipped
+}
@@ -0,0 +1,99 @@
+package handles_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/handles"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+)
+
+func TestDryRun(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/dry_a", mock.Addition{Seed: 10, Depth: 2, NumFolder: 1, NumFile: 2, FileSize: 1, Extensions: "txt"})
+	s.Mount("/dry_b", mock.Addition{Seed: 11, Depth: 1, NumFile: 1, FileSize: 1, Extensions: "txt"})
+	admin := s.AdminToken()
+
+	res := servertest.PostJSON[handles.DryRunResp](s, "/api/fs/remove", admin, handles.RemoveReq{Dir: "/dry_a", Names: []string{"folder_0", "missing"}, DryRun: true})
+	if res.Code != 200 {
+		t.Fatalf("failed dry run remove: %s", res.Message)
+	}
+	if len(res.Data.Changes) != 1 || res.Data.Files != 2 || res.Data.Size != 2 || res.Data.TransferSize != 0 {
+		t.Errorf("unexpected dry run of remove: %+v", res.Data)
+	}
+
+	res = servertest.PostJSON[handles.DryRunResp](s, "/api/fs/move?dry_run=true", admin, handles.MoveCopyReq{SrcDir: "/dry_a", DstDir: "/dry_b", Names: []string{"file_0.txt", "file_1.txt"}, SkipExisting: true})
+	if res.Code != 200 {
+		t.Fatalf("failed dry run move: %s", res.Message)
+	}
+	if len(res.Data.Skipped) != 1 || res.Data.Skipped[0] != "file_0.txt" {
+		t.Errorf("expected file_0.txt to be skipped, got %v", res.Data.Skipped)
+	}
+	if len(res.Data.Changes) != 1 || !res.Data.Changes[0].Transfer || res.Data.TransferFiles != 1 {
+		t.Errorf("expected a move between storages to be a transfer: %+v", res.Data)
+	}
+
+	res = servertest.PostJSON[handles.DryRunResp](s, "/api/fs/regex_rename", admin, handles.RegexRenameReq{SrcDir: "/dry_a", SrcNameRegex: `^file_(\d)\.txt$`, NewNameRegex: "doc_$1.txt", DryRun: true})
+	if res.Code != 200 || len(res.Data.Changes) != 2 {
+		t.Fatalf("unexpected dry run of regex rename: %d %s %+v", res.Code, res.Message, res.Data)
+	}
+
+	// only the folders holding no files would be removed
+	if res := servertest.PostJSON[any](s, "/api/fs/mkdir", admin, handles.MkdirOrLinkReq{Path: "/dry_a/empty/inner"}); res.Code != 200 {
+		t.Fatalf("failed mkdir: %s", res.Message)
+	}
+	res = servertest.PostJSON[handles.DryRunResp](s, "/api/fs/remove_empty_directory", admin, handles.RemoveEmptyDirectoryReq{SrcDir: "/dry_a", DryRun: true})
+	if res.Code != 200 {
+		t.Fatalf("failed dry run remove empty directory: %s", res.Message)
+	}
+	var removed []string
+	for _, change := range res.Data.Changes {
+		removed = append(removed, change.SrcPath)
+	}
+	if strings.Join(removed, ",") != "/dry_a/empty/inner,/dry_a/empty" {
+		t.Errorf("expected the empty folders to be removed, got %v", removed)
+	}
+
+	list := servertest.PostJSON[handles.FsListResp](s, "/api/fs/list", admin, handles.ListReq{Path: "/dry_a"})
+	names := make(map[string]bool)
+	for _, obj := range list.Data.Content {
+		names[obj.Name] = true
+	}
+	if !names["folder_0"] || !names["file_0.txt"] || !names["file_1.txt"] || !names["empty"] {
+		t.Errorf("expected the dry runs to change nothing, got %v", names)
+	}
+}
+
+func TestDryRunPartial(t *testing.T) {
+	s := servertest.New(t)
+	s.MountStorage(model.Storage{MountPath: "/dry_c", CacheExpiration: 30}, mock.Addition{Seed: 12, Depth: 3, NumFolder: 1, NumFile: 2, FileSize: 1, Extensions: "txt"})
+	admin := s.AdminToken()
+	for _, path := range []string{"/dry_c", "/dry_c/folder_0"} {
+		if res := servertest.PostJSON[handles.FsListResp](s, "/api/fs/list", admin, handles.ListReq{Path: path}); res.Code != 200 {
+			t.Fatalf("failed list %s: %s", path, res.Message)
+		}
+	}
+	// the listings that aren't cached yet fail from now on
+	dev := flags.Dev
+	flags.Dev = true
+	t.Cleanup(func() {
+		_ = op.SetChaosRules(nil)
+		flags.Dev = dev
+	})
+	if err := op.SetChaosRules([]op.ChaosRule{{MountPath: "/dry_c", Ops: []string{op.ChaosList}, Probability: 1, Error: "down"}}); err != nil {
+		t.Fatalf("failed set chaos rules: %+v", err)
+	}
+
+	// the folder that can't be listed is left out of the counts instead of failing the dry run
+	res := servertest.PostJSON[handles.DryRunResp](s, "/api/fs/remove", admin, handles.RemoveReq{Dir: "/dry_c", Names: []string{"folder_0"}, DryRun: true})
+	if res.Code != 200 {
+		t.Fatalf("failed dry run remove: %s", res.Message)
+	}
+	if !res.Data.Partial || len(res.Data.Changes) != 1 || !res.Data.Changes[0].Partial || res.Data.Files != 2 {
+		t.Errorf("expected a partial count of the listed files, got %+v", res.Data)
+	}
+}
@@ -0,0 +1,210 @@
+package handles
+
+import (
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/inspect"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/OpenListTeam/go-cache"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	// listCursorExpire is how long a client may wait before asking for the next part of a listing
+	listCursorExpire = 5 * time.Minute
+	// listStreamFlushEvery is the number of entries written between two flushes
+	listStreamFlushEvery = 200
+)
+
+type ListStreamReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+	Refresh  bool   `json:"refresh" form:"refresh"`
+	// Cursor continues a listing from the end line of the previous response, path and password are ignored then
+	Cursor string `json:"cursor" form:"cursor"`
+	// Limit is the max number of entries of the response, 0 streams the rest of the directory
+	Limit int `json:"limit" form:"limit"`
+}
+
+type FsListStreamHeader struct {
+	Total             int64    `json:"total"`
+	Readme            string   `json:"readme"`
+	Header            string   `json:"header"`
+	Write             bool     `json:"write"`
+	Provider          string   `json:"provider"`
+	DirectUploadTools []string `json:"direct_upload_tools,omitempty"`
+}
+
+type FsListStreamEnd struct {
+	// Count is the number of entries of this response
+	Count int `json:"count"`
+	// Cursor is set if entries are left, pass it to get them
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// FsListStreamLine is a line of the NDJSON response, exactly one field is set.
+// The header comes first, then an obj line per entry and the end line last.
+type FsListStreamLine struct {
+	Header *FsListStreamHeader `json:"header,omitempty"`
+	Obj    *ObjResp            `json:"obj,omitempty"`
+	End    *FsListStreamEnd    `json:"end,omitempty"`
+}
+
+// listCursor keeps the snapshot of a listing between the responses,
+// so the parts stay consistent even if the directory cache is refreshed meanwhile
+type listCursor struct {
+	userID uint
+	path   string
+	header FsListStreamHeader
+	objs   []model.Obj
+	offset int
+}
+
+var listCursorCache = cache.NewMemCache(cache.WithShards[*listCursor](16))
+
+// FsListStream lists a directory as NDJSON, entries are flushed while they are
+// written so huge directories render progressively
+func FsListStream(c *gin.Context) {
+	var req ListStreamReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Limit < 0 {
+		common.ErrorStrResp(c, "limit must not be negative", 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsGuest() && (user.Disabled || common.IsKillSwitchOn()) {
+		common.ErrorStrResp(c, "Guest user is disabled, login please", 401)
+		return
+	}
+	var cur *listCursor
+	if req.Cursor != "" {
+		var ok bool
+		cur, ok = listCursorCache.Get(req.Cursor)
+		if !ok || cur.userID != user.ID {
+			common.ErrorStrResp(c, "cursor is invalid or expired", 400)
+			return
+		}
+		// a cursor is used once, the response carries the next one
+		listCursorCache.Del(req.Cursor)
+	} else {
+		if strings.HasPrefix(req.Path, "/@s") {
+			common.ErrorStrResp(c, "streaming listing of sharings is not supported", 400)
+			return
+		}
+		var ok bool
+		if cur, ok = newListCursor(c, &req, user); !ok {
+			return
+		}
+	}
+	streamList(c, cur, req.Limit, user)
+}
+
+func newListCursor(c *gin.Context, req *ListStreamReq, user *model.User) (*listCursor, bool) {
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return nil, false
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return nil, false
+		}
+	}
+	common.GinWithValue(c, conf.MetaKey, meta)
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return nil, false
+	}
+	if !user.CanWrite() && !common.CanWrite(meta, reqPath) && req.Refresh {
+		common.ErrorStrResp(c, "Refresh without permission", 403)
+		return nil, false
+	}
+	objs, err := fs.List(c.Request.Context(), reqPath, &fs.ListArgs{
+		Refresh:            req.Refresh,
+		WithStorageDetails: !user.IsGuest() && !setting.GetBool(conf.HideStorageDetails),
+	})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return nil, false
+	}
+	var directUploadTools []string
+	// the uploads must go through the server while they are inspected
+	if user.CanWrite() && !inspect.Enabled() {
+		if storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{}); err == nil {
+			directUploadTools = op.GetDirectUploadTools(storage)
+		}
+	}
+	return &listCursor{
+		userID: user.ID,
+		path:   reqPath,
+		header: FsListStreamHeader{
+			Total:             int64(len(objs)),
+			Readme:            getReadme(meta, reqPath),
+			Header:            getHeader(meta, reqPath),
+			Write:             user.CanWrite() || common.CanWrite(meta, reqPath),
+			Provider:          "unknown",
+			DirectUploadTools: directUploadTools,
+		},
+		objs: objs,
+	}, true
+}
+
+func streamList(c *gin.Context, cur *listCursor, limit int, user *model.User) {
+	c.Header("Content-Type", "application/x-ndjson; charset=utf-8")
+	c.Header("Cache-Control", "no-cache")
+	// ask nginx not to buffer the response, it would defeat the streaming
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(200)
+	enc := utils.Json.NewEncoder(c.Writer)
+	ctx := c.Request.Context()
+	write := func(line FsListStreamLine) bool {
+		return enc.Encode(line) == nil
+	}
+	if !write(FsListStreamLine{Header: &cur.header}) {
+		return
+	}
+	c.Writer.Flush()
+	end := len(cur.objs)
+	if limit > 0 && cur.offset+limit < end {
+		end = cur.offset + limit
+	}
+	count := 0
+	for ; cur.offset < end; cur.offset++ {
+		if ctx.Err() != nil {
+			// the client is gone
+			return
+		}
+		obj := cur.objs[cur.offset]
+		resp := toObjRespWithUser(ctx, obj, cur.path, user.Username)
+		if !write(FsListStreamLine{Obj: &resp}) {
+			return
+		}
+		common.EmitEntryEvent(c, cur.path, obj)
+		count++
+		if count%listStreamFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+	}
+	last := &FsListStreamEnd{Count: count}
+	if cur.offset < len(cur.objs) {
+		last.Cursor = random.String(32)
+		listCursorCache.Set(last.Cursor, cur, cache.WithEx[*listCursor](listCursorExpire))
+	}
+	write(FsListStreamLine{End: last})
+	c.Writer.Flush()
+}
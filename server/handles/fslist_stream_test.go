@@ -0,0 +1,74 @@
+package handles_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/handles"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+)
+
+func TestFsListStream(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/stream", mock.Addition{Seed: 5, Depth: 1, NumFile: 5, FileSize: 16, Extensions: "txt"})
+	admin := s.AdminToken()
+
+	read := func(req handles.ListStreamReq) []handles.FsListStreamLine {
+		t.Helper()
+		body, _ := utils.Json.Marshal(req)
+		r := s.NewRequest(http.MethodPost, "/api/fs/list/stream", bytes.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		resp := s.Do(r, admin)
+		if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/x-ndjson") {
+			t.Fatalf("unexpected content type %s: %s", ct, s.ReadBody(resp))
+		}
+		var lines []handles.FsListStreamLine
+		dec := utils.Json.NewDecoder(resp.Body)
+		for dec.More() {
+			var line handles.FsListStreamLine
+			if err := dec.Decode(&line); err != nil {
+				t.Fatalf("failed decode line: %+v", err)
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	}
+
+	var names []string
+	req := handles.ListStreamReq{Path: "/stream", Limit: 2}
+	for parts := 0; ; parts++ {
+		if parts > 5 {
+			t.Fatalf("listing doesn't end")
+		}
+		lines := read(req)
+		if len(lines) < 2 || lines[0].Header == nil || lines[len(lines)-1].End == nil {
+			t.Fatalf("unexpected lines: %+v", lines)
+		}
+		if lines[0].Header.Total != 5 {
+			t.Errorf("expected total 5, got %d", lines[0].Header.Total)
+		}
+		for _, line := range lines[1 : len(lines)-1] {
+			names = append(names, line.Obj.Name)
+		}
+		end := lines[len(lines)-1].End
+		if end.Count != len(lines)-2 {
+			t.Errorf("expected count %d, got %d", len(lines)-2, end.Count)
+		}
+		if end.Cursor == "" {
+			break
+		}
+		req = handles.ListStreamReq{Cursor: end.Cursor, Limit: 2}
+	}
+	if len(names) != 5 {
+		t.Errorf("expected 5 entries, got %v", names)
+	}
+
+	// cursors are used once
+	if res := servertest.PostJSON[any](s, "/api/fs/list/stream", admin, req); res.Code != 400 {
+		t.Errorf("expected a used cursor to be rejected, got %d", res.Code)
+	}
+}
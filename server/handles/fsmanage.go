@@ -36,6 +36,10 @@ func FsMkdir(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.CanAccessRestricted(user, reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	if !user.CanWrite() {
 		meta, err := op.GetNearestMeta(stdpath.Dir(reqPath))
 		if err != nil {
@@ -63,6 +67,7 @@ type MoveCopyReq struct {
 	Overwrite    bool     `json:"overwrite"`
 	SkipExisting bool     `json:"skip_existing"`
 	Merge        bool     `json:"merge"`
+	DryRun       bool     `json:"dry_run"`
 }
 
 func FsMove(c *gin.Context) {
@@ -90,6 +95,10 @@ func FsMove(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.CanAccessRestricted(user, append(joinNames(srcDir, req.Names), dstDir)...) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	var validNames []string
 	if !req.Overwrite {
@@ -105,6 +114,20 @@ func FsMove(c *gin.Context) {
 		validNames = req.Names
 	}
 
+	if isDryRun(c, req.DryRun) {
+		resp := newDryRunResp(skippedNames(req.Names, validNames))
+		for _, name := range validNames {
+			change, err := fs.DryRunMove(c.Request.Context(), stdpath.Join(srcDir, name), dstDir)
+			if err != nil {
+				common.ErrorResp(c, err, 500)
+				return
+			}
+			resp.add(change)
+		}
+		common.SuccessResp(c, resp)
+		return
+	}
+
 	// Create all tasks immediately without any synchronous validation
 	// All validation will be done asynchronously in the background
 	var addedTasks []task.TaskExtensionInfo
@@ -157,6 +180,10 @@ func FsCopy(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.CanAccessRestricted(user, append(joinNames(srcDir, req.Names), dstDir)...) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	var validNames []string
 	if !req.Overwrite {
@@ -176,6 +203,25 @@ func FsCopy(c *gin.Context) {
 		validNames = req.Names
 	}
 
+	if isDryRun(c, req.DryRun) {
+		resp := newDryRunResp(skippedNames(req.Names, validNames))
+		for _, name := range validNames {
+			var change *fs.DryRunChange
+			if req.Merge {
+				change, err = fs.DryRunMerge(c.Request.Context(), stdpath.Join(srcDir, name), dstDir)
+			} else {
+				change, err = fs.DryRunCopy(c.Request.Context(), stdpath.Join(srcDir, name), dstDir)
+			}
+			if err != nil {
+				common.ErrorResp(c, err, 500)
+				return
+			}
+			resp.add(change)
+		}
+		common.SuccessResp(c, resp)
+		return
+	}
+
 	// Create all tasks immediately without any synchronous validation
 	// All validation will be done asynchronously in the background
 	var addedTasks []task.TaskExtensionInfo
@@ -233,6 +279,10 @@ func FsRename(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.CanAccessRestricted(user, reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	if !req.Overwrite {
 		dstPath := stdpath.Join(stdpath.Dir(reqPath), req.Name)
 		if dstPath != reqPath {
@@ -249,6 +299,15 @@ func FsRename(c *gin.Context) {
 	common.SuccessResp(c)
 }
 
+// joinNames returns the paths of the names in dir
+func joinNames(dir string, names []string) []string {
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		paths = append(paths, stdpath.Join(dir, name))
+	}
+	return paths
+}
+
 func checkRelativePath(path string) error {
 	if strings.ContainsAny(path, "/\\") || path == "" || path == "." || path == ".." {
 		return errs.RelativePath
@@ -257,8 +316,9 @@ func checkRelativePath(path string) error {
 }
 
 type RemoveReq struct {
-	Dir   string   `json:"dir"`
-	Names []string `json:"names"`
+	Dir    string   `json:"dir"`
+	Names  []string `json:"names"`
+	DryRun bool     `json:"dry_run"`
 }
 
 func FsRemove(c *gin.Context) {
@@ -281,6 +341,23 @@ func FsRemove(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.CanAccessRestricted(user, joinNames(reqDir, req.Names)...) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if isDryRun(c, req.DryRun) {
+		resp := newDryRunResp(nil)
+		for _, name := range req.Names {
+			change, err := fs.DryRunRemove(c.Request.Context(), stdpath.Join(reqDir, name))
+			if err != nil {
+				common.ErrorResp(c, err, 500)
+				return
+			}
+			resp.add(change)
+		}
+		common.SuccessResp(c, resp)
+		return
+	}
 	for _, name := range req.Names {
 		err := fs.Remove(c.Request.Context(), stdpath.Join(reqDir, name))
 		if err != nil {
@@ -294,6 +371,7 @@ func FsRemove(c *gin.Context) {
 
 type RemoveEmptyDirectoryReq struct {
 	SrcDir string `json:"src_dir"`
+	DryRun bool   `json:"dry_run"`
 }
 
 func FsRemoveEmptyDirectory(c *gin.Context) {
@@ -313,6 +391,10 @@ func FsRemoveEmptyDirectory(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.CanAccessRestricted(user, srcDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	meta, err := op.GetNearestMeta(srcDir)
 	if err != nil {
@@ -323,6 +405,20 @@ func FsRemoveEmptyDirectory(c *gin.Context) {
 	}
 	common.GinWithValue(c, conf.MetaKey, meta)
 
+	if isDryRun(c, req.DryRun) {
+		changes, err := fs.DryRunRemoveEmptyDirectories(c.Request.Context(), srcDir)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		resp := newDryRunResp(nil)
+		for i := range changes {
+			resp.add(&changes[i])
+		}
+		common.SuccessResp(c, resp)
+		return
+	}
+
 	rootFiles, err := fs.List(c.Request.Context(), srcDir, &fs.ListArgs{})
 	if err != nil {
 		common.ErrorResp(c, err, 500)
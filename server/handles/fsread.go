@@ -1,6 +1,7 @@
 package handles
 
 import (
+	"context"
 	"fmt"
 	stdpath "path"
 	"strings"
@@ -9,6 +10,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/inspect"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
@@ -39,11 +41,14 @@ type ObjResp struct {
 	Modified     time.Time                  `json:"modified"`
 	Created      time.Time                  `json:"created"`
 	Sign         string                     `json:"sign"`
+	SignUser     string                     `json:"sign_user,omitempty"`
 	Thumb        string                     `json:"thumb"`
 	Type         int                        `json:"type"`
 	HashInfoStr  string                     `json:"hashinfo"`
 	HashInfo     map[*utils.HashType]string `json:"hash_info"`
 	MountDetails *model.StorageDetails      `json:"mount_details,omitempty"`
+	// PurgeAt is set if the object is scheduled for deletion
+	PurgeAt *time.Time `json:"purge_at,omitempty"`
 }
 
 type FsListResp struct {
@@ -69,7 +74,7 @@ func FsListSplit(c *gin.Context) {
 		return
 	}
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if user.IsGuest() && user.Disabled {
+	if user.IsGuest() && (user.Disabled || common.IsKillSwitchOn()) {
 		common.ErrorStrResp(c, "Guest user is disabled, login please", 401)
 		return
 	}
@@ -109,13 +114,14 @@ func FsList(c *gin.Context, req *ListReq, user *model.User) {
 	total, objs := pagination(objs, &req.PageReq)
 	provider := "unknown"
 	var directUploadTools []string
-	if user.CanWrite() {
+	// the uploads must go through the server while they are inspected
+	if user.CanWrite() && !inspect.Enabled() {
 		if storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{}); err == nil {
 			directUploadTools = op.GetDirectUploadTools(storage)
 		}
 	}
 	common.SuccessResp(c, FsListResp{
-		Content:           toObjsRespWithUser(objs, reqPath, isEncrypt(meta, reqPath), user.Username),
+		Content:           toObjsRespWithUser(c.Request.Context(), objs, reqPath, isEncrypt(meta, reqPath), user.Username),
 		Total:             int64(total),
 		Readme:            getReadme(meta, reqPath),
 		Header:            getHeader(meta, reqPath),
@@ -243,38 +249,49 @@ func toObjsResp(objs []model.Obj, parent string, encrypt bool) []ObjResp {
 			Thumb:        thumb,
 			Type:         utils.GetObjType(obj.GetName(), obj.IsDir()),
 			MountDetails: mountDetails,
+			PurgeAt:      purgeAtOf(stdpath.Join(parent, obj.GetName())),
 		})
 	}
 	return resp
 }
 
-func toObjsRespWithUser(objs []model.Obj, parent string, encrypt bool, username string) []ObjResp {
+func purgeAtOf(path string) *time.Time {
+	if d, ok := op.GetScheduledDeletion(path); ok {
+		return &d.PurgeAt
+	}
+	return nil
+}
+
+func toObjsRespWithUser(ctx context.Context, objs []model.Obj, parent string, encrypt bool, username string) []ObjResp {
 	var resp []ObjResp
 	for _, obj := range objs {
-		thumb, _ := model.GetThumb(obj)
-		mountDetails, _ := model.GetStorageDetails(obj)
-		// 始终生成包含用户名的签名
-		fileSign := common.SignWithUserAlways(obj, parent, username)
-		if fileSign != "" {
-			fileSign = fileSign + ":user:" + username
-		}
-		resp = append(resp, ObjResp{
-			Name:         obj.GetName(),
-			Size:         obj.GetSize(),
-			IsDir:        obj.IsDir(),
-			Modified:     obj.ModTime(),
-			Created:      obj.CreateTime(),
-			HashInfoStr:  obj.GetHash().String(),
-			HashInfo:     obj.GetHash().Export(),
-			Sign:         fileSign,
-			Thumb:        thumb,
-			Type:         utils.GetObjType(obj.GetName(), obj.IsDir()),
-			MountDetails: mountDetails,
-		})
+		resp = append(resp, toObjRespWithUser(ctx, obj, parent, username))
 	}
 	return resp
 }
 
+func toObjRespWithUser(ctx context.Context, obj model.Obj, parent string, username string) ObjResp {
+	thumb, _ := model.GetThumb(obj)
+	mountDetails, _ := model.GetStorageDetails(obj)
+	// 始终生成包含用户名的签名
+	fileSign, signUser := common.UserSign(ctx, common.SignWithUserAlways(obj, parent, username), username)
+	return ObjResp{
+		Name:         obj.GetName(),
+		Size:         obj.GetSize(),
+		IsDir:        obj.IsDir(),
+		Modified:     obj.ModTime(),
+		Created:      obj.CreateTime(),
+		HashInfoStr:  obj.GetHash().String(),
+		HashInfo:     obj.GetHash().Export(),
+		Sign:         fileSign,
+		SignUser:     signUser,
+		Thumb:        thumb,
+		Type:         utils.GetObjType(obj.GetName(), obj.IsDir()),
+		MountDetails: mountDetails,
+		PurgeAt:      purgeAtOf(stdpath.Join(parent, obj.GetName())),
+	}
+}
+
 type FsGetReq struct {
 	Path     string `json:"path" form:"path"`
 	Password string `json:"password" form:"password"`
@@ -301,7 +318,7 @@ func FsGetSplit(c *gin.Context) {
 		return
 	}
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if user.IsGuest() && user.Disabled {
+	if user.IsGuest() && (user.Disabled || common.IsKillSwitchOn()) {
 		common.ErrorStrResp(c, "Guest user is disabled, login please", 401)
 		return
 	}
@@ -315,8 +332,8 @@ func FsGet(c *gin.Context, req *FsGetReq, user *model.User) {
 		return
 	}
 	
-	// 记录媒体文件访问日志（当获取文件信息时）
-	common.LogMediaAccess(c, reqPath)
+	// 触发访问事件，由 hook 记录媒体文件访问日志（当获取文件信息时）
+	common.EmitAccessEvent(c, reqPath, common.AccessTypePreview)
 	
 	meta, err := op.GetNearestMeta(reqPath)
 	if err != nil {
@@ -351,7 +368,7 @@ func FsGet(c *gin.Context, req *FsGetReq, user *model.User) {
 		}
 		// 始终使用包含用户名的签名（用于用户识别）
 		userSign := sign.SignWithUser(reqPath, user.Username)
-		signQuery := "?sign=" + userSign + ":user:" + user.Username
+		signQuery := common.UserSignQuery(c.Request.Context(), userSign, user.Username)
 		
 		if storage.Config().MustProxy() || storage.GetStorage().WebProxy {
 			rawURL = common.GenerateDownProxyURL(storage.GetStorage(), reqPath)
@@ -380,11 +397,8 @@ func FsGet(c *gin.Context, req *FsGetReq, user *model.User) {
 	mountDetails, _ := model.GetStorageDetails(obj)
 	
 	// 始终生成包含用户名的签名（用于用户识别）
-	fileSign := common.SignWithUserAlways(obj, parentPath, user.Username)
-	// 在签名后附加用户名参数
-	if fileSign != "" {
-		fileSign = fileSign + ":user:" + user.Username
-	}
+	// v1 在签名后附加用户名参数，v2 单独返回用户名
+	fileSign, signUser := common.UserSign(c.Request.Context(), common.SignWithUserAlways(obj, parentPath, user.Username), user.Username)
 	
 	common.SuccessResp(c, FsGetResp{
 		ObjResp: ObjResp{
@@ -396,15 +410,17 @@ func FsGet(c *gin.Context, req *FsGetReq, user *model.User) {
 			HashInfoStr:  obj.GetHash().String(),
 			HashInfo:     obj.GetHash().Export(),
 			Sign:         fileSign,
+			SignUser:     signUser,
 			Type:         utils.GetFileType(obj.GetName()),
 			Thumb:        thumb,
 			MountDetails: mountDetails,
+			PurgeAt:      purgeAtOf(reqPath),
 		},
 		RawURL:   rawURL,
 		Readme:   getReadme(meta, reqPath),
 		Header:   getHeader(meta, reqPath),
 		Provider: provider,
-		Related:  toObjsRespWithUser(related, parentPath, isEncrypt(parentMeta, parentPath), user.Username),
+		Related:  toObjsRespWithUser(c.Request.Context(), related, parentPath, isEncrypt(parentMeta, parentPath), user.Username),
 	})
 }
 
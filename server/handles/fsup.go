@@ -17,6 +17,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 )
 
 func getLastModified(c *gin.Context) time.Time {
@@ -114,6 +115,10 @@ func FsStream(c *gin.Context) {
 	} else {
 		err = fs.PutDirectly(c.Request.Context(), dir, s)
 	}
+	if errors.Is(err, errs.UploadRejected) {
+		common.ErrorResp(c, err, 403)
+		return
+	}
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
@@ -214,6 +219,10 @@ func FsForm(c *gin.Context) {
 	} else {
 		err = fs.PutDirectly(c.Request.Context(), dir, s)
 	}
+	if errors.Is(err, errs.UploadRejected) {
+		common.ErrorResp(c, err, 403)
+		return
+	}
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
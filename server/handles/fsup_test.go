@@ -0,0 +1,55 @@
+package handles_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/inspect"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+)
+
+func TestUploadInspection(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/inspect", mock.Addition{Seed: 12, Depth: 1, NumFile: 1, FileSize: 16, Extensions: "txt"})
+	admin := s.AdminToken()
+
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := new(bytes.Buffer)
+		_, _ = buf.ReadFrom(r.Body)
+		verdict := inspect.VerdictClean
+		if strings.Contains(buf.String(), "EICAR") {
+			verdict = inspect.VerdictMalicious
+		}
+		_ = utils.Json.NewEncoder(w).Encode(map[string]string{"verdict": verdict, "reason": "test"})
+	}))
+	defer hook.Close()
+	s.SetSetting(conf.UploadInspectors, `[{"name":"dlp","type":"webhook","url":"`+hook.URL+`"}]`)
+
+	upload := func(name, content string) int {
+		req := s.NewRequest(http.MethodPut, "/api/fs/put", strings.NewReader(content))
+		req.Header.Set("File-Path", "/inspect/"+name)
+		res := servertest.DecodeResp[any](s, s.Do(req, admin))
+		return res.Code
+	}
+	if code := upload("clean.txt", "hello"); code != 200 {
+		t.Errorf("expected a clean upload to pass, got %d", code)
+	}
+	if code := upload("virus.txt", "X5O EICAR test"); code != 403 {
+		t.Errorf("expected a malicious upload to be rejected, got %d", code)
+	}
+
+	// the storage would get direct uploads without the inspectors seeing them
+	req := s.NewRequest(http.MethodPost, "/api/fs/get_direct_upload_info",
+		strings.NewReader(`{"path":"/inspect","file_name":"direct.txt","file_size":5,"tool":"HttpDirect"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("File-Path", "/inspect/direct.txt")
+	if res := servertest.DecodeResp[any](s, s.Do(req, admin)); res.Code != 403 {
+		t.Errorf("expected direct uploads to be refused while inspecting, got %d", res.Code)
+	}
+}
@@ -0,0 +1,212 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+func ListGroups(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	groups, total, err := op.GetGroups(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: groups,
+		Total:   total,
+	})
+}
+
+func CreateGroup(c *gin.Context) {
+	var req model.Group
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.CreateGroup(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+func UpdateGroup(c *gin.Context) {
+	var req model.Group
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.UpdateGroup(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+func DeleteGroup(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteGroupById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+type GroupResp struct {
+	model.Group
+	Usage *model.GroupUsage `json:"usage"`
+}
+
+// ownGroup returns the group of the group owner of the request
+func ownGroup(c *gin.Context) (*model.Group, bool) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	group, err := op.GetGroupByOwnerId(user.ID)
+	if err != nil {
+		common.ErrorStrResp(c, "you don't own a group", 403)
+		return nil, false
+	}
+	return group, true
+}
+
+// ownMember returns the member of the group of the group owner of the request
+func ownMember(c *gin.Context, id uint) (*model.User, bool) {
+	owner := c.Request.Context().Value(conf.UserKey).(*model.User)
+	member, err := op.GetUserById(id)
+	if err != nil || !op.IsGroupMemberOf(owner, member) {
+		common.ErrorStrResp(c, "member not found", 404)
+		return nil, false
+	}
+	return member, true
+}
+
+func GetOwnGroup(c *gin.Context) {
+	group, ok := ownGroup(c)
+	if !ok {
+		return
+	}
+	usage, err := op.GetGroupUsage(group)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, GroupResp{Group: *group, Usage: usage})
+}
+
+func ListGroupMembers(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	group, ok := ownGroup(c)
+	if !ok {
+		return
+	}
+	users, total, err := op.GetGroupMembers(group, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: users,
+		Total:   total,
+	})
+}
+
+func CreateGroupMember(c *gin.Context) {
+	var req model.User
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	group, ok := ownGroup(c)
+	if !ok {
+		return
+	}
+	req.ID = 0
+	if err := op.CheckGroupMember(group, &req, true); err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	req.SetPassword(req.Password)
+	req.Password = ""
+	req.Authn = "[]"
+	if err := op.CreateUser(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	log.Infof("group owner of %s created member %s", group.Name, req.Username)
+	common.SuccessResp(c)
+}
+
+func UpdateGroupMember(c *gin.Context) {
+	var req model.User
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	group, ok := ownGroup(c)
+	if !ok {
+		return
+	}
+	member, ok := ownMember(c, req.ID)
+	if !ok {
+		return
+	}
+	if err := op.CheckGroupMember(group, &req, false); err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if req.Password == "" {
+		req.PwdHash = member.PwdHash
+		req.Salt = member.Salt
+		req.PwdTS = member.PwdTS
+	} else {
+		req.SetPassword(req.Password)
+		req.Password = ""
+	}
+	// only the admin manages these
+	req.OtpSecret = member.OtpSecret
+	req.Authn = member.Authn
+	req.SsoID = member.SsoID
+	req.AllowLdap = member.AllowLdap
+	if err := op.UpdateUser(&req); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func DeleteGroupMember(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	member, ok := ownMember(c, uint(id))
+	if !ok {
+		return
+	}
+	if err := op.DeleteUserById(member.ID); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,114 @@
+package handles_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/OpenListTeam/OpenList/v4/server/handles"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+)
+
+func TestGroupOwner(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/grp", mock.Addition{Seed: 7, Depth: 1, NumFile: 2, FileSize: 16, Extensions: "txt"})
+	const share = 1 << 14
+	owner := s.CreateUser(model.User{Username: "group_owner", Role: model.GROUPOWNER, BasePath: "/grp", Permission: share}, "password")
+	ownerToken := s.Token(owner)
+	other := s.CreateUser(model.User{Username: "group_outsider", Permission: share}, "password")
+
+	group := servertest.PostJSON[model.Group](s, "/api/admin/group/create", s.AdminToken(), model.Group{
+		Name:       "group",
+		OwnerID:    owner.ID,
+		BasePath:   "/grp",
+		Permission: share,
+		MaxMembers: 1,
+		MaxShares:  2,
+	})
+	if group.Code != 200 {
+		t.Fatalf("failed create group: %s", group.Message)
+	}
+	t.Cleanup(func() {
+		res := servertest.PostJSON[any](s, "/api/admin/group/delete?id="+strconv.Itoa(int(group.Data.ID)), s.AdminToken(), nil)
+		if res.Code != 200 {
+			t.Errorf("failed delete group: %s", res.Message)
+		}
+	})
+	if res := servertest.GetJSON[handles.GroupResp](s, "/api/group/get", s.Token(other)); res.Code != 403 {
+		t.Errorf("expected a general user to be rejected, got %d", res.Code)
+	}
+
+	for name, member := range map[string]model.User{
+		"base path":  {Username: "group_member", BasePath: "/", Permission: share, MaxShares: 2},
+		"permission": {Username: "group_member", BasePath: "/grp", Permission: share | 1<<3, MaxShares: 2},
+		"max shares": {Username: "group_member", BasePath: "/grp", Permission: share, MaxShares: 3},
+		"admin role": {Username: "group_member", BasePath: "/grp", Permission: share, MaxShares: 2, Role: model.ADMIN},
+	} {
+		if res := servertest.PostJSON[any](s, "/api/group/member/create", ownerToken, member); res.Code != 403 {
+			t.Errorf("%s: expected the member to exceed the allocation, got %d", name, res.Code)
+		}
+	}
+	member := model.User{Username: "group_member", Password: "password", BasePath: "/grp", Permission: share, MaxShares: 2}
+	if res := servertest.PostJSON[any](s, "/api/group/member/create", ownerToken, member); res.Code != 200 {
+		t.Fatalf("failed create member: %s", res.Message)
+	}
+	members := servertest.GetJSON[common.PageResp](s, "/api/group/member/list", ownerToken)
+	if members.Code != 200 || members.Data.Total != 2 {
+		t.Fatalf("expected the owner and the member, got %+v", members)
+	}
+	t.Cleanup(func() {
+		list := servertest.GetJSON[struct {
+			Content []model.User `json:"content"`
+		}](s, "/api/group/member/list", ownerToken)
+		for _, u := range list.Data.Content {
+			if u.ID != owner.ID {
+				servertest.PostJSON[any](s, "/api/group/member/delete?id="+strconv.Itoa(int(u.ID)), ownerToken, nil)
+			}
+		}
+	})
+	member.Username = "group_member_2"
+	if res := servertest.PostJSON[any](s, "/api/group/member/create", ownerToken, member); res.Code != 403 {
+		t.Errorf("expected the second member to exceed max members, got %d", res.Code)
+	}
+
+	login := servertest.PostJSON[map[string]string](s, "/api/auth/login", "", map[string]string{"username": "group_member", "password": "password"})
+	memberToken := login.Data["token"]
+	createShare := func(token string) common.Resp[handles.SharingResp] {
+		return servertest.PostJSON[handles.SharingResp](s, "/api/share/create", token, handles.UpdateSharingReq{Files: []string{"/grp/file_0.txt"}})
+	}
+	var shareID string
+	for i := 0; i < 2; i++ {
+		res := createShare(memberToken)
+		if res.Code != 200 {
+			t.Fatalf("failed create sharing: %s", res.Message)
+		}
+		shareID = res.Data.ID
+	}
+	if res := createShare(memberToken); res.Code != 403 {
+		t.Errorf("expected the member to exceed its share quota, got %d", res.Code)
+	}
+	if res := createShare(ownerToken); res.Code != 403 {
+		t.Errorf("expected the owner to exceed the share quota of the group, got %d", res.Code)
+	}
+	if res := servertest.GetJSON[common.PageResp](s, "/api/share/list", ownerToken); res.Data.Total != 2 {
+		t.Errorf("expected the owner to see the sharings of the members, got %d", res.Data.Total)
+	}
+	update := handles.UpdateSharingReq{ID: shareID, Files: []string{"/grp/file_1.txt"}, Remark: "by owner"}
+	if res := servertest.PostJSON[any](s, "/api/share/update", s.Token(other), update); res.Code != 404 {
+		t.Errorf("expected an outsider not to update the sharing, got %d", res.Code)
+	}
+	if res := servertest.PostJSON[handles.SharingResp](s, "/api/share/update", ownerToken, update); res.Code != 200 || res.Data.CreatorName != "group_member" || res.Data.Remark != "by owner" {
+		t.Errorf("expected the owner to update the sharing of a member, got %d %s %+v", res.Code, res.Message, res.Data)
+	}
+	if res := servertest.PostJSON[any](s, "/api/share/delete?id="+shareID, s.Token(other), nil); res.Code != 404 {
+		t.Errorf("expected an outsider not to delete the sharing, got %d", res.Code)
+	}
+	if res := servertest.PostJSON[any](s, "/api/share/delete?id="+shareID, ownerToken, nil); res.Code != 200 {
+		t.Errorf("failed delete the sharing of a member: %s", res.Message)
+	}
+	if res := createShare(ownerToken); res.Code != 200 {
+		t.Errorf("failed create sharing within the quota: %s", res.Message)
+	}
+}
@@ -0,0 +1,35 @@
+package handles
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type SetKillSwitchReq struct {
+	Enable bool `json:"enable"`
+	// Duration in seconds after which the kill switch turns itself off, 0 means never
+	Duration int64 `json:"duration"`
+}
+
+func GetKillSwitch(c *gin.Context) {
+	common.SuccessResp(c, common.GetKillSwitchStatus())
+}
+
+func SetKillSwitch(c *gin.Context) {
+	var req SetKillSwitchReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Duration < 0 {
+		common.ErrorStrResp(c, "duration must not be negative", 400)
+		return
+	}
+	if err := common.SetKillSwitch(req.Enable, time.Duration(req.Duration)*time.Second); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.GetKillSwitchStatus())
+}
@@ -0,0 +1,90 @@
+package handles_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/OpenListTeam/OpenList/v4/server/handles"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+)
+
+func TestKillSwitch(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/kill", mock.Addition{Seed: 17, Depth: 1, NumFile: 1, FileSize: 16, Extensions: "txt"})
+	s.SetSetting(conf.SignAll, "false")
+	s.UpdateUser("guest", func(u *model.User) {
+		u.Disabled = false
+	})
+	user := s.CreateUser(model.User{Username: "kill_user", Permission: 1 << 14}, "password")
+	userToken := s.Token(user)
+	share := servertest.PostJSON[handles.SharingResp](s, "/api/share/create", userToken, handles.UpdateSharingReq{Files: []string{"/kill/file_0.txt"}})
+	if share.Code != 200 {
+		t.Fatalf("failed create sharing: %s", share.Message)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteSharing(share.Data.ID)
+	})
+	path := "/kill/file_0.txt"
+
+	if err := common.SetKillSwitch(true, 0); err != nil {
+		t.Fatalf("failed set kill switch: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = common.SetKillSwitch(false, 0)
+	})
+	if res := servertest.GetJSON[any](s, "/api/me", ""); res.Code != 401 {
+		t.Errorf("expected code 401 for guest, got %d", res.Code)
+	}
+	if res := servertest.GetJSON[handles.UserResp](s, "/api/me", userToken); res.Code != 200 || res.Data.Username != user.Username {
+		t.Errorf("expected the user to keep working, got %d %s", res.Code, res.Message)
+	}
+	if res := servertest.PostJSON[handles.FsListResp](s, "/api/fs/list", userToken, handles.ListReq{Path: "/kill"}); res.Code != 200 {
+		t.Errorf("expected the user to keep listing, got %d %s", res.Code, res.Message)
+	}
+	if resp := s.Get("/sd/"+share.Data.ID, ""); resp.StatusCode != 403 {
+		t.Errorf("expected the public share to be blocked, got %d", resp.StatusCode)
+	}
+	if resp := s.Get("/d"+path, ""); resp.StatusCode != 401 {
+		t.Errorf("expected the unsigned download to be blocked, got %d", resp.StatusCode)
+	}
+	if resp := s.Get("/d"+path+"?sign="+sign.Sign(path), ""); resp.StatusCode != 200 {
+		t.Errorf("expected the signed download to pass, got %d", resp.StatusCode)
+	}
+	if resp := s.Get("/d"+path, userToken); resp.StatusCode != 200 {
+		t.Errorf("expected the download of the user to pass, got %d", resp.StatusCode)
+	}
+
+	// the kill switch turns itself off after the duration
+	if err := common.SetKillSwitch(true, time.Second); err != nil {
+		t.Fatalf("failed set kill switch: %+v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for setting.GetBool(conf.KillSwitch) && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if setting.GetBool(conf.KillSwitch) {
+		t.Fatal("expected the kill switch to be turned off by the timer")
+	}
+	if resp := s.Get("/sd/"+share.Data.ID, ""); resp.StatusCode == 403 {
+		t.Errorf("expected the public share to be open again, got %d", resp.StatusCode)
+	}
+
+	// setting the kill switch again cancels the timer of the earlier one
+	if err := common.SetKillSwitch(true, time.Second); err != nil {
+		t.Fatalf("failed set kill switch: %+v", err)
+	}
+	if err := common.SetKillSwitch(true, 0); err != nil {
+		t.Fatalf("failed set kill switch: %+v", err)
+	}
+	time.Sleep(1500 * time.Millisecond)
+	if !common.IsKillSwitchOn() {
+		t.Error("expected the kill switch without a duration to stay on")
+	}
+}
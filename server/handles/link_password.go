@@ -0,0 +1,188 @@
+package handles
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type SetLinkPasswordReq struct {
+	Path     string `json:"path" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	// MetaPassword is the password of the folder the file is in, if it has one
+	MetaPassword string `json:"meta_password"`
+}
+
+type SetLinkPasswordResp struct {
+	URL string `json:"url"`
+}
+
+// linkPasswordPath checks the user may protect the links of the path and returns the full path
+// with its current protection. The link is signed, it must not open files the user can't access
+// itself, and only the creator of a protection or the admin may change it.
+func linkPasswordPath(c *gin.Context, path, metaPassword string) (string, *model.LinkPassword, bool) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !user.CanShare() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return "", nil, false
+	}
+	reqPath, err := user.JoinPath(path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return "", nil, false
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return "", nil, false
+		}
+	}
+	if !common.CanAccess(user, meta, reqPath, metaPassword) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return "", nil, false
+	}
+	l, err := op.GetLinkPassword(reqPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return "", nil, false
+	}
+	if l != nil && !user.IsAdmin() && l.CreatorID != user.ID {
+		common.ErrorStrResp(c, "the link is protected by another user", 403)
+		return "", nil, false
+	}
+	return reqPath, l, true
+}
+
+// SetLinkPassword protects the direct link of a file with a password and returns the link
+func SetLinkPassword(c *gin.Context) {
+	var req SetLinkPasswordReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	reqPath, _, ok := linkPasswordPath(c, req.Path, req.MetaPassword)
+	if !ok {
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	obj, err := fs.Get(c.Request.Context(), reqPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	if obj.IsDir() {
+		common.ErrorStrResp(c, "only the link of a file can be protected", 400)
+		return
+	}
+	if err = op.SetLinkPassword(reqPath, req.Password, user.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, SetLinkPasswordResp{
+		URL: fmt.Sprintf("%s/d%s?sign=%s", common.GetApiUrl(c), utils.EncodePath(reqPath, true), sign.Sign(reqPath)),
+	})
+}
+
+type DeleteLinkPasswordReq struct {
+	Path string `json:"path" binding:"required"`
+	// MetaPassword is the password of the folder the file is in, if it has one
+	MetaPassword string `json:"meta_password"`
+}
+
+func DeleteLinkPassword(c *gin.Context) {
+	var req DeleteLinkPasswordReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	reqPath, l, ok := linkPasswordPath(c, req.Path, req.MetaPassword)
+	if !ok {
+		return
+	}
+	if l == nil {
+		common.ErrorStrResp(c, "the link isn't protected", 404)
+		return
+	}
+	if err := op.DeleteLinkPassword(reqPath); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ListLinkPasswords(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	links, total, err := op.GetLinkPasswords(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: links,
+		Total:   total,
+	})
+}
+
+type UnlockLinkReq struct {
+	Path     string `form:"path" binding:"required"`
+	Password string `form:"password"`
+	Redirect string `form:"redirect"`
+}
+
+// UnlockLink checks the password posted by the interstitial page, keeps the unlock token in
+// a cookie and redirects back to the link
+func UnlockLink(c *gin.Context) {
+	var req UnlockLinkReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorPage(c, err, 400)
+		return
+	}
+	path := utils.FixAndCleanPath(req.Path)
+	redirect := req.Redirect
+	// only redirect inside the site
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") {
+		redirect = conf.URL.Path + "/d" + utils.EncodePath(path, true)
+	}
+	ip := c.ClientIP()
+	count, ok := model.LoginCache.Get(ip)
+	if ok && count >= model.DefaultMaxAuthRetries {
+		model.LoginCache.Expire(ip, model.DefaultLockDuration)
+		common.ErrorPage(c, errors.New("too many failed attempts, try again later"), 429)
+		return
+	}
+	l, err := op.GetLinkPassword(path)
+	if err != nil {
+		common.ErrorPage(c, err, 500, true)
+		return
+	}
+	if l == nil {
+		common.ErrorPage(c, errors.New("the link isn't protected"), 404)
+		return
+	}
+	if !l.ValidatePassword(req.Password) {
+		model.LoginCache.Set(ip, count+1)
+		common.LinkPasswordPage(c, path, redirect, true)
+		return
+	}
+	model.LoginCache.Del(ip)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(common.LinkPasswordCookie(l), l.UnlockToken(), 0, "/", "", c.Request.TLS != nil, true)
+	c.Redirect(302, redirect)
+}
@@ -0,0 +1,137 @@
+package handles_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/handles"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+)
+
+func TestLinkPassword(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/locked", mock.Addition{Seed: 11, Depth: 1, NumFile: 2, FileSize: 64, Extensions: "bin"})
+	s.SetSetting(conf.SignAll, "false")
+	admin := s.AdminToken()
+	path := "/locked/file_0.bin"
+
+	res := servertest.PostJSON[handles.SetLinkPasswordResp](s, "/api/fs/link_password/set", admin, handles.SetLinkPasswordReq{Path: path, Password: "secret"})
+	if res.Code != 200 || !strings.Contains(res.Data.URL, "/d"+path) {
+		t.Fatalf("failed set link password: %+v", res)
+	}
+	t.Cleanup(func() {
+		servertest.PostJSON[any](s, "/api/fs/link_password/delete", admin, handles.DeleteLinkPasswordReq{Path: path})
+		model.LoginCache.Del("127.0.0.1")
+	})
+
+	resp := s.Get("/d"+path, "")
+	if resp.StatusCode != 401 || !strings.HasPrefix(resp.Header.Get("WWW-Authenticate"), "Basic") {
+		t.Errorf("tool: expected a basic auth challenge, got %d %v", resp.StatusCode, resp.Header)
+	}
+	req := s.NewRequest(http.MethodGet, "/d"+path, nil)
+	req.Header.Set("Accept", "text/html")
+	if resp := s.Do(req, ""); resp.StatusCode != 401 || !bytes.Contains(s.ReadBody(resp), []byte("link_password/unlock")) {
+		t.Errorf("browser: expected the interstitial page, got %d", resp.StatusCode)
+	}
+	if resp := s.Get("/d/locked/file_1.bin", ""); resp.StatusCode != 200 {
+		t.Errorf("expected the other files of the folder to stay open, got %d", resp.StatusCode)
+	}
+	req = s.NewRequest(http.MethodGet, "/d"+path, nil)
+	req.SetBasicAuth("", "wrong")
+	if resp := s.Do(req, ""); resp.StatusCode != 401 {
+		t.Errorf("wrong password: expected status 401, got %d", resp.StatusCode)
+	}
+	req = s.NewRequest(http.MethodGet, "/d"+path, nil)
+	req.SetBasicAuth("", "secret")
+	if resp := s.Do(req, ""); resp.StatusCode != 200 {
+		t.Errorf("basic auth: expected status 200, got %d", resp.StatusCode)
+	}
+
+	form := "path=" + path + "&password=secret&redirect=/d" + path
+	req = s.NewRequest(http.MethodPost, "/api/public/link_password/unlock", strings.NewReader(form))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp = s.Do(req, "")
+	if resp.StatusCode != 302 || resp.Header.Get("Location") != "/d"+path || len(resp.Cookies()) != 1 {
+		t.Fatalf("unlock: expected a redirect with a cookie, got %d %v", resp.StatusCode, resp.Header)
+	}
+	req = s.NewRequest(http.MethodGet, "/d"+path, nil)
+	req.AddCookie(resp.Cookies()[0])
+	if resp := s.Do(req, ""); resp.StatusCode != 200 {
+		t.Errorf("cookie: expected status 200, got %d", resp.StatusCode)
+	}
+
+	// basic auth is locked out after too many wrong passwords like the unlock form
+	basic := func(pwd string) int {
+		req := s.NewRequest(http.MethodGet, "/d"+path, nil)
+		req.SetBasicAuth("", pwd)
+		return s.Do(req, "").StatusCode
+	}
+	for i := 0; i < model.DefaultMaxAuthRetries; i++ {
+		if code := basic("wrong"); code != 401 {
+			t.Fatalf("wrong password %d: expected status 401, got %d", i, code)
+		}
+	}
+	if code := basic("secret"); code != 429 {
+		t.Errorf("expected basic auth to be locked out, got %d", code)
+	}
+}
+
+func TestLinkPasswordMeta(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/locked_meta", mock.Addition{Seed: 12, Depth: 1, NumFile: 1, FileSize: 64, Extensions: "bin"})
+	user := s.CreateUser(model.User{Username: "link_user", Permission: 1 << 14}, "password")
+	token := s.Token(user)
+	meta := &model.Meta{Path: "/locked_meta", Password: "meta", PSub: true}
+	if err := op.CreateMeta(meta); err != nil {
+		t.Fatalf("failed create meta: %+v", err)
+	}
+	path := "/locked_meta/file_0.bin"
+	t.Cleanup(func() {
+		_ = op.DeleteLinkPassword(path)
+		_ = op.DeleteMetaById(meta.ID)
+	})
+
+	// the signed link must not open a folder the user has no password of
+	set := func(metaPassword string) int {
+		return servertest.PostJSON[any](s, "/api/fs/link_password/set", token,
+			handles.SetLinkPasswordReq{Path: path, Password: "secret", MetaPassword: metaPassword}).Code
+	}
+	if code := set(""); code != 403 {
+		t.Errorf("without the meta password: expected code 403, got %d", code)
+	}
+	if code := set("meta"); code != 200 {
+		t.Errorf("with the meta password: expected code 200, got %d", code)
+	}
+
+	// only the creator or the admin may change the protection
+	other := s.Token(s.CreateUser(model.User{Username: "link_other", Permission: 1 << 14}, "password"))
+	if res := servertest.PostJSON[any](s, "/api/fs/link_password/set", other,
+		handles.SetLinkPasswordReq{Path: path, Password: "taken", MetaPassword: "meta"}); res.Code != 403 {
+		t.Errorf("another user: expected the password not to be replaced, got code %d", res.Code)
+	}
+	del := func(token, metaPassword string) int {
+		return servertest.PostJSON[any](s, "/api/fs/link_password/delete", token,
+			handles.DeleteLinkPasswordReq{Path: path, MetaPassword: metaPassword}).Code
+	}
+	if code := del(other, "meta"); code != 403 {
+		t.Errorf("another user: expected the password not to be deleted, got code %d", code)
+	}
+	if code := del(token, ""); code != 403 {
+		t.Errorf("without the meta password: expected the password not to be deleted, got code %d", code)
+	}
+	if l, err := op.GetLinkPassword(path); err != nil || l == nil || l.CreatorID != user.ID {
+		t.Fatalf("expected the protection of the creator to be kept, got %+v %v", l, err)
+	}
+	if code := del(s.AdminToken(), ""); code != 200 {
+		t.Errorf("admin: expected the password to be deleted, got code %d", code)
+	}
+	if code := del(token, "meta"); code != 404 {
+		t.Errorf("expected a deleted password to be gone, got code %d", code)
+	}
+}
@@ -0,0 +1,111 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditPathGrantsReq struct {
+	model.PageReq
+	Status string `json:"status" form:"status"`
+	UserID uint   `json:"user_id" form:"user_id"`
+	Path   string `json:"path" form:"path"`
+}
+
+type PathGrantResp struct {
+	model.PathGrant
+	Username      string `json:"username"`
+	RequesterName string `json:"requester_name"`
+	DeciderName   string `json:"decider_name"`
+}
+
+// AuditPathGrants lists the grants of the restricted paths, including the
+// decided and expired ones, the latest first
+func AuditPathGrants(c *gin.Context) {
+	var req AuditPathGrantsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	grants, total, err := op.GetPathGrants(model.PathGrant{
+		Status: req.Status,
+		UserID: req.UserID,
+		Path:   req.Path,
+	}, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	names := make(map[uint]string)
+	nameOf := func(id uint) string {
+		if id == 0 {
+			return ""
+		}
+		if name, ok := names[id]; ok {
+			return name
+		}
+		if user, err := op.GetUserById(id); err == nil {
+			names[id] = user.Username
+		} else {
+			names[id] = ""
+		}
+		return names[id]
+	}
+	resp := make([]PathGrantResp, 0, len(grants))
+	for _, g := range grants {
+		resp = append(resp, PathGrantResp{
+			PathGrant:     g,
+			Username:      nameOf(g.UserID),
+			RequesterName: nameOf(g.RequestedBy),
+			DeciderName:   nameOf(g.DecidedBy),
+		})
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: resp,
+		Total:   total,
+	})
+}
+
+func RequestPathGrant(c *gin.Context) {
+	var req model.PathGrant
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if err := op.RequestPathGrant(user, &req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+var (
+	// ApprovePathGrant approves a pending grant, the approver must not be the requester
+	ApprovePathGrant = decidePathGrant(op.ApprovePathGrant)
+	RejectPathGrant  = decidePathGrant(op.RejectPathGrant)
+	RevokePathGrant  = decidePathGrant(op.RevokePathGrant)
+)
+
+func decidePathGrant(decide func(reviewer *model.User, id uint) (*model.PathGrant, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Query("id"))
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		user := c.Request.Context().Value(conf.UserKey).(*model.User)
+		g, err := decide(user, uint(id))
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		common.SuccessResp(c, g)
+	}
+}
@@ -0,0 +1,166 @@
+package handles_test
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/server/handles"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+)
+
+func TestAccessReview(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/review", mock.Addition{Seed: 8, Depth: 2, NumFolder: 1, NumFile: 1, FileSize: 16, Extensions: "txt"})
+	user := s.CreateUser(model.User{Username: "review_user"}, "password")
+	userToken := s.Token(user)
+	reviewer := s.CreateUser(model.User{Username: "review_reviewer"}, "password")
+	reviewerToken := s.Token(reviewer)
+	s.SetSetting(conf.AccessReviewers, "review_reviewer")
+
+	if res := servertest.PostJSON[any](s, "/api/admin/meta/create", s.AdminToken(), model.Meta{Path: "/review", Restricted: true}); res.Code != 200 {
+		t.Fatalf("failed create meta: %s", res.Message)
+	}
+	t.Cleanup(func() {
+		metas := servertest.GetJSON[struct {
+			Content []model.Meta `json:"content"`
+		}](s, "/api/admin/meta/list?page=1&per_page=100", s.AdminToken())
+		for _, m := range metas.Data.Content {
+			if m.Path == "/review" {
+				servertest.PostJSON[any](s, "/api/admin/meta/delete?id="+strconv.Itoa(int(m.ID)), s.AdminToken(), nil)
+			}
+		}
+	})
+	list := func() int {
+		return servertest.PostJSON[handles.FsListResp](s, "/api/fs/list", userToken, handles.ListReq{Path: "/review"}).Code
+	}
+	if code := list(); code != 403 {
+		t.Fatalf("expected the restricted path to be denied, got %d", code)
+	}
+	if res := servertest.GetJSON[any](s, "/api/access/audit", userToken); res.Code != 403 {
+		t.Errorf("expected a user that isn't a reviewer to be rejected, got %d", res.Code)
+	}
+	if res := servertest.PostJSON[any](s, "/api/access/grant/request", reviewerToken, model.PathGrant{Path: "/other", UserID: user.ID}); res.Code != 400 {
+		t.Errorf("expected a grant of a path that isn't restricted to be rejected, got %d", res.Code)
+	}
+
+	grant := servertest.PostJSON[model.PathGrant](s, "/api/access/grant/request", reviewerToken, model.PathGrant{Path: "/review", UserID: user.ID, Reason: "test"})
+	if grant.Code != 200 || grant.Data.Status != model.GrantPending {
+		t.Fatalf("failed request grant: %+v", grant)
+	}
+	id := strconv.Itoa(int(grant.Data.ID))
+	if res := servertest.PostJSON[any](s, "/api/access/grant/approve?id="+id, reviewerToken, nil); res.Code != 400 {
+		t.Errorf("expected the requester not to approve its own grant, got %d", res.Code)
+	}
+	if code := list(); code != 403 {
+		t.Errorf("expected a pending grant not to give access, got %d", code)
+	}
+	if res := servertest.PostJSON[model.PathGrant](s, "/api/access/grant/approve?id="+id, s.AdminToken(), nil); res.Code != 200 || res.Data.Status != model.GrantApproved {
+		t.Fatalf("failed approve grant: %+v", res)
+	}
+	if code := list(); code != 200 {
+		t.Errorf("expected an approved grant to give access, got %d", code)
+	}
+	if res := servertest.PostJSON[any](s, "/api/access/grant/revoke?id="+id, reviewerToken, nil); res.Code != 200 {
+		t.Fatalf("failed revoke grant: %s", res.Message)
+	}
+	if code := list(); code != 403 {
+		t.Errorf("expected a revoked grant not to give access, got %d", code)
+	}
+
+	audit := servertest.GetJSON[struct {
+		Content []handles.PathGrantResp `json:"content"`
+	}](s, "/api/access/audit?user_id="+strconv.Itoa(int(user.ID)), s.AdminToken())
+	if len(audit.Data.Content) != 1 {
+		t.Fatalf("expected one grant in the audit, got %+v", audit.Data.Content)
+	}
+	g := audit.Data.Content[0]
+	if g.Status != model.GrantRevoked || g.RequesterName != "review_reviewer" || g.DeciderName != "review_reviewer" {
+		t.Errorf("unexpected audit entry: %+v", g)
+	}
+}
+
+func TestRestrictedPaths(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/secret", mock.Addition{Seed: 15, Depth: 2, NumFolder: 1, NumFile: 2, FileSize: 16, Extensions: "txt"})
+	s.Mount("/open", mock.Addition{Seed: 16, Depth: 1})
+	// write, rename, move, copy, remove, webdav read and manage, and share
+	user := s.CreateUser(model.User{Username: "restricted_user", Permission: 0x3f8 | 1<<14}, "password")
+	token := s.Token(user)
+	meta := &model.Meta{Path: "/secret", Restricted: true}
+	if err := op.CreateMeta(meta); err != nil {
+		t.Fatalf("failed create meta: %+v", err)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteSharingsByCreatorId(user.ID)
+		_ = op.DeleteMetaById(meta.ID)
+	})
+
+	dav := func(method, path string) int {
+		req := s.NewRequest(method, "/dav"+path, nil)
+		req.SetBasicAuth("restricted_user", "password")
+		return s.Do(req, "").StatusCode
+	}
+	userSign := sign.SignWithUser("/secret/file_0.txt", user.Username)
+	cases := []struct {
+		name string
+		code func() int
+	}{
+		// the frontend appends the user to the sign of the links it makes
+		{"download", func() int {
+			return s.Get("/d/secret/file_0.txt?sign="+userSign+":user:"+user.Username, token).StatusCode
+		}},
+		{"signed download", func() int {
+			return s.Get("/d/secret/file_0.txt?sign="+userSign+"&user="+user.Username, "").StatusCode
+		}},
+		{"mkdir", func() int {
+			return servertest.PostJSON[any](s, "/api/fs/mkdir", token, handles.MkdirOrLinkReq{Path: "/secret/new"}).Code
+		}},
+		{"rename", func() int {
+			return servertest.PostJSON[any](s, "/api/fs/rename", token, handles.RenameReq{Path: "/secret/file_0.txt", Name: "renamed.txt"}).Code
+		}},
+		{"move", func() int {
+			return servertest.PostJSON[any](s, "/api/fs/move", token, handles.MoveCopyReq{SrcDir: "/secret", DstDir: "/open", Names: []string{"file_1.txt"}}).Code
+		}},
+		{"copy", func() int {
+			return servertest.PostJSON[any](s, "/api/fs/copy", token, handles.MoveCopyReq{SrcDir: "/secret", DstDir: "/open", Names: []string{"file_1.txt"}}).Code
+		}},
+		{"remove", func() int {
+			return servertest.PostJSON[any](s, "/api/fs/remove", token, handles.RemoveReq{Dir: "/secret", Names: []string{"file_1.txt"}}).Code
+		}},
+		{"share", func() int {
+			return servertest.PostJSON[any](s, "/api/share/create", token, handles.UpdateSharingReq{Files: []string{"/secret/file_0.txt"}}).Code
+		}},
+		{"webdav get", func() int { return dav(http.MethodGet, "/secret/file_0.txt") }},
+		{"webdav delete", func() int { return dav(http.MethodDelete, "/secret/file_1.txt") }},
+	}
+	for _, c := range cases {
+		if code := c.code(); code != 403 {
+			t.Errorf("%s: expected the restricted path to be denied, got %d", c.name, code)
+		}
+	}
+
+	g := &model.PathGrant{Path: "/secret", UserID: user.ID}
+	if err := op.RequestPathGrant(user, g); err != nil {
+		t.Fatalf("failed request grant: %+v", err)
+	}
+	admin, _ := op.GetAdmin()
+	if _, err := op.ApprovePathGrant(admin, g.ID); err != nil {
+		t.Fatalf("failed approve grant: %+v", err)
+	}
+	// the cases that only read or add something, the others would change the files of the later ones
+	for _, c := range cases {
+		if !slices.Contains([]string{"download", "signed download", "mkdir", "share", "webdav get"}, c.name) {
+			continue
+		}
+		if code := c.code(); code == 403 || code == 401 {
+			t.Errorf("%s: expected a grant to give access, got %d", c.name, code)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package handles
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/policy"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type TestPolicyReq struct {
+	// Policy is tested instead of the saved one if it is not empty
+	Policy    string `json:"policy"`
+	Username  string `json:"username"`
+	Path      string `json:"path"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"ua"`
+	Sharing   string `json:"sharing"`
+	// Time is a unix timestamp, 0 means now
+	Time int64 `json:"time"`
+}
+
+// TestPolicy evaluates a download policy against a made up request, so admins
+// can check their rules before saving them
+func TestPolicy(c *gin.Context) {
+	var req TestPolicyReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	src := req.Policy
+	if src == "" {
+		src = setting.GetStr(conf.DownloadPolicy)
+	}
+	rules, err := policy.Parse(src)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	in := policy.Input{
+		Path:      req.Path,
+		IP:        req.IP,
+		UserAgent: req.UserAgent,
+		Sharing:   req.Sharing,
+	}
+	if req.Time > 0 {
+		in.Time = time.Unix(req.Time, 0)
+	}
+	if req.Username != "" {
+		if in.User, err = op.GetUserByName(req.Username); err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+	}
+	common.SuccessResp(c, policy.Evaluate(rules, in))
+}
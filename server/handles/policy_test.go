@@ -0,0 +1,75 @@
+package handles_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/policy"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/server/handles"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+)
+
+func TestDownloadPolicy(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/policy", mock.Addition{Seed: 6, Depth: 1, NumFile: 3, FileSize: 16, Extensions: "txt,mp4,jpg"})
+	s.SetSetting(conf.DownloadPolicy, `[
+		{"name": "tag", "when": "ext == \"jpg\"", "action": "annotate", "annotations": {"Kind": "image"}},
+		{"name": "no-curl", "when": "startsWith(lower(ua), \"curl/\")", "action": "deny", "message": "no scripts"},
+		{"name": "guests", "when": "user.is_guest && glob(path, \"/policy/*.mp4\")", "action": "deny"},
+		{"name": "private", "when": "path == \"/policy/file_1.mp4\"", "action": "deny"}
+	]`)
+
+	get := func(path, ua string) *http.Response {
+		req := s.NewRequest(http.MethodGet, "/d"+path+"?sign="+sign.Sign(path), nil)
+		req.Header.Set("User-Agent", ua)
+		return s.Do(req, "")
+	}
+	if resp := get("/policy/file_0.txt", "Mozilla/5.0"); resp.StatusCode != 200 {
+		t.Errorf("expected allowed download, got status %d", resp.StatusCode)
+	}
+	resp := get("/policy/file_0.txt", "curl/8.0")
+	if resp.StatusCode != http.StatusForbidden || !bytes.Contains(s.ReadBody(resp), []byte("no scripts")) {
+		t.Errorf("expected download denied by ua, got status %d", resp.StatusCode)
+	}
+	if resp := get("/policy/file_2.jpg", "Mozilla/5.0"); resp.StatusCode != 200 || resp.Header.Get("X-Policy-Kind") != "image" {
+		t.Errorf("expected annotated download, got status %d and headers %v", resp.StatusCode, resp.Header)
+	}
+
+	// a share download is checked on the path of the file, not the one inside the share
+	share := servertest.PostJSON[handles.SharingResp](s, "/api/share/create", s.AdminToken(), handles.UpdateSharingReq{Files: []string{"/policy"}})
+	if share.Code != 200 {
+		t.Fatalf("failed create sharing: %s", share.Message)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteSharing(share.Data.ID)
+	})
+	if resp := s.Get("/sd/"+share.Data.ID+"/file_1.mp4", ""); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the shared file to be denied, got status %d", resp.StatusCode)
+	}
+	if resp := s.Get("/sd/"+share.Data.ID+"/file_0.txt", ""); resp.StatusCode == http.StatusForbidden {
+		t.Errorf("expected the other shared files to be allowed, got status %d", resp.StatusCode)
+	}
+
+	// invalid policies are rejected when they are saved
+	res := servertest.PostJSON[any](s, "/api/admin/setting/save", s.AdminToken(), []model.SettingItem{{
+		Key:   conf.DownloadPolicy,
+		Value: `[{"when": "path ==", "action": "deny"}]`,
+	}})
+	if res.Code == 200 {
+		t.Errorf("expected invalid policy to be rejected")
+	}
+
+	test := servertest.PostJSON[policy.Decision](s, "/api/admin/policy/test", s.AdminToken(), handles.TestPolicyReq{
+		Path:      "/policy/file_1.mp4",
+		UserAgent: "Mozilla/5.0",
+	})
+	if test.Code != 200 || test.Data.Action != policy.ActionDeny || test.Data.Rule != "guests" {
+		t.Errorf("unexpected policy test result: %+v", test)
+	}
+}
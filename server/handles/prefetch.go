@@ -0,0 +1,148 @@
+package handles
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/prefetch"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	common.RegisterAccessHook("prefetch", 10, recordPrefetchAccess)
+}
+
+// recordPrefetchAccess feeds the accesses of a user, or of a client ip for guests, to the prefetch patterns
+func recordPrefetchAccess(e *common.AccessEvent) {
+	if !setting.GetBool(conf.PrefetchHints) || !isPrefetchable(e.Path) {
+		return
+	}
+	session := "ip:" + e.IP
+	if e.User != nil && !e.User.IsGuest() {
+		session = "user:" + strconv.FormatUint(uint64(e.User.ID), 10)
+	}
+	prefetch.Record(session, e.Path, time.Now())
+}
+
+func isPrefetchable(path string) bool {
+	t := utils.GetFileType(stdpath.Base(path))
+	return t == conf.VIDEO || t == conf.AUDIO
+}
+
+// prefetchAllowed only lets the files through the user could get a link of with fs/get
+func prefetchAllowed(user *model.User) func(path string) bool {
+	return func(path string) bool {
+		if !utils.IsSubPath(user.BasePath, path) {
+			return false
+		}
+		meta, err := op.GetNearestMeta(stdpath.Dir(path))
+		if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			return false
+		}
+		return common.CanAccess(user, meta, path, "")
+	}
+}
+
+func prefetchURL(ctx context.Context, user *model.User, path string) string {
+	return fmt.Sprintf("%s/d%s%s",
+		common.GetApiUrl(ctx),
+		utils.EncodePath(path, true),
+		common.UserSignQuery(ctx, sign.SignWithUser(path, user.Username), user.Username))
+}
+
+// setPrefetchLinks adds Link: preload headers of the files likely played after the download.
+// Players send a lot of range requests for a file, only the first one gets the hints,
+// and the folder is only looked at if its listing is cached.
+func setPrefetchLinks(c *gin.Context, rawPath string) {
+	if !setting.GetBool(conf.PrefetchHints) || !isPrefetchable(rawPath) {
+		return
+	}
+	if c.Request.Method == http.MethodHead {
+		return
+	}
+	if r := c.GetHeader("Range"); r != "" && !strings.HasPrefix(r, "bytes=0-") {
+		return
+	}
+	ctx := c.Request.Context()
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	if user == nil || user.Disabled {
+		return
+	}
+	hints := prefetch.NextCached(rawPath, setting.GetInt(conf.PrefetchHintCount, 2), prefetchAllowed(user))
+	if len(hints) == 0 {
+		return
+	}
+	links := make([]string, 0, len(hints))
+	for _, h := range hints {
+		links = append(links, "<"+prefetchURL(ctx, user, h.Path)+">; rel=preload; as=fetch")
+	}
+	c.Header("Link", strings.Join(links, ", "))
+}
+
+type PrefetchReq struct {
+	Path  string `json:"path" form:"path"`
+	Limit int    `json:"limit" form:"limit"`
+}
+
+type PrefetchHintResp struct {
+	Path   string `json:"path"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+	URL    string `json:"url"`
+}
+
+// FsPrefetch returns the files likely fetched after a file, for players that can't read the Link headers
+func FsPrefetch(c *gin.Context) {
+	var req PrefetchReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if !setting.GetBool(conf.PrefetchHints) {
+		common.ErrorStrResp(c, "prefetch hints are disabled", 403)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !prefetchAllowed(user)(reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	limit := setting.GetInt(conf.PrefetchHintCount, 2)
+	if req.Limit > 0 && req.Limit < limit {
+		limit = req.Limit
+	}
+	hints := prefetch.Next(c.Request.Context(), reqPath, limit, prefetchAllowed(user))
+	resp := make([]PrefetchHintResp, 0, len(hints))
+	for _, h := range hints {
+		path := h.Path
+		if user.BasePath != "/" {
+			path = utils.FixAndCleanPath(strings.TrimPrefix(path, user.BasePath))
+		}
+		resp = append(resp, PrefetchHintResp{
+			Path:   path,
+			Name:   h.Name,
+			Reason: h.Reason,
+			URL:    prefetchURL(c.Request.Context(), user, h.Path),
+		})
+	}
+	common.SuccessResp(c, resp)
+}
@@ -0,0 +1,60 @@
+package handles_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/prefetch"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/server/handles"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+)
+
+func TestPrefetchHints(t *testing.T) {
+	s := servertest.New(t)
+	s.MountStorage(model.Storage{MountPath: "/prefetch", CacheExpiration: 30}, mock.Addition{Seed: 14, Depth: 1, NumFile: 4, FileSize: 16, Extensions: "mp4"})
+	admin := s.AdminToken()
+	s.SetSetting(conf.PrefetchHints, "true")
+
+	path := "/prefetch/file_1.mp4"
+	link := func(method, rangeHeader string) string {
+		req := s.NewRequest(method, "/d"+path+"?sign="+sign.Sign(path), nil)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		return s.Do(req, admin).Header.Get("Link")
+	}
+	// the download doesn't list the folder to find the next files
+	if l := link(http.MethodGet, ""); l != "" {
+		t.Errorf("expected no hints before the folder is listed, got %q", l)
+	}
+
+	hints := func(path string) []handles.PrefetchHintResp {
+		res := servertest.GetJSON[[]handles.PrefetchHintResp](s, "/api/fs/prefetch?path="+path, admin)
+		if res.Code != 200 {
+			t.Fatalf("failed get prefetch hints: %s", res.Message)
+		}
+		return res.Data
+	}
+	next := hints(path)
+	if len(next) != 2 || next[0].Name != "file_2.mp4" || next[1].Name != "file_3.mp4" || next[0].Reason != prefetch.ReasonNextInFolder {
+		t.Errorf("expected the next files of the folder, got %+v", next)
+	}
+
+	if l := link(http.MethodGet, ""); !strings.Contains(l, "/d/prefetch/file_2.mp4") || !strings.Contains(l, "rel=preload") {
+		t.Errorf("expected a preload link of the next file, got %q", l)
+	}
+	if l := link(http.MethodGet, "bytes=0-"); l == "" {
+		t.Error("expected the first range request to get the hints")
+	}
+	if l := link(http.MethodGet, "bytes=100-"); l != "" {
+		t.Errorf("expected the later range requests not to get the hints, got %q", l)
+	}
+	if l := link(http.MethodHead, ""); l != "" {
+		t.Errorf("expected a head request not to get the hints, got %q", l)
+	}
+}
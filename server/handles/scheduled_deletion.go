@@ -0,0 +1,120 @@
+package handles
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type ScheduleRemoveReq struct {
+	Path    string    `json:"path" binding:"required"`
+	PurgeAt time.Time `json:"purge_at" binding:"required"`
+	Reason  string    `json:"reason"`
+}
+
+// scheduledRemovePath checks the user may schedule the deletion of the path and returns the full path
+// with its current schedule. Only the creator of a schedule or the admin may move or cancel it.
+func scheduledRemovePath(c *gin.Context, path string) (string, *model.ScheduledDeletion, bool) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !user.CanRemove() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return "", nil, false
+	}
+	reqPath, err := user.JoinPath(path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return "", nil, false
+	}
+	if !common.CanAccessRestricted(user, reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return "", nil, false
+	}
+	d, err := op.GetScheduledDeletionByPath(reqPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return "", nil, false
+	}
+	if d != nil && !user.IsAdmin() && d.CreatorID != user.ID {
+		common.ErrorStrResp(c, "the deletion was scheduled by another user", 403)
+		return "", nil, false
+	}
+	return reqPath, d, true
+}
+
+// FsScheduleRemove schedules the deletion of a file or folder, scheduling it again moves the purge date
+func FsScheduleRemove(c *gin.Context) {
+	var req ScheduleRemoveReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	reqPath, _, ok := scheduledRemovePath(c, req.Path)
+	if !ok {
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if _, err := fs.Get(c.Request.Context(), reqPath, &fs.GetArgs{}); err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	d := &model.ScheduledDeletion{
+		Path:      reqPath,
+		PurgeAt:   req.PurgeAt,
+		Reason:    req.Reason,
+		CreatorID: user.ID,
+	}
+	if err := op.ScheduleDeletion(d); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, d)
+}
+
+type CancelScheduledRemoveReq struct {
+	Path string `json:"path" binding:"required"`
+}
+
+func FsCancelScheduledRemove(c *gin.Context) {
+	var req CancelScheduledRemoveReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	reqPath, d, ok := scheduledRemovePath(c, req.Path)
+	if !ok {
+		return
+	}
+	if d == nil {
+		common.ErrorStrResp(c, "the path isn't scheduled for deletion", 404)
+		return
+	}
+	if err := op.CancelScheduledDeletion(reqPath); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ListScheduledRemoves(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	deletions, total, err := op.GetScheduledDeletions(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: deletions,
+		Total:   total,
+	})
+}
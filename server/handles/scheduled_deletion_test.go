@@ -0,0 +1,110 @@
+package handles_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/OpenListTeam/OpenList/v4/server/handles"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+)
+
+func TestScheduledRemove(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/purge", mock.Addition{Seed: 9, Depth: 1, NumFile: 2, FileSize: 16, Extensions: "txt"})
+	admin := s.AdminToken()
+	purgeAt := time.Now().Add(time.Hour)
+	t.Cleanup(func() {
+		_ = op.CancelScheduledDeletion("/purge/file_0.txt")
+		_ = op.CancelScheduledDeletion("/purge/file_1.txt")
+	})
+
+	if res := servertest.PostJSON[any](s, "/api/fs/schedule_remove", admin, handles.ScheduleRemoveReq{Path: "/purge/file_0.txt", PurgeAt: time.Now().Add(-time.Hour)}); res.Code != 400 {
+		t.Errorf("expected a purge date in the past to be rejected, got %d", res.Code)
+	}
+	for _, name := range []string{"file_0.txt", "file_1.txt"} {
+		res := servertest.PostJSON[model.ScheduledDeletion](s, "/api/fs/schedule_remove", admin, handles.ScheduleRemoveReq{Path: "/purge/" + name, PurgeAt: purgeAt})
+		if res.Code != 200 {
+			t.Fatalf("failed schedule remove: %s", res.Message)
+		}
+	}
+	if res := servertest.PostJSON[any](s, "/api/fs/cancel_scheduled_remove", admin, handles.CancelScheduledRemoveReq{Path: "/purge/file_1.txt"}); res.Code != 200 {
+		t.Fatalf("failed cancel scheduled remove: %s", res.Message)
+	}
+	list := func() map[string]*time.Time {
+		res := servertest.PostJSON[handles.FsListResp](s, "/api/fs/list", admin, handles.ListReq{Path: "/purge"})
+		badges := make(map[string]*time.Time)
+		for _, obj := range res.Data.Content {
+			badges[obj.Name] = obj.PurgeAt
+		}
+		return badges
+	}
+	badges := list()
+	if badges["file_0.txt"] == nil || badges["file_0.txt"].Unix() != purgeAt.Unix() {
+		t.Errorf("expected file_0.txt to show its purge date, got %v", badges["file_0.txt"])
+	}
+	if badges["file_1.txt"] != nil {
+		t.Errorf("expected the canceled schedule not to show, got %v", badges["file_1.txt"])
+	}
+	if res := servertest.GetJSON[common.PageResp](s, "/api/admin/scheduled_remove/list", admin); res.Data.Total != 1 {
+		t.Errorf("expected one schedule, got %d", res.Data.Total)
+	}
+	if res := servertest.PostJSON[any](s, "/api/fs/cancel_scheduled_remove", admin, handles.CancelScheduledRemoveReq{Path: "/purge/file_0.txt"}); res.Code != 200 {
+		t.Errorf("failed cancel scheduled remove: %s", res.Message)
+	}
+}
+
+func TestScheduledRemoveOwner(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/purge_owner", mock.Addition{Seed: 19, Depth: 2, NumFolder: 1, NumFile: 1, FileSize: 16, Extensions: "txt"})
+	const remove = 1 << 7
+	creator := s.Token(s.CreateUser(model.User{Username: "purge_creator", Permission: remove}, "password"))
+	other := s.Token(s.CreateUser(model.User{Username: "purge_other", Permission: remove}, "password"))
+	meta := &model.Meta{Path: "/purge_owner/folder_0", Restricted: true}
+	if err := op.CreateMeta(meta); err != nil {
+		t.Fatalf("failed create meta: %+v", err)
+	}
+	path := "/purge_owner/file_0.txt"
+	t.Cleanup(func() {
+		_ = op.CancelScheduledDeletion(path)
+		_ = op.CancelScheduledDeletion("/purge_owner/folder_0/file_0.txt")
+		_ = op.DeleteMetaById(meta.ID)
+	})
+	schedule := func(token, path string) int {
+		return servertest.PostJSON[any](s, "/api/fs/schedule_remove", token, handles.ScheduleRemoveReq{Path: path, PurgeAt: time.Now().Add(time.Hour)}).Code
+	}
+	cancel := func(token, path string) int {
+		return servertest.PostJSON[any](s, "/api/fs/cancel_scheduled_remove", token, handles.CancelScheduledRemoveReq{Path: path}).Code
+	}
+
+	// the schedule of a restricted path can't be canceled without a grant
+	restricted := "/purge_owner/folder_0/file_0.txt"
+	if code := schedule(s.AdminToken(), restricted); code != 200 {
+		t.Fatalf("failed schedule remove: %d", code)
+	}
+	if code := cancel(creator, restricted); code != 403 {
+		t.Errorf("expected the restricted path not to be canceled, got %d", code)
+	}
+
+	if code := schedule(creator, path); code != 200 {
+		t.Fatalf("failed schedule remove: %d", code)
+	}
+	if code := cancel(other, path); code != 403 {
+		t.Errorf("expected another user not to cancel the schedule, got %d", code)
+	}
+	if code := schedule(other, path); code != 403 {
+		t.Errorf("expected another user not to move the schedule, got %d", code)
+	}
+	if code := cancel(creator, path); code != 200 {
+		t.Errorf("expected the creator to cancel the schedule, got %d", code)
+	}
+	if code := cancel(creator, path); code != 404 {
+		t.Errorf("expected a canceled schedule to be gone, got %d", code)
+	}
+	if code := cancel(s.AdminToken(), restricted); code != 200 {
+		t.Errorf("expected the admin to cancel any schedule, got %d", code)
+	}
+}
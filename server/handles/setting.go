@@ -145,3 +145,8 @@ func DeleteSetting(c *gin.Context) {
 func PublicSettings(c *gin.Context) {
 	common.SuccessResp(c, op.GetPublicSettingsMap())
 }
+
+// FileTypes returns the parsed file_types setting, one entry per extension
+func FileTypes(c *gin.Context) {
+	common.SuccessResp(c, conf.FileTypeMap)
+}
@@ -0,0 +1,38 @@
+package handles_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/handles"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+)
+
+func TestFileTypes(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/types", mock.Addition{Seed: 6, Depth: 1, NumFile: 2, FileSize: 64, Extensions: "txt,dat"})
+	s.SetSetting(conf.SignAll, "false")
+	s.SetSetting(conf.FileTypes, `{"txt": {"mime": "text/x-custom"}, "DAT,.bin": {"preview": "video"}}`)
+
+	if resp := s.Get("/d/types/file_0.txt", ""); resp.Header.Get("Content-Type") != "text/x-custom" {
+		t.Errorf("expected the mapped content type, got %s", resp.Header.Get("Content-Type"))
+	}
+	res := servertest.GetJSON[map[string]conf.FileType](s, "/api/public/file_types", "")
+	if res.Data["dat"].Preview != conf.PreviewVideo || res.Data["bin"].Preview != conf.PreviewVideo {
+		t.Errorf("unexpected file types: %+v", res.Data)
+	}
+	list := servertest.PostJSON[handles.FsListResp](s, "/api/fs/list", s.AdminToken(), handles.ListReq{Path: "/types"})
+	for _, obj := range list.Data.Content {
+		if obj.Name == "file_1.dat" && obj.Type != conf.VIDEO {
+			t.Errorf("expected the mapped preview to decide the type, got %d", obj.Type)
+		}
+	}
+
+	// invalid file types are rejected when they are saved
+	saved := servertest.PostJSON[any](s, "/api/admin/setting/save", s.AdminToken(), []model.SettingItem{{Key: conf.FileTypes, Value: `{"txt": {"mime": "not a mime"}}`}})
+	if saved.Code == 200 {
+		t.Errorf("expected invalid file types to be rejected")
+	}
+}
@@ -21,6 +21,10 @@ import (
 )
 
 func SharingGet(c *gin.Context, req *FsGetReq) {
+	if common.IsKillSwitchOn() {
+		common.ErrorStrResp(c, "public sharing is temporarily disabled", 403)
+		return
+	}
 	sid, path, _ := strings.Cut(strings.TrimPrefix(req.Path, "/"), "/")
 	if sid == "" {
 		common.ErrorStrResp(c, "invalid share id", 400)
@@ -65,6 +69,10 @@ func SharingGet(c *gin.Context, req *FsGetReq) {
 }
 
 func SharingList(c *gin.Context, req *ListReq) {
+	if common.IsKillSwitchOn() {
+		common.ErrorStrResp(c, "public sharing is temporarily disabled", 403)
+		return
+	}
 	sid, path, _ := strings.Cut(strings.TrimPrefix(req.Path, "/"), "/")
 	if sid == "" {
 		common.ErrorStrResp(c, "invalid share id", 400)
@@ -104,6 +112,10 @@ func SharingList(c *gin.Context, req *ListReq) {
 }
 
 func SharingArchiveMeta(c *gin.Context, req *ArchiveMetaReq) {
+	if common.IsKillSwitchOn() {
+		common.ErrorStrResp(c, "public sharing is temporarily disabled", 403)
+		return
+	}
 	if !setting.GetBool(conf.ShareArchivePreview) {
 		common.ErrorStrResp(c, "sharing archives previewing is not allowed", 403)
 		return
@@ -147,6 +159,10 @@ func SharingArchiveMeta(c *gin.Context, req *ArchiveMetaReq) {
 }
 
 func SharingArchiveList(c *gin.Context, req *ArchiveListReq) {
+	if common.IsKillSwitchOn() {
+		common.ErrorStrResp(c, "public sharing is temporarily disabled", 403)
+		return
+	}
 	if !setting.GetBool(conf.ShareArchivePreview) {
 		common.ErrorStrResp(c, "sharing archives previewing is not allowed", 403)
 		return
@@ -188,12 +204,16 @@ func SharingArchiveList(c *gin.Context, req *ArchiveListReq) {
 }
 
 func SharingDown(c *gin.Context) {
+	if common.IsKillSwitchOn() {
+		common.ErrorPage(c, errors.New("public sharing is temporarily disabled"), 403)
+		return
+	}
 	sid := c.Request.Context().Value(conf.SharingIDKey).(string)
 	path := c.Request.Context().Value(conf.PathKey).(string)
 	path = utils.FixAndCleanPath(path)
 	
-	// 记录媒体文件访问日志
-	common.LogMediaAccess(c, path)
+	// 触发访问事件，由 hook 记录媒体文件访问日志
+	common.EmitAccessEvent(c, path, common.AccessTypePreview)
 	
 	pwd := c.Query("pwd")
 	s, err := op.GetSharingById(sid)
@@ -214,6 +234,9 @@ func SharingDown(c *gin.Context) {
 		common.ErrorPage(c, errors.New("failed get sharing unwrap path"), 500)
 		return
 	}
+	if !common.CheckDownloadPolicy(c, unwrapPath) {
+		return
+	}
 	storage, actualPath, err := op.GetStorageAndActualPath(unwrapPath)
 	if dealErrorPage(c, err) {
 		return
@@ -253,6 +276,10 @@ func SharingDown(c *gin.Context) {
 }
 
 func SharingArchiveExtract(c *gin.Context) {
+	if common.IsKillSwitchOn() {
+		common.ErrorPage(c, errors.New("public sharing is temporarily disabled"), 403)
+		return
+	}
 	if !setting.GetBool(conf.ShareArchivePreview) {
 		common.ErrorPage(c, errors.New("sharing archives previewing is not allowed"), 403)
 		return
@@ -281,6 +308,9 @@ func SharingArchiveExtract(c *gin.Context) {
 		common.ErrorPage(c, errors.New("failed get sharing unwrap path"), 500)
 		return
 	}
+	if !common.CheckDownloadPolicy(c, unwrapPath) {
+		return
+	}
 	storage, actualPath, err := op.GetStorageAndActualPath(unwrapPath)
 	if dealErrorPage(c, err) {
 		return
@@ -360,11 +390,20 @@ type SharingResp struct {
 	CreatorRole int    `json:"creator_role"`
 }
 
+// canManageSharing reports whether user may view, update, delete, enable or disable the sharing,
+// a group owner manages the sharings of the members of its group
+func canManageSharing(user *model.User, s *model.Sharing) bool {
+	if user.IsAdmin() || s.CreatorId == user.ID {
+		return true
+	}
+	return s.Creator != nil && op.IsGroupMemberOf(user, s.Creator)
+}
+
 func GetSharing(c *gin.Context) {
 	sid := c.Query("id")
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
 	s, err := op.GetSharingById(sid)
-	if err != nil || (!user.IsAdmin() && s.Creator.ID != user.ID) {
+	if err != nil || !canManageSharing(user, s) {
 		common.ErrorStrResp(c, "sharing not found", 404)
 		return
 	}
@@ -388,6 +427,8 @@ func ListSharings(c *gin.Context) {
 	var err error
 	if user.IsAdmin() {
 		sharings, total, err = op.GetSharings(req.Page, req.PerPage)
+	} else if user.IsGroupOwner() && user.GroupID != 0 {
+		sharings, total, err = op.GetSharingsByGroupId(user.GroupID, req.Page, req.PerPage)
 	} else {
 		sharings, total, err = op.GetSharingsByCreatorId(user.ID, req.Page, req.PerPage)
 	}
@@ -432,38 +473,33 @@ func UpdateSharing(c *gin.Context) {
 		common.ErrorStrResp(c, "must add at least 1 object", 400)
 		return
 	}
-	var user *model.User
-	var err error
 	reqUser := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !reqUser.IsAdmin() && !reqUser.CanShare() {
+		common.ErrorStrResp(c, "permission denied", 403)
+		return
+	}
+	s, err := op.GetSharingById(req.ID)
+	if err != nil || !canManageSharing(reqUser, s) {
+		common.ErrorStrResp(c, "sharing not found", 404)
+		return
+	}
+	// a group owner updates the sharings of its members without taking them over
+	user := s.Creator
 	if reqUser.IsAdmin() && req.CreatorName != "" {
 		user, err = op.GetUserByName(req.CreatorName)
 		if err != nil {
 			common.ErrorStrResp(c, "no such a user", 400)
 			return
 		}
-	} else {
-		user = reqUser
-		if !user.CanShare() {
-			common.ErrorStrResp(c, "permission denied", 403)
-			return
-		}
 	}
-	for i, s := range req.Files {
-		s = utils.FixAndCleanPath(s)
-		req.Files[i] = s
-		if !reqUser.IsAdmin() && !strings.HasPrefix(s, user.BasePath) {
-			common.ErrorStrResp(c, fmt.Sprintf("permission denied to share path [%s]", s), 500)
+	for i, f := range req.Files {
+		f = utils.FixAndCleanPath(f)
+		req.Files[i] = f
+		if !reqUser.IsAdmin() && !strings.HasPrefix(f, user.BasePath) {
+			common.ErrorStrResp(c, fmt.Sprintf("permission denied to share path [%s]", f), 500)
 			return
 		}
 	}
-	s, err := op.GetSharingById(req.ID)
-	if err != nil || (!reqUser.IsAdmin() && s.CreatorId != user.ID) {
-		common.ErrorStrResp(c, "sharing not found", 404)
-		return
-	}
-	if reqUser.IsAdmin() && req.CreatorName == "" {
-		user = s.Creator
-	}
 	s.Files = req.Files
 	s.Expires = req.Expires
 	s.Pwd = req.Pwd
@@ -475,7 +511,9 @@ func UpdateSharing(c *gin.Context) {
 	s.Readme = req.Readme
 	s.Remark = req.Remark
 	s.Creator = user
-	if err = op.UpdateSharing(s); err != nil {
+	if err = op.UpdateSharing(s); errors.Is(err, errs.PermissionDenied) {
+		common.ErrorResp(c, err, 403)
+	} else if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		common.SuccessResp(c, SharingResp{
@@ -537,7 +575,9 @@ func CreateSharing(c *gin.Context) {
 		Creator: user,
 	}
 	var id string
-	if id, err = op.CreateSharing(s); err != nil {
+	if id, err = op.CreateSharing(s); errors.Is(err, errs.ShareQuotaExceeded) || errors.Is(err, errs.PermissionDenied) {
+		common.ErrorResp(c, err, 403)
+	} else if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		s.ID = id
@@ -553,8 +593,8 @@ func DeleteSharing(c *gin.Context) {
 	sid := c.Query("id")
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
 	s, err := op.GetSharingById(sid)
-	if err != nil || (!user.IsAdmin() && s.CreatorId != user.ID) {
-		common.ErrorResp(c, err, 404)
+	if err != nil || !canManageSharing(user, s) {
+		common.ErrorStrResp(c, "sharing not found", 404)
 		return
 	}
 	if err = op.DeleteSharing(sid); err != nil {
@@ -569,7 +609,7 @@ func SetEnableSharing(disable bool) func(ctx *gin.Context) {
 		sid := c.Query("id")
 		user := c.Request.Context().Value(conf.UserKey).(*model.User)
 		s, err := op.GetSharingById(sid)
-		if err != nil || (!user.IsAdmin() && s.CreatorId != user.ID) {
+		if err != nil || !canManageSharing(user, s) {
 			common.ErrorStrResp(c, "sharing not found", 404)
 			return
 		}
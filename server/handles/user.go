@@ -39,6 +39,16 @@ func CreateUser(c *gin.Context) {
 		common.ErrorStrResp(c, "admin or guest user can not be created", 400, true)
 		return
 	}
+	if req.GroupID != 0 {
+		if req.IsGroupOwner() {
+			common.ErrorStrResp(c, "a group owner joins its group when the group is created", 400)
+			return
+		}
+		if _, err := op.GetGroupById(req.GroupID); err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+	}
 	req.SetPassword(req.Password)
 	req.Password = ""
 	req.Authn = "[]"
@@ -60,10 +70,12 @@ func UpdateUser(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
-	if user.Role != req.Role {
+	if user.Role != req.Role && !canSwitchRole(user, &req) {
 		common.ErrorStrResp(c, "role can not be changed", 400)
 		return
 	}
+	// the group is managed by the group apis
+	req.GroupID = user.GroupID
 	if req.Password == "" {
 		req.PwdHash = user.PwdHash
 		req.Salt = user.Salt
@@ -85,6 +97,15 @@ func UpdateUser(c *gin.Context) {
 	}
 }
 
+// canSwitchRole allows the admin to turn a general user into a group owner and back,
+// as long as the user is in no group
+func canSwitchRole(old, new *model.User) bool {
+	isSwitchable := func(role int) bool {
+		return role == model.GENERAL || role == model.GROUPOWNER
+	}
+	return isSwitchable(old.Role) && isSwitchable(new.Role) && old.GroupID == 0
+}
+
 func DeleteUser(c *gin.Context) {
 	idStr := c.Query("id")
 	id, err := strconv.Atoi(idStr)
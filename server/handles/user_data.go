@@ -0,0 +1,60 @@
+package handles
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/privacy"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+func ExportUserData(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user, err := op.GetUserById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	// build the archive in memory so that a failure can still be reported as json
+	var buf bytes.Buffer
+	if err = privacy.Export(c.Request.Context(), user, &buf); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	log.Infof("exported data of user [%s]", user.Username)
+	fileName := fmt.Sprintf("%s-%s.zip", user.Username, time.Now().Format("20060102150405"))
+	c.Header("Content-Disposition", utils.GenerateContentDisposition(fileName))
+	c.Data(200, "application/zip", buf.Bytes())
+}
+
+func EraseUserData(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user, err := op.GetUserById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	res, err := privacy.Erase(user)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	log.Infof("erased data of user [%s]: %+v", user.Username, res)
+	common.SuccessResp(c, res)
+}
@@ -0,0 +1,109 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/watch"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListWatchRules(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	rules, total, err := db.GetWatchRules(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: rules,
+		Total:   total,
+	})
+}
+
+func CreateWatchRule(c *gin.Context) {
+	var req model.WatchRule
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := watch.Validate(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.LastScanAt = nil
+	req.LastError = ""
+	if err := db.CreateWatchRule(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+func UpdateWatchRule(c *gin.Context) {
+	var req model.WatchRule
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	old, err := db.GetWatchRuleById(req.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	if err = watch.Validate(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	// the scan state isn't changed by the admin
+	req.LastScanAt = old.LastScanAt
+	req.LastError = old.LastError
+	if err = db.UpdateWatchRule(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+func DeleteWatchRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err = db.DeleteWatchRuleById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// ScanWatchRule scans a rule right away and returns what it shared
+func ScanWatchRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	rule, err := db.GetWatchRuleById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	releases, err := watch.Scan(c.Request.Context(), rule)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if releases == nil {
+		releases = []watch.Release{}
+	}
+	common.SuccessResp(c, releases)
+}
@@ -0,0 +1,58 @@
+package handles_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/watch"
+	"github.com/OpenListTeam/OpenList/v4/server/handles"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+)
+
+func TestWatchRule(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/watch", mock.Addition{Seed: 10, Depth: 2, NumFolder: 2, NumFile: 1, FileSize: 16, Extensions: "txt"})
+	admin := s.AdminToken()
+	me := servertest.GetJSON[handles.UserResp](s, "/api/me", admin)
+
+	rule := servertest.PostJSON[model.WatchRule](s, "/api/admin/watch/create", admin, model.WatchRule{
+		Name:      "releases",
+		Path:      "/watch",
+		CreatorID: me.Data.ID,
+	})
+	if rule.Code != 200 {
+		t.Fatalf("failed create watch rule: %s", rule.Message)
+	}
+	id := strconv.Itoa(int(rule.Data.ID))
+	t.Cleanup(func() {
+		servertest.PostJSON[any](s, "/api/admin/watch/delete?id="+id, admin, nil)
+	})
+	scan := func() []watch.Release {
+		res := servertest.PostJSON[[]watch.Release](s, "/api/admin/watch/scan?id="+id, admin, nil)
+		if res.Code != 200 {
+			t.Fatalf("failed scan: %s", res.Message)
+		}
+		return res.Data
+	}
+
+	if releases := scan(); len(releases) != 0 {
+		t.Fatalf("expected the existing folders to be skipped, got %+v", releases)
+	}
+	if res := servertest.PostJSON[any](s, "/api/fs/mkdir", admin, handles.MkdirOrLinkReq{Path: "/watch/v1.0"}); res.Code != 200 {
+		t.Fatalf("failed mkdir: %s", res.Message)
+	}
+	releases := scan()
+	if len(releases) != 1 || releases[0].Path != "/watch/v1.0" {
+		t.Fatalf("expected the new folder to be shared, got %+v", releases)
+	}
+	t.Cleanup(func() {
+		_ = op.DeleteSharing(releases[0].SharingID)
+	})
+
+	if res := servertest.PostJSON[any](s, "/api/admin/watch/create", admin, model.WatchRule{Name: "orphan", Path: "/watch"}); res.Code == 200 {
+		t.Errorf("expected a rule without a creator to be rejected")
+	}
+}
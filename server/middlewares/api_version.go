@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersion sets the api version of the request. Routes with a pinned version
+// always use it, the unversioned routes negotiate it from the request headers and
+// fall back to v1. Responses of v1 are marked as deprecated in their headers.
+func APIVersion(pinned int) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		version := pinned
+		if version == 0 {
+			v, err := common.NegotiateAPIVersion(c.GetHeader(common.APIVersionHeader), c.GetHeader("Accept"))
+			if err != nil {
+				common.ErrorResp(c, err, 400)
+				c.Abort()
+				return
+			}
+			version = v
+		}
+		if version == 0 {
+			version = common.APIVersionV1
+		}
+		if version == common.APIVersionV1 {
+			deprecation, sunset := common.DeprecationHeaders()
+			c.Header("Deprecation", deprecation)
+			if sunset != "" {
+				c.Header("Sunset", sunset)
+			}
+			if successor := successorPath(c.Request.URL.Path); successor != "" {
+				c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+			}
+		}
+		c.Header(common.APIVersionHeader, strconv.Itoa(version))
+		common.GinWithValue(c, conf.APIVersionKey, version)
+		c.Next()
+	}
+}
+
+// successorPath returns the path of the v2 route of a v1 route
+func successorPath(path string) string {
+	prefix := strings.TrimSuffix(conf.URL.Path, "/") + "/api/"
+	if !strings.HasPrefix(path, prefix) {
+		return ""
+	}
+	return prefix + "v2/" + strings.TrimPrefix(path, prefix)
+}
@@ -0,0 +1,77 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/OpenListTeam/OpenList/v4/server/handles"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+)
+
+func TestAPIVersion(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/version", mock.Addition{Seed: 5, Depth: 1, NumFile: 1, FileSize: 16, Extensions: "txt"})
+	user := s.CreateUser(model.User{Username: "version_user"}, "password")
+	token := s.Token(user)
+	s.SetSetting(conf.ApiV1SunsetAt, "1893456000")
+
+	resp := s.Get("/api/me", token)
+	if resp.Header.Get("Deprecation") != "true" {
+		t.Errorf("expected v1 to be deprecated, got %q", resp.Header.Get("Deprecation"))
+	}
+	if resp.Header.Get("Sunset") != "Tue, 01 Jan 2030 00:00:00 GMT" {
+		t.Errorf("unexpected sunset: %q", resp.Header.Get("Sunset"))
+	}
+	if resp.Header.Get("Link") != `</api/v2/me>; rel="successor-version"` {
+		t.Errorf("unexpected successor link: %q", resp.Header.Get("Link"))
+	}
+
+	resp = s.Get("/api/v2/me", token)
+	if resp.Header.Get("Deprecation") != "" || resp.Header.Get(common.APIVersionHeader) != "2" {
+		t.Errorf("unexpected v2 headers: %v", resp.Header)
+	}
+	if res := servertest.DecodeResp[handles.UserResp](s, resp); res.Code != 200 || res.Data.Username != user.Username {
+		t.Errorf("failed get me from v2: %s", res.Message)
+	}
+
+	// the version can be negotiated on the unversioned routes
+	req := s.NewRequest(http.MethodGet, "/api/me", nil)
+	req.Header.Set("Accept", "application/vnd.openlist.v2+json")
+	if resp = s.Do(req, token); resp.Header.Get(common.APIVersionHeader) != "2" || resp.Header.Get("Deprecation") != "" {
+		t.Errorf("expected negotiated v2, got headers: %v", resp.Header)
+	}
+	req = s.NewRequest(http.MethodGet, "/api/me", nil)
+	req.Header.Set(common.APIVersionHeader, "99")
+	if res := servertest.DecodeResp[any](s, s.Do(req, token)); res.Code != 400 {
+		t.Errorf("expected unsupported version to be rejected, got code %d", res.Code)
+	}
+
+	// v1 keeps the user in the sign, v2 passes it separately
+	path := "/version/file_0.txt"
+	v1 := servertest.PostJSON[handles.FsGetResp](s, "/api/fs/get", token, handles.FsGetReq{Path: path})
+	if v1.Code != 200 {
+		t.Fatalf("failed get %s: %s", path, v1.Message)
+	}
+	if !strings.HasSuffix(v1.Data.Sign, ":user:"+user.Username) || v1.Data.SignUser != "" {
+		t.Errorf("unexpected v1 sign: %q %q", v1.Data.Sign, v1.Data.SignUser)
+	}
+	v2 := servertest.PostJSON[handles.FsGetResp](s, "/api/v2/fs/get", token, handles.FsGetReq{Path: path})
+	if v2.Code != 200 {
+		t.Fatalf("failed get %s from v2: %s", path, v2.Message)
+	}
+	if strings.Contains(v2.Data.Sign, ":user:") || v2.Data.SignUser != user.Username {
+		t.Errorf("unexpected v2 sign: %q %q", v2.Data.Sign, v2.Data.SignUser)
+	}
+	// both formats are accepted by /d
+	for _, u := range []string{v1.Data.RawURL, v2.Data.RawURL} {
+		u = strings.TrimPrefix(u, s.URL)
+		if resp := s.Get(u, ""); resp.StatusCode != 200 {
+			t.Errorf("failed download %s: status %d", u, resp.StatusCode)
+		}
+	}
+}
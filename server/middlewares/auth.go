@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"crypto/subtle"
+	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -16,7 +17,8 @@ import (
 // if token is empty, set user to guest
 func Auth(allowDisabledGuest bool) func(c *gin.Context) {
 	return func(c *gin.Context) {
-		token := c.GetHeader("Authorization")
+		// tokens exchanged by client apps are usually sent as bearer tokens
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
 		if subtle.ConstantTimeCompare([]byte(token), []byte(setting.GetStr(conf.Token))) == 1 {
 			admin, err := op.GetAdmin()
 			if err != nil {
@@ -41,6 +43,11 @@ func Auth(allowDisabledGuest bool) func(c *gin.Context) {
 				c.Abort()
 				return
 			}
+			if !allowDisabledGuest && common.IsKillSwitchOn() {
+				common.ErrorStrResp(c, "Public access is temporarily disabled, login please", 401)
+				c.Abort()
+				return
+			}
 			common.GinWithValue(c, conf.UserKey, guest)
 			log.Debugf("use empty token: %+v", guest)
 			c.Next()
@@ -69,6 +76,11 @@ func Auth(allowDisabledGuest bool) func(c *gin.Context) {
 			c.Abort()
 			return
 		}
+		if err = common.CheckClientToken(c, userClaims, user); err != nil {
+			common.ErrorResp(c, err, 403)
+			c.Abort()
+			return
+		}
 		common.GinWithValue(c, conf.UserKey, user)
 		log.Debugf("use login token: %+v", user)
 		c.Next()
@@ -79,7 +91,7 @@ func Auth(allowDisabledGuest bool) func(c *gin.Context) {
 // 用于下载路由，尝试从 Authorization header 或 token query 参数获取用户信息
 func AuthOptional(c *gin.Context) {
 	// 尝试从 header 获取 token
-	token := c.GetHeader("Authorization")
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
 	// 如果 header 没有，尝试从 query 参数获取
 	if token == "" {
 		token = c.Query("token")
@@ -101,7 +113,8 @@ func AuthOptional(c *gin.Context) {
 		userClaims, err := common.ParseToken(token)
 		if err == nil {
 			user, err := op.GetUserByName(userClaims.Username)
-			if err == nil && userClaims.PwdTS == user.PwdTS && !user.Disabled {
+			if err == nil && userClaims.PwdTS == user.PwdTS && !user.Disabled &&
+				common.CheckClientToken(c, userClaims, user) == nil {
 				common.GinWithValue(c, conf.UserKey, user)
 				log.Debugf("auth optional: use user token: %s", user.Username)
 				c.Next()
@@ -168,6 +181,11 @@ func Authn(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	if err = common.CheckClientToken(c, userClaims, user); err != nil {
+		common.ErrorResp(c, err, 403)
+		c.Abort()
+		return
+	}
 	common.GinWithValue(c, conf.UserKey, user)
 	log.Debugf("use login token: %+v", user)
 	c.Next()
@@ -192,3 +210,39 @@ func AuthAdmin(c *gin.Context) {
 		c.Next()
 	}
 }
+
+func isAccessReviewer(user *model.User) bool {
+	if user.IsAdmin() {
+		return true
+	}
+	if user.IsGuest() || user.Disabled {
+		return false
+	}
+	for _, name := range strings.Split(setting.GetStr(conf.AccessReviewers), ",") {
+		if strings.TrimSpace(name) == user.Username {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthAccessReviewer allows the admin and the users listed in the access reviewers setting
+func AuthAccessReviewer(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if isAccessReviewer(user) {
+		c.Next()
+	} else {
+		common.ErrorStrResp(c, "You are not an access reviewer", 403)
+		c.Abort()
+	}
+}
+
+func AuthGroupOwner(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !user.IsGroupOwner() {
+		common.ErrorStrResp(c, "You are not a group owner", 403)
+		c.Abort()
+	} else {
+		c.Next()
+	}
+}
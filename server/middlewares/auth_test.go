@@ -0,0 +1,66 @@
+package middlewares_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/handles"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+)
+
+func TestAuth(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/auth", mock.Addition{Seed: 1, Depth: 1, NumFile: 3, FileSize: 16, Extensions: "txt"})
+	user := s.CreateUser(model.User{Username: "auth_user"}, "password")
+	userToken := s.Token(user)
+
+	var cases = []struct {
+		name  string
+		token string
+		code  int
+	}{
+		{name: "disabled guest", token: "", code: 401},
+		{name: "invalid token", token: "invalid", code: 401},
+		{name: "admin", token: s.AdminToken(), code: 200},
+		{name: "user", token: userToken, code: 200},
+	}
+	for _, c := range cases {
+		res := servertest.GetJSON[handles.UserResp](s, "/api/me", c.token)
+		if res.Code != c.code {
+			t.Errorf("%s: expected code %d, got %d: %s", c.name, c.code, res.Code, res.Message)
+		}
+	}
+
+	list := servertest.PostJSON[handles.FsListResp](s, "/api/fs/list", userToken, handles.ListReq{Path: "/auth"})
+	if list.Code != 200 {
+		t.Fatalf("failed list: %s", list.Message)
+	}
+	var names []string
+	for _, obj := range list.Data.Content {
+		names = append(names, obj.Name)
+	}
+	expected := []string{"file_0.txt", "file_1.txt", "file_2.txt"}
+	if len(names) != len(expected) {
+		t.Fatalf("expected: %+v, got: %+v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatalf("expected: %+v, got: %+v", expected, names)
+		}
+	}
+
+	s.UpdateUser(user.Username, func(u *model.User) {
+		u.Disabled = true
+	})
+	if res := servertest.GetJSON[any](s, "/api/me", userToken); res.Code != 401 {
+		t.Errorf("disabled user: expected code 401, got %d", res.Code)
+	}
+
+	s.UpdateUser("guest", func(u *model.User) {
+		u.Disabled = false
+	})
+	if res := servertest.GetJSON[handles.UserResp](s, "/api/me", ""); res.Code != 200 || res.Data.Username != "guest" {
+		t.Errorf("enabled guest: expected guest, got %d %s", res.Code, res.Message)
+	}
+}
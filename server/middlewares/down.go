@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"crypto/subtle"
 	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
@@ -33,6 +34,9 @@ func Down(verifyFunc func(string, string) error) func(c *gin.Context) {
 			}
 		}
 		common.GinWithValue(c, conf.MetaKey, meta)
+		if !checkLinkPassword(c, rawPath) {
+			return
+		}
 		
 		// 获取URL中的签名
 		signParam := strings.TrimSuffix(c.Query("sign"), "/")
@@ -51,7 +55,7 @@ func Down(verifyFunc func(string, string) error) func(c *gin.Context) {
 		}
 		
 		// verify sign
-		if needSign(meta, rawPath) {
+		if needSign(meta, rawPath) || (common.IsKillSwitchOn() && !isAuthenticated(c)) {
 			// 如果有用户名，尝试使用带用户名的签名验证
 			if username != "" && signStr != "" {
 				err = sign.VerifyWithUser(rawPath, username, signStr)
@@ -61,6 +65,9 @@ func Down(verifyFunc func(string, string) error) func(c *gin.Context) {
 					if userErr == nil && user != nil {
 						common.GinWithValue(c, conf.UserKey, user)
 					}
+					if !checkRestricted(c, rawPath) {
+						return
+					}
 					c.Next()
 					return
 				}
@@ -85,16 +92,79 @@ func Down(verifyFunc func(string, string) error) func(c *gin.Context) {
 				}
 			}
 		}
+		if !checkRestricted(c, rawPath) {
+			return
+		}
 		c.Next()
 	}
 }
 
+// checkRestricted only lets the users with a grant download from the restricted paths,
+// the user is the one of the token or of the sign
+func checkRestricted(c *gin.Context, rawPath string) bool {
+	if _, ok := op.GetRestrictedPath(rawPath); !ok {
+		return true
+	}
+	user, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if ok && user != nil && op.CanAccessRestricted(user, rawPath) {
+		return true
+	}
+	common.ErrorPage(c, errors.WithStack(errs.PermissionDenied), 403)
+	c.Abort()
+	return false
+}
+
+// checkLinkPassword asks for the password of a protected link, browsers get the
+// interstitial page and the other clients a basic auth challenge with any username
+func checkLinkPassword(c *gin.Context, rawPath string) bool {
+	l, err := op.GetLinkPassword(rawPath)
+	if err != nil {
+		common.ErrorPage(c, err, 500, true)
+		return false
+	}
+	if l == nil {
+		return true
+	}
+	if token, err := c.Cookie(common.LinkPasswordCookie(l)); err == nil &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(l.UnlockToken())) == 1 {
+		return true
+	}
+	if _, pwd, ok := c.Request.BasicAuth(); ok {
+		// the same lockout as the unlock form, or basic auth would allow guessing without limit
+		ip := c.ClientIP()
+		count, cok := model.LoginCache.Get(ip)
+		if cok && count >= model.DefaultMaxAuthRetries {
+			model.LoginCache.Expire(ip, model.DefaultLockDuration)
+			common.ErrorPage(c, errors.New("too many failed attempts, try again later"), 429)
+			return false
+		}
+		if l.ValidatePassword(pwd) {
+			model.LoginCache.Del(ip)
+			return true
+		}
+		model.LoginCache.Set(ip, count+1)
+	}
+	if strings.Contains(c.GetHeader("Accept"), "text/html") {
+		common.LinkPasswordPage(c, rawPath, c.Request.URL.RequestURI(), false)
+		return false
+	}
+	c.Header("WWW-Authenticate", `Basic realm="OpenList", charset="UTF-8"`)
+	common.ErrorPage(c, errors.New("password is required"), 401)
+	return false
+}
+
 // TODO: implement
 // path maybe contains # ? etc.
 func parsePath(path string) string {
 	return utils.FixAndCleanPath(path)
 }
 
+// isAuthenticated reports whether the request carries a logged-in, non-guest user
+func isAuthenticated(c *gin.Context) bool {
+	user, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	return ok && user != nil && !user.IsGuest()
+}
+
 func needSign(meta *model.Meta, path string) bool {
 	if setting.GetBool(conf.SignAll) {
 		return true
@@ -0,0 +1,58 @@
+package middlewares_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+)
+
+func TestDown(t *testing.T) {
+	s := servertest.New(t)
+	m := s.Mount("/down", mock.Addition{Seed: 2, Depth: 1, NumFile: 2, FileSize: 4096, Extensions: "bin"})
+	content, err := m.Content("/file_0.bin")
+	if err != nil {
+		t.Fatalf("failed get content: %+v", err)
+	}
+	path := "/down/file_0.bin"
+
+	if resp := s.Get("/d"+path, ""); resp.StatusCode != 401 {
+		t.Errorf("without sign: expected status 401, got %d", resp.StatusCode)
+	}
+	if resp := s.Get("/d"+path+"?sign=invalid", ""); resp.StatusCode != 401 {
+		t.Errorf("invalid sign: expected status 401, got %d", resp.StatusCode)
+	}
+
+	resp := s.Get("/d"+path+"?sign="+sign.Sign(path), "")
+	if resp.StatusCode != 200 {
+		t.Fatalf("with sign: expected status 200, got %d", resp.StatusCode)
+	}
+	if body := s.ReadBody(resp); !bytes.Equal(body, content) {
+		t.Errorf("with sign: content mismatch, expected %d bytes, got %d", len(content), len(body))
+	}
+
+	req := s.NewRequest(http.MethodGet, "/d"+path+"?sign="+sign.Sign(path), nil)
+	req.Header.Set("Range", "bytes=100-199")
+	resp = s.Do(req, "")
+	if resp.StatusCode != 206 {
+		t.Fatalf("range: expected status 206, got %d", resp.StatusCode)
+	}
+	if body := s.ReadBody(resp); !bytes.Equal(body, content[100:200]) {
+		t.Errorf("range: content mismatch")
+	}
+
+	// a sign is only valid for the path it was made for
+	other := "/down/file_1.bin"
+	if resp := s.Get("/d"+other+"?sign="+sign.Sign(path), ""); resp.StatusCode != 401 {
+		t.Errorf("sign of another path: expected status 401, got %d", resp.StatusCode)
+	}
+
+	s.SetSetting(conf.SignAll, "false")
+	if resp := s.Get("/d"+other, ""); resp.StatusCode != 200 {
+		t.Errorf("sign disabled: expected status 200, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,24 @@
+package middlewares
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// Hooks runs the hooks registered at point before the rest of the chain
+func Hooks(point common.HookPoint) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		if !common.RunHooks(point, c) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// DownloadHooks wraps a download handler with the pre-download and post-download hooks
+func DownloadHooks(c *gin.Context) {
+	if common.RunHooks(common.HookPreDownload, c) {
+		c.Next()
+	}
+	common.RunHooks(common.HookPostDownload, c)
+}
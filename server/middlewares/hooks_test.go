@@ -0,0 +1,148 @@
+package middlewares_test
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/OpenListTeam/OpenList/v4/server/servertest"
+	"github.com/gin-gonic/gin"
+)
+
+func TestHooks(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/hooks", mock.Addition{Seed: 4, Depth: 1, NumFile: 2, FileSize: 1024, Extensions: "mp4"})
+
+	// hooks are global, so they only act on the requests of this test
+	var mu sync.Mutex
+	var calls []string
+	var events []*common.AccessEvent
+	record := func(name string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			if c.GetHeader("X-Hook-Test") == "" {
+				return
+			}
+			mu.Lock()
+			calls = append(calls, name)
+			mu.Unlock()
+		}
+	}
+	common.RegisterHook(common.HookPreAuth, "test_pre_auth", 0, record("pre-auth"))
+	common.RegisterHook(common.HookPostAuth, "test_post_auth", 0, record("post-auth"))
+	common.RegisterHook(common.HookPreDownload, "test_pre_download_2", 20, record("pre-download-2"))
+	common.RegisterHook(common.HookPreDownload, "test_pre_download_1", 10, func(c *gin.Context) {
+		record("pre-download-1")(c)
+		if c.GetHeader("X-Hook-Test") == "block" {
+			c.AbortWithStatus(http.StatusForbidden)
+		}
+	})
+	common.RegisterHook(common.HookPostDownload, "test_post_download", 0, record("post-download"))
+	common.RegisterAccessHook("test_access", 0, func(e *common.AccessEvent) {
+		if strings.HasPrefix(e.Path, "/hooks/") {
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+		}
+	})
+	t.Cleanup(func() {
+		common.UnregisterHook(common.HookPreAuth, "test_pre_auth")
+		common.UnregisterHook(common.HookPostAuth, "test_post_auth")
+		common.UnregisterHook(common.HookPreDownload, "test_pre_download_1")
+		common.UnregisterHook(common.HookPreDownload, "test_pre_download_2")
+		common.UnregisterHook(common.HookPostDownload, "test_post_download")
+		common.UnregisterAccessHook("test_access")
+	})
+	reset := func() {
+		mu.Lock()
+		calls, events = nil, nil
+		mu.Unlock()
+	}
+	expectCalls := func(expected ...string) {
+		t.Helper()
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.Join(calls, ",") != strings.Join(expected, ",") {
+			t.Errorf("expected hooks %v, got %v", expected, calls)
+		}
+	}
+
+	path := "/hooks/file_0.mp4"
+	req := s.NewRequest(http.MethodGet, "/d"+path+"?sign="+sign.Sign(path), nil)
+	req.Header.Set("X-Hook-Test", "1")
+	if resp := s.Do(req, ""); resp.StatusCode != 200 {
+		t.Fatalf("failed get %s: status %d", path, resp.StatusCode)
+	}
+	expectCalls("pre-auth", "pre-download-1", "pre-download-2", "post-download")
+	mu.Lock()
+	if len(events) == 0 || events[0].Path != path || events[0].Type != common.AccessTypeDownload {
+		t.Errorf("unexpected access events: %+v", events)
+	}
+	mu.Unlock()
+
+	// an aborting hook skips the rest of the hooks and the handler
+	reset()
+	path = "/hooks/file_1.mp4"
+	req = s.NewRequest(http.MethodGet, "/d"+path+"?sign="+sign.Sign(path), nil)
+	req.Header.Set("X-Hook-Test", "block")
+	if resp := s.Do(req, ""); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected blocked download, got status %d", resp.StatusCode)
+	}
+	expectCalls("pre-auth", "pre-download-1", "post-download")
+	mu.Lock()
+	if len(events) != 0 {
+		t.Errorf("expected no access events of a blocked download: %+v", events)
+	}
+	mu.Unlock()
+
+	reset()
+	req = s.NewRequest(http.MethodGet, "/api/me", nil)
+	req.Header.Set("X-Hook-Test", "1")
+	s.Do(req, s.AdminToken())
+	expectCalls("pre-auth", "post-auth")
+}
+
+func TestAccessEvents(t *testing.T) {
+	s := servertest.New(t)
+	s.Mount("/media", mock.Addition{Seed: 3, Depth: 1, NumFile: 4, FileSize: 1024, Extensions: "mp4,txt"})
+	user := s.CreateUser(model.User{Username: "media_user"}, "password")
+	var mu sync.Mutex
+	events := make(map[string]*common.AccessEvent)
+	common.RegisterAccessHook("test_access_events", 0, func(e *common.AccessEvent) {
+		if strings.HasPrefix(e.Path, "/media/") {
+			mu.Lock()
+			events[e.Path] = e
+			mu.Unlock()
+		}
+	})
+	t.Cleanup(func() {
+		common.UnregisterAccessHook("test_access_events")
+	})
+	get := func(path, signStr, ua string) *common.AccessEvent {
+		req := s.NewRequest(http.MethodGet, "/d"+path+"?sign="+signStr, nil)
+		if ua != "" {
+			req.Header.Set("User-Agent", ua)
+		}
+		if resp := s.Do(req, ""); resp.StatusCode != 200 {
+			t.Fatalf("failed get %s: status %d", path, resp.StatusCode)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return events[path]
+	}
+
+	e := get("/media/file_0.mp4", sign.Sign("/media/file_0.mp4"), "")
+	if e == nil || e.User == nil || !e.User.IsGuest() || e.Type != common.AccessTypeDownload {
+		t.Errorf("unexpected access event: %+v", e)
+	}
+	// the user is recovered from a user-bound sign
+	path := "/media/file_2.mp4"
+	e = get(path, sign.SignWithUser(path, user.Username)+":user:"+user.Username, "VLC/3.0.20 LibVLC/3.0.20")
+	if e == nil || e.User == nil || e.User.Username != user.Username || e.Type != common.AccessTypePlayer {
+		t.Errorf("unexpected access event of a user-bound sign: %+v", e)
+	}
+}
@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader = "X-Request-Id"
+
+// RequestID keeps the id and the client ip of the request in its context, so the drivers
+// can pass them on to the providers. The id of a reverse proxy is kept if it is sane.
+func RequestID(c *gin.Context) {
+	id := c.GetHeader(RequestIDHeader)
+	if !validRequestID(id) {
+		id = random.String(16)
+	}
+	c.Header(RequestIDHeader, id)
+	common.GinWithValue(c, conf.RequestIDKey, id, conf.ClientIPKey, c.ClientIP())
+	c.Next()
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/message"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/sign"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -35,7 +36,9 @@ func Init(e *gin.Engine) {
 	g.GET("/manifest.json", static.ManifestJSON)
 	g.GET("/i/:link_name", handles.Plist)
 	common.SecretKey = []byte(conf.Conf.JwtSecret)
+	g.Use(middlewares.RequestID)
 	g.Use(middlewares.StoragesLoaded)
+	g.Use(middlewares.Hooks(common.HookPreAuth))
 	if conf.Conf.MaxConnections > 0 {
 		g.Use(middlewares.MaxAllowed(conf.Conf.MaxConnections))
 	}
@@ -44,34 +47,46 @@ func Init(e *gin.Engine) {
 
 	downloadLimiter := middlewares.DownloadRateLimiter(stream.ClientDownloadLimit)
 	signCheck := middlewares.Down(sign.Verify)
-	g.GET("/d/*path", middlewares.PathParse, middlewares.AuthOptional, signCheck, downloadLimiter, handles.Down)
-	g.GET("/p/*path", middlewares.PathParse, middlewares.AuthOptional, signCheck, downloadLimiter, handles.Proxy)
-	g.HEAD("/d/*path", middlewares.PathParse, middlewares.AuthOptional, signCheck, handles.Down)
-	g.HEAD("/p/*path", middlewares.PathParse, middlewares.AuthOptional, signCheck, handles.Proxy)
+	g.GET("/d/*path", middlewares.PathParse, middlewares.AuthOptional, signCheck, downloadLimiter, middlewares.DownloadHooks, handles.Down)
+	g.GET("/p/*path", middlewares.PathParse, middlewares.AuthOptional, signCheck, downloadLimiter, middlewares.DownloadHooks, handles.Proxy)
+	g.HEAD("/d/*path", middlewares.PathParse, middlewares.AuthOptional, signCheck, middlewares.DownloadHooks, handles.Down)
+	g.HEAD("/p/*path", middlewares.PathParse, middlewares.AuthOptional, signCheck, middlewares.DownloadHooks, handles.Proxy)
 	archiveSignCheck := middlewares.Down(sign.VerifyArchive)
-	g.GET("/ad/*path", middlewares.PathParse, archiveSignCheck, downloadLimiter, handles.ArchiveDown)
-	g.GET("/ap/*path", middlewares.PathParse, archiveSignCheck, downloadLimiter, handles.ArchiveProxy)
-	g.GET("/ae/*path", middlewares.PathParse, archiveSignCheck, downloadLimiter, handles.ArchiveInternalExtract)
-	g.HEAD("/ad/*path", middlewares.PathParse, archiveSignCheck, handles.ArchiveDown)
-	g.HEAD("/ap/*path", middlewares.PathParse, archiveSignCheck, handles.ArchiveProxy)
-	g.HEAD("/ae/*path", middlewares.PathParse, archiveSignCheck, handles.ArchiveInternalExtract)
-
-	g.GET("/sd/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, middlewares.AuthOptional, downloadLimiter, handles.SharingDown)
-	g.GET("/sd/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, middlewares.AuthOptional, downloadLimiter, handles.SharingDown)
-	g.HEAD("/sd/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, middlewares.AuthOptional, handles.SharingDown)
-	g.HEAD("/sd/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, middlewares.AuthOptional, handles.SharingDown)
-	g.GET("/sad/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, downloadLimiter, handles.SharingArchiveExtract)
-	g.GET("/sad/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, downloadLimiter, handles.SharingArchiveExtract)
-	g.HEAD("/sad/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, handles.SharingArchiveExtract)
-	g.HEAD("/sad/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, handles.SharingArchiveExtract)
-
-	api := g.Group("/api")
-	auth := api.Group("", middlewares.Auth(false))
+	g.GET("/ad/*path", middlewares.PathParse, archiveSignCheck, downloadLimiter, middlewares.DownloadHooks, handles.ArchiveDown)
+	g.GET("/ap/*path", middlewares.PathParse, archiveSignCheck, downloadLimiter, middlewares.DownloadHooks, handles.ArchiveProxy)
+	g.GET("/ae/*path", middlewares.PathParse, archiveSignCheck, downloadLimiter, middlewares.DownloadHooks, handles.ArchiveInternalExtract)
+	g.HEAD("/ad/*path", middlewares.PathParse, archiveSignCheck, middlewares.DownloadHooks, handles.ArchiveDown)
+	g.HEAD("/ap/*path", middlewares.PathParse, archiveSignCheck, middlewares.DownloadHooks, handles.ArchiveProxy)
+	g.HEAD("/ae/*path", middlewares.PathParse, archiveSignCheck, middlewares.DownloadHooks, handles.ArchiveInternalExtract)
+
+	g.GET("/sd/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, middlewares.AuthOptional, downloadLimiter, middlewares.DownloadHooks, handles.SharingDown)
+	g.GET("/sd/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, middlewares.AuthOptional, downloadLimiter, middlewares.DownloadHooks, handles.SharingDown)
+	g.HEAD("/sd/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, middlewares.AuthOptional, middlewares.DownloadHooks, handles.SharingDown)
+	g.HEAD("/sd/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, middlewares.AuthOptional, middlewares.DownloadHooks, handles.SharingDown)
+	g.GET("/sad/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, downloadLimiter, middlewares.DownloadHooks, handles.SharingArchiveExtract)
+	g.GET("/sad/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, downloadLimiter, middlewares.DownloadHooks, handles.SharingArchiveExtract)
+	g.HEAD("/sad/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, middlewares.DownloadHooks, handles.SharingArchiveExtract)
+	g.HEAD("/sad/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, middlewares.DownloadHooks, handles.SharingArchiveExtract)
+
+	// the unversioned routes are v1, kept for existing clients and marked as deprecated
+	_api(g.Group("/api", middlewares.APIVersion(0)))
+	_api(g.Group("/api/v2", middlewares.APIVersion(common.APIVersionV2)))
+	if flags.Debug || flags.Dev {
+		debug(g.Group("/debug"))
+	}
+	static.Static(g, func(handlers ...gin.HandlerFunc) {
+		e.NoRoute(handlers...)
+	})
+}
+
+func _api(api *gin.RouterGroup) {
+	auth := api.Group("", middlewares.Auth(false), middlewares.Hooks(common.HookPostAuth))
 	webauthn := api.Group("/authn", middlewares.Authn)
 
 	api.POST("/auth/login", handles.Login)
 	api.POST("/auth/login/hash", handles.LoginHash)
 	api.POST("/auth/login/ldap", handles.LoginLdap)
+	api.POST("/auth/token", handles.ExchangeToken)
 	auth.GET("/me", handles.CurrentUser)
 	auth.POST("/me/update", handles.UpdateCurrent)
 	auth.GET("/me/sshkey/list", handles.ListMyPublicKey)
@@ -100,18 +115,18 @@ func Init(e *gin.Engine) {
 	public.Any("/settings", handles.PublicSettings)
 	public.Any("/offline_download_tools", handles.OfflineDownloadTools)
 	public.Any("/archive_extensions", handles.ArchiveExtensions)
+	public.Any("/file_types", handles.FileTypes)
+	public.POST("/link_password/unlock", handles.UnlockLink)
+
+	api.POST("/share/:sid/report", handles.ReportSharing)
 
 	_fs(auth.Group("/fs"))
-	fsAndShare(api.Group("/fs", middlewares.Auth(true)))
+	fsAndShare(api.Group("/fs", middlewares.Auth(true), middlewares.Hooks(common.HookPostAuth)))
 	_task(auth.Group("/task", middlewares.AuthNotGuest))
 	_sharing(auth.Group("/share", middlewares.AuthNotGuest))
+	_group(auth.Group("/group", middlewares.AuthGroupOwner))
+	_access(auth.Group("/access", middlewares.AuthAccessReviewer))
 	admin(auth.Group("/admin", middlewares.AuthAdmin))
-	if flags.Debug || flags.Dev {
-		debug(g.Group("/debug"))
-	}
-	static.Static(g, func(handlers ...gin.HandlerFunc) {
-		e.NoRoute(handlers...)
-	})
 }
 
 func admin(g *gin.RouterGroup) {
@@ -122,6 +137,22 @@ func admin(g *gin.RouterGroup) {
 	meta.POST("/update", handles.UpdateMeta)
 	meta.POST("/delete", handles.DeleteMeta)
 
+	g.GET("/scheduled_remove/list", handles.ListScheduledRemoves)
+	g.GET("/link_password/list", handles.ListLinkPasswords)
+
+	watch := g.Group("/watch")
+	watch.GET("/list", handles.ListWatchRules)
+	watch.POST("/create", handles.CreateWatchRule)
+	watch.POST("/update", handles.UpdateWatchRule)
+	watch.POST("/delete", handles.DeleteWatchRule)
+	watch.POST("/scan", handles.ScanWatchRule)
+
+	group := g.Group("/group")
+	group.GET("/list", handles.ListGroups)
+	group.POST("/create", handles.CreateGroup)
+	group.POST("/update", handles.UpdateGroup)
+	group.POST("/delete", handles.DeleteGroup)
+
 	user := g.Group("/user")
 	user.GET("/list", handles.ListUsers)
 	user.GET("/get", handles.GetUser)
@@ -130,6 +161,8 @@ func admin(g *gin.RouterGroup) {
 	user.POST("/cancel_2fa", handles.Cancel2FAById)
 	user.POST("/delete", handles.DeleteUser)
 	user.POST("/del_cache", handles.DelUserCache)
+	user.GET("/export", handles.ExportUserData)
+	user.POST("/erase", handles.EraseUserData)
 	user.GET("/sshkey/list", handles.ListPublicKeys)
 	user.POST("/sshkey/delete", handles.DeletePublicKey)
 
@@ -181,6 +214,30 @@ func admin(g *gin.RouterGroup) {
 	index.POST("/clear", middlewares.SearchIndex, handles.ClearIndex)
 	index.GET("/progress", middlewares.SearchIndex, handles.GetProgress)
 
+	abuseReport := g.Group("/abuse_report")
+	abuseReport.GET("/list", handles.ListAbuseReports)
+	abuseReport.POST("/handle", handles.HandleAbuseReport)
+	abuseReport.POST("/delete", handles.DeleteAbuseReport)
+
+	clientApp := g.Group("/client_app")
+	clientApp.GET("/list", handles.ListClientApps)
+	clientApp.POST("/create", handles.CreateClientApp)
+	clientApp.POST("/update", handles.UpdateClientApp)
+	clientApp.POST("/reset_secret", handles.ResetClientAppSecret)
+	clientApp.POST("/delete", handles.DeleteClientApp)
+
+	killSwitch := g.Group("/kill_switch")
+	killSwitch.GET("/get", handles.GetKillSwitch)
+	killSwitch.POST("/set", handles.SetKillSwitch)
+
+	if op.ChaosAllowed() {
+		chaos := g.Group("/chaos")
+		chaos.GET("/get", handles.GetChaos)
+		chaos.POST("/set", handles.SetChaos)
+	}
+
+	g.POST("/policy/test", handles.TestPolicy)
+
 	scan := g.Group("/scan")
 	scan.POST("/start", handles.StartManualScan)
 	scan.POST("/stop", handles.StopManualScan)
@@ -189,6 +246,7 @@ func admin(g *gin.RouterGroup) {
 
 func fsAndShare(g *gin.RouterGroup) {
 	g.Any("/list", handles.FsListSplit)
+	g.Any("/list/stream", handles.FsListStream)
 	g.Any("/get", handles.FsGetSplit)
 	a := g.Group("/archive")
 	a.Any("/meta", handles.FsArchiveMetaSplit)
@@ -199,6 +257,7 @@ func _fs(g *gin.RouterGroup) {
 	g.Any("/search", middlewares.SearchIndex, handles.Search)
 	g.Any("/other", handles.FsOther)
 	g.Any("/dirs", handles.FsDirs)
+	g.Any("/prefetch", handles.FsPrefetch)
 	g.POST("/mkdir", handles.FsMkdir)
 	g.POST("/rename", handles.FsRename)
 	g.POST("/batch_rename", handles.FsBatchRename)
@@ -208,6 +267,10 @@ func _fs(g *gin.RouterGroup) {
 	g.POST("/copy", handles.FsCopy)
 	g.POST("/remove", handles.FsRemove)
 	g.POST("/remove_empty_directory", handles.FsRemoveEmptyDirectory)
+	g.POST("/schedule_remove", handles.FsScheduleRemove)
+	g.POST("/cancel_scheduled_remove", handles.FsCancelScheduledRemove)
+	g.POST("/link_password/set", handles.SetLinkPassword)
+	g.POST("/link_password/delete", handles.DeleteLinkPassword)
 	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
 	g.PUT("/put", middlewares.FsUp, uploadLimiter, handles.FsStream)
 	g.PUT("/form", middlewares.FsUp, uploadLimiter, handles.FsForm)
@@ -225,6 +288,22 @@ func _task(g *gin.RouterGroup) {
 	handles.SetupTaskRoute(g)
 }
 
+func _group(g *gin.RouterGroup) {
+	g.GET("/get", handles.GetOwnGroup)
+	g.GET("/member/list", handles.ListGroupMembers)
+	g.POST("/member/create", handles.CreateGroupMember)
+	g.POST("/member/update", handles.UpdateGroupMember)
+	g.POST("/member/delete", handles.DeleteGroupMember)
+}
+
+func _access(g *gin.RouterGroup) {
+	g.GET("/audit", handles.AuditPathGrants)
+	g.POST("/grant/request", handles.RequestPathGrant)
+	g.POST("/grant/approve", handles.ApprovePathGrant)
+	g.POST("/grant/reject", handles.RejectPathGrant)
+	g.POST("/grant/revoke", handles.RevokePathGrant)
+}
+
 func _sharing(g *gin.RouterGroup) {
 	g.Any("/list", handles.ListSharings)
 	g.GET("/get", handles.GetSharing)
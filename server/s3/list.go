@@ -40,7 +40,7 @@ func (b *s3Backend) entryListR(bucket, fdPath, name string, addPrefix bool, resp
 		// workround for control-chars detect
 		objectPath := path.Join(fdPath, object)
 
-		if !strings.HasPrefix(object, name) {
+		if !strings.HasPrefix(object, name) || isRestricted(path.Join(bucket, objectPath)) {
 			continue
 		}
 
@@ -42,7 +42,16 @@ func getBucketByName(name string) (Bucket, error) {
 	return Bucket{}, gofakes3.BucketNotFound(name)
 }
 
+// isRestricted reports whether path is restricted, the s3 server has no user that could hold a grant
+func isRestricted(path string) bool {
+	_, ok := op.GetRestrictedPath(path)
+	return ok
+}
+
 func getDirEntries(path string) ([]model.Obj, error) {
+	if isRestricted(path) {
+		return nil, gofakes3.ErrNoSuchKey
+	}
 	ctx := context.Background()
 	meta, _ := op.GetNearestMeta(path)
 	fi, err := fs.Get(context.WithValue(ctx, conf.MetaKey, meta), path, &fs.GetArgs{})
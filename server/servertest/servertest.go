@@ -0,0 +1,302 @@
+// Package servertest runs the whole router against an in-memory database and
+// the mock driver, so that requests go through the real middleware chain.
+package servertest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/OpenListTeam/OpenList/v4/drivers/mock"
+	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/data"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var (
+	initOnce sync.Once
+	initErr  error
+	engine   *gin.Engine
+)
+
+const indexHtml = `<!DOCTYPE html><html><head></head><body></body></html>`
+
+// Setup initializes the global state once per process, the same way bootstrap does,
+// and returns the engine with every route registered
+func Setup() (*gin.Engine, error) {
+	initOnce.Do(func() {
+		initErr = setup()
+	})
+	return engine, initErr
+}
+
+func setup() error {
+	dir, err := os.MkdirTemp("", "openlist-servertest")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	conf.Conf = conf.DefaultConfig(dir)
+	conf.Conf.Log.Enable = false
+	conf.Conf.DistDir = filepath.Join(dir, "dist")
+	if err = os.MkdirAll(conf.Conf.DistDir, 0o777); err != nil {
+		return errors.WithStack(err)
+	}
+	if err = os.WriteFile(filepath.Join(conf.Conf.DistDir, "index.html"), []byte(indexHtml), 0o666); err != nil {
+		return errors.WithStack(err)
+	}
+	conf.URL, err = url.Parse(conf.Conf.SiteURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	dB, err := gorm.Open(sqlite.Open("file:servertest?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	base.InitClient()
+	db.Init(dB)
+	data.InitData()
+	conf.SendStoragesLoadedSignal()
+	gin.SetMode(gin.TestMode)
+	engine = gin.New()
+	server.Init(engine)
+	return nil
+}
+
+type Server struct {
+	*httptest.Server
+	t testing.TB
+}
+
+// New starts a test server, it is closed when the test finishes.
+// All servers share the same database, so tests using them must not run in parallel.
+func New(t testing.TB) *Server {
+	t.Helper()
+	e, err := Setup()
+	if err != nil {
+		t.Fatalf("failed init test server: %+v", err)
+	}
+	s := &Server{Server: httptest.NewServer(e), t: t}
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Mount adds a mock storage at mountPath and removes it when the test finishes
+func (s *Server) Mount(mountPath string, addition mock.Addition) *mock.Mock {
+	s.t.Helper()
+	return s.MountStorage(model.Storage{MountPath: mountPath}, addition)
+}
+
+// MountStorage is like Mount, but keeps the other fields of storage, such as WebProxy or DownProxyURL
+func (s *Server) MountStorage(storage model.Storage, addition mock.Addition) *mock.Mock {
+	s.t.Helper()
+	additionStr, err := utils.Json.MarshalToString(addition)
+	if err != nil {
+		s.t.Fatalf("failed marshal addition: %+v", err)
+	}
+	storage.Driver = "Mock"
+	storage.Addition = additionStr
+	id, err := op.CreateStorage(context.Background(), storage)
+	if err != nil {
+		s.t.Fatalf("failed create mock storage: %+v", err)
+	}
+	s.t.Cleanup(func() {
+		if err := op.DeleteStorageById(context.Background(), id); err != nil {
+			s.t.Errorf("failed delete mock storage: %+v", err)
+		}
+	})
+	d, err := op.GetStorageByMountPath(utils.FixAndCleanPath(storage.MountPath))
+	if err != nil {
+		s.t.Fatalf("failed get mock storage: %+v", err)
+	}
+	return d.(*mock.Mock)
+}
+
+// CreateUser creates the user with the given password and deletes it when the test finishes
+func (s *Server) CreateUser(user model.User, password string) *model.User {
+	s.t.Helper()
+	u := &user
+	u.SetPassword(password)
+	if u.BasePath == "" {
+		u.BasePath = "/"
+	}
+	if u.Authn == "" {
+		u.Authn = "[]"
+	}
+	if err := op.CreateUser(u); err != nil {
+		s.t.Fatalf("failed create user: %+v", err)
+	}
+	s.t.Cleanup(func() {
+		if err := op.DeleteUserById(u.ID); err != nil {
+			s.t.Errorf("failed delete user: %+v", err)
+		}
+	})
+	return u
+}
+
+// UpdateUser changes the user with fn and restores it when the test finishes
+func (s *Server) UpdateUser(username string, fn func(u *model.User)) {
+	s.t.Helper()
+	u, err := op.GetUserByName(username)
+	if err != nil {
+		s.t.Fatalf("failed get user: %+v", err)
+	}
+	old := *u
+	updated := *u
+	fn(&updated)
+	if err = op.UpdateUser(&updated); err != nil {
+		s.t.Fatalf("failed update user: %+v", err)
+	}
+	s.t.Cleanup(func() {
+		if err := op.UpdateUser(&old); err != nil {
+			s.t.Errorf("failed restore user: %+v", err)
+		}
+	})
+}
+
+// SetSetting changes the value of a setting and restores it when the test finishes
+func (s *Server) SetSetting(key, value string) {
+	s.t.Helper()
+	item, err := op.GetSettingItemByKey(key)
+	if err != nil {
+		s.t.Fatalf("failed get setting %s: %+v", key, err)
+	}
+	old := *item
+	updated := *item
+	updated.Value = value
+	if err = op.SaveSettingItem(&updated); err != nil {
+		s.t.Fatalf("failed save setting %s: %+v", key, err)
+	}
+	s.t.Cleanup(func() {
+		if err := op.SaveSettingItem(&old); err != nil {
+			s.t.Errorf("failed restore setting %s: %+v", key, err)
+		}
+	})
+}
+
+// Token returns a login token of the user
+func (s *Server) Token(user *model.User) string {
+	s.t.Helper()
+	token, err := common.GenerateToken(user)
+	if err != nil {
+		s.t.Fatalf("failed generate token: %+v", err)
+	}
+	return token
+}
+
+// AdminToken returns a login token of the admin user
+func (s *Server) AdminToken() string {
+	s.t.Helper()
+	admin, err := op.GetAdmin()
+	if err != nil {
+		s.t.Fatalf("failed get admin: %+v", err)
+	}
+	return s.Token(admin)
+}
+
+// Logs captures the entries of the standard logger until the test finishes
+func (s *Server) Logs() *test.Hook {
+	hook := new(test.Hook)
+	logger := logrus.StandardLogger()
+	old := logger.ReplaceHooks(make(logrus.LevelHooks))
+	for _, hooks := range old {
+		for _, h := range hooks {
+			logger.AddHook(h)
+		}
+	}
+	logger.AddHook(hook)
+	s.t.Cleanup(func() {
+		logger.ReplaceHooks(old)
+	})
+	return hook
+}
+
+// Do sends the request with the token, the response body is closed when the test finishes
+func (s *Server) Do(req *http.Request, token string) *http.Response {
+	s.t.Helper()
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	// don't follow redirects, tests usually want to check them
+	client := *s.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		s.t.Fatalf("failed %s %s: %+v", req.Method, req.URL, err)
+	}
+	s.t.Cleanup(func() {
+		_ = resp.Body.Close()
+	})
+	return resp
+}
+
+func (s *Server) NewRequest(method, path string, body io.Reader) *http.Request {
+	s.t.Helper()
+	req, err := http.NewRequest(method, s.URL+path, body)
+	if err != nil {
+		s.t.Fatalf("failed create request: %+v", err)
+	}
+	return req
+}
+
+func (s *Server) Get(path, token string) *http.Response {
+	s.t.Helper()
+	return s.Do(s.NewRequest(http.MethodGet, path, nil), token)
+}
+
+// PostJSON posts v as json to an api and decodes the response into a common.Resp
+func PostJSON[T any](s *Server, path, token string, v any) common.Resp[T] {
+	s.t.Helper()
+	body, err := utils.Json.Marshal(v)
+	if err != nil {
+		s.t.Fatalf("failed marshal request: %+v", err)
+	}
+	req := s.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return DecodeResp[T](s, s.Do(req, token))
+}
+
+// GetJSON gets an api and decodes the response into a common.Resp
+func GetJSON[T any](s *Server, path, token string) common.Resp[T] {
+	s.t.Helper()
+	return DecodeResp[T](s, s.Get(path, token))
+}
+
+func DecodeResp[T any](s *Server, resp *http.Response) common.Resp[T] {
+	s.t.Helper()
+	var res common.Resp[T]
+	if err := utils.Json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		s.t.Fatalf("failed decode response: %+v", err)
+	}
+	return res
+}
+
+// ReadBody reads the whole body of the response
+func (s *Server) ReadBody(resp *http.Response) []byte {
+	s.t.Helper()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		s.t.Fatalf("failed read response: %+v", err)
+	}
+	return b
+}
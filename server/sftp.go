@@ -89,6 +89,9 @@ func (d *SftpDriver) NoClientAuth(conn ssh.ConnMetadata) (*ssh.Permissions, erro
 	if guest.Disabled || !guest.CanFTPAccess() {
 		return nil, errors.New("user is not allowed to access via SFTP")
 	}
+	if common.IsKillSwitchOn() {
+		return nil, errors.New("guest access is temporarily disabled")
+	}
 	return nil, nil
 }
 
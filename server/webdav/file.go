@@ -91,6 +91,10 @@ func walkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn
 	if depth == 1 {
 		depth = 0
 	}
+	// the content of the restricted dirs is only listed with a grant
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok && !op.CanAccessRestricted(user, name) {
+		return nil
+	}
 	meta, _ := op.GetNearestMeta(name)
 	// Read directory names.
 	objs, err := fs.List(context.WithValue(ctx, conf.MetaKey, meta), name, &fs.ListArgs{})
@@ -50,6 +50,18 @@ func (h *Handler) stripPrefix(p string) (string, int, error) {
 	return p, http.StatusNotFound, errPrefixMismatch
 }
 
+// joinPath joins the path to the base path of user, the restricted paths need a grant of user
+func joinPath(user *model.User, reqPath string) (string, error) {
+	reqPath, err := user.JoinPath(reqPath)
+	if err != nil {
+		return "", err
+	}
+	if !common.CanAccessRestricted(user, reqPath) {
+		return "", errs.PermissionDenied
+	}
+	return reqPath, nil
+}
+
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	status, err := http.StatusBadRequest, errUnsupportedMethod
 	brw := newBufferedResponseWriter()
@@ -198,7 +210,7 @@ func (h *Handler) handleOptions(w http.ResponseWriter, r *http.Request) (status
 	}
 	ctx := r.Context()
 	user := ctx.Value(conf.UserKey).(*model.User)
-	reqPath, err = user.JoinPath(reqPath)
+	reqPath, err = joinPath(user, reqPath)
 	if err != nil {
 		return 403, err
 	}
@@ -226,7 +238,7 @@ func (h *Handler) handleGetHeadPost(w http.ResponseWriter, r *http.Request) (sta
 	// TODO: check locks for read-only access??
 	ctx := r.Context()
 	user := ctx.Value(conf.UserKey).(*model.User)
-	reqPath, err = user.JoinPath(reqPath)
+	reqPath, err = joinPath(user, reqPath)
 	if err != nil {
 		return http.StatusForbidden, err
 	}
@@ -294,7 +306,7 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) (status i
 
 	ctx := r.Context()
 	user := ctx.Value(conf.UserKey).(*model.User)
-	reqPath, err = user.JoinPath(reqPath)
+	reqPath, err = joinPath(user, reqPath)
 	if err != nil {
 		return 403, err
 	}
@@ -339,7 +351,7 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	// comments in http.checkEtag.
 	ctx := r.Context()
 	user := ctx.Value(conf.UserKey).(*model.User)
-	reqPath, err = user.JoinPath(reqPath)
+	reqPath, err = joinPath(user, reqPath)
 	if err != nil {
 		return http.StatusForbidden, err
 	}
@@ -405,7 +417,7 @@ func (h *Handler) handleMkcol(w http.ResponseWriter, r *http.Request) (status in
 
 	ctx := r.Context()
 	user := ctx.Value(conf.UserKey).(*model.User)
-	reqPath, err = user.JoinPath(reqPath)
+	reqPath, err = joinPath(user, reqPath)
 	if err != nil {
 		return 403, err
 	}
@@ -469,11 +481,11 @@ func (h *Handler) handleCopyMove(w http.ResponseWriter, r *http.Request) (status
 
 	ctx := r.Context()
 	user := ctx.Value(conf.UserKey).(*model.User)
-	src, err = user.JoinPath(src)
+	src, err = joinPath(user, src)
 	if err != nil {
 		return 403, err
 	}
-	dst, err = user.JoinPath(dst)
+	dst, err = joinPath(user, dst)
 	if err != nil {
 		return 403, err
 	}
@@ -570,7 +582,7 @@ func (h *Handler) handleLock(w http.ResponseWriter, r *http.Request) (retStatus
 		if err != nil {
 			return status, err
 		}
-		reqPath, err = user.JoinPath(reqPath)
+		reqPath, err = joinPath(user, reqPath)
 		if err != nil {
 			return 403, err
 		}
@@ -653,7 +665,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 	userAgent := r.Header.Get("User-Agent")
 	ctx = context.WithValue(ctx, conf.UserAgentKey, userAgent)
 	user := ctx.Value(conf.UserKey).(*model.User)
-	reqPath, err = user.JoinPath(reqPath)
+	reqPath, err = joinPath(user, reqPath)
 	if err != nil {
 		return 403, err
 	}
@@ -732,7 +744,7 @@ func (h *Handler) handleProppatch(w http.ResponseWriter, r *http.Request) (statu
 
 	ctx := r.Context()
 	user := ctx.Value(conf.UserKey).(*model.User)
-	reqPath, err = user.JoinPath(reqPath)
+	reqPath, err = joinPath(user, reqPath)
 	if err != nil {
 		return 403, err
 	}